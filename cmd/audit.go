@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
+	"strings"
 
+	"github.com/CanopyHQ/phloem/internal/memory"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/cobra"
 )
@@ -23,8 +27,9 @@ var auditCmd = &cobra.Command{
 Checks:
   1. Data inventory — lists all files in ~/.phloem/ with sizes
   2. Permissions — verifies files are user-readable only
-  3. Schema — shows SQLite tables and row counts (no content)
-  4. Network — instructions to verify zero network activity
+  3. Encryption — whether memory content is encrypted at rest
+  4. Schema — shows SQLite tables, row counts and embedding storage (no content)
+  5. Network — instructions to verify zero network activity
 
 Run this anytime to confirm Phloem respects your privacy.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -53,11 +58,107 @@ func fileDescription(name string) string {
 		return "SQLite write-ahead log (temporary)"
 	case "memories.db-shm":
 		return "SQLite shared memory file (temporary)"
+	case "trusted_keys":
+		return "Public keys of trusted graft signers"
+	case memory.DefaultKeyFileName:
+		return "Memory encryption key"
+	}
+	switch filepath.Ext(name) {
+	case ".key":
+		return "Graft signing private key"
+	case ".pub":
+		return "Graft signing public key"
 	default:
 		return ""
 	}
 }
 
+// auditEncryption reports whether memory content is encrypted at rest, without needing the key.
+// Returns the number of issues found.
+func auditEncryption(dbPath string) int {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		fmt.Println("  Database not found — nothing to encrypt yet.")
+		return 0
+	}
+	db, err := sql.Open("sqlite3", dbPath+"?mode=ro")
+	if err != nil {
+		fmt.Printf("  ⚠️  Cannot open database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	status, err := memory.ReadEncryptionStatus(context.Background(), db)
+	if err != nil {
+		fmt.Printf("  ⚠️  Cannot read encryption status: %v\n", err)
+		return 1
+	}
+
+	if status.Config == nil {
+		fmt.Println("  Status: not encrypted")
+		fmt.Printf("  %d value(s) of memory content, context and citations stored in plaintext\n", status.Plaintext)
+		fmt.Println("  Enable with: phloem encrypt")
+		return 0
+	}
+
+	issues := 0
+	fmt.Println("  Status: ✅ encrypted (AES-256-GCM)")
+	switch status.Config.Method {
+	case memory.EncryptionPassphrase:
+		fmt.Printf("  Key:    derived from PHLOEM_PASSPHRASE (PBKDF2-SHA256, %d rounds)\n", status.Config.Iterations)
+	default:
+		keyFile := os.Getenv("PHLOEM_KEY_FILE")
+		if keyFile == "" {
+			keyFile = status.Config.KeyFile
+		}
+		fmt.Printf("  Key:    %s", keyFile)
+		if info, err := os.Stat(keyFile); err != nil {
+			fmt.Println("  ⚠️  not found")
+			issues++
+		} else if info.Mode().Perm()&0077 != 0 {
+			fmt.Printf("  ⚠️  WARNING: mode %04o, readable by others\n", info.Mode().Perm())
+			fmt.Printf("    Fix: chmod 600 %s\n", keyFile)
+			issues++
+		} else {
+			fmt.Println("  ✅ OK")
+		}
+	}
+	fmt.Printf("  Encrypted values: %d\n", status.Sealed)
+	if status.Plaintext > 0 {
+		fmt.Printf("  ⚠️  %d value(s) still stored in plaintext\n", status.Plaintext)
+		issues++
+	}
+	fmt.Println("  Not encrypted: embeddings (needed for recall), tags, scopes and timestamps")
+	return issues
+}
+
+// auditEmbeddingStorage reports how embeddings are stored and the space saved by not
+// storing them as JSON
+func auditEmbeddingStorage(db *sql.DB) {
+	stats, err := memory.ReadEmbeddingStorage(context.Background(), db)
+	if err != nil {
+		fmt.Printf("  ⚠️  Cannot read embedding storage: %v\n", err)
+		return
+	}
+	if stats.Vectors == 0 {
+		return
+	}
+	formats := make([]string, 0, len(stats.ByFormat))
+	for format, n := range stats.ByFormat {
+		formats = append(formats, fmt.Sprintf("%s: %d", format, n))
+	}
+	sort.Strings(formats)
+	fmt.Println()
+	fmt.Printf("  Embeddings: %d vector(s) (%s)\n", stats.Vectors, strings.Join(formats, ", "))
+	fmt.Printf("  Stored in %s; as JSON: %s", humanSize(stats.Bytes), humanSize(stats.JSONBytes))
+	if stats.SavedBytes > 0 {
+		fmt.Printf(" (%s saved)", humanSize(stats.SavedBytes))
+	}
+	fmt.Println()
+	if n := stats.ByFormat["json"]; n > 0 {
+		fmt.Printf("  %d vector(s) still stored as JSON by an earlier version\n", n)
+	}
+}
+
 func runAudit() error {
 	fmt.Println("🔒 Phloem Privacy Audit")
 	fmt.Println()
@@ -156,9 +257,18 @@ func runAudit() error {
 	}
 	fmt.Println()
 
-	// ── Section 3: Database Schema ─────────────────────────────────────
+	// ── Section 3: Encryption ──────────────────────────────────────────
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("🗃️  Section 3: Database Schema")
+	fmt.Println("🔑 Section 3: Encryption")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println()
+
+	issues += auditEncryption(dbPath)
+	fmt.Println()
+
+	// ── Section 4: Database Schema ─────────────────────────────────────
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("🗃️  Section 4: Database Schema")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println()
 
@@ -202,6 +312,7 @@ func runAudit() error {
 					fmt.Println("  No tables found (empty database).")
 				}
 			}
+			auditEmbeddingStorage(db)
 		}
 	}
 	fmt.Println()
@@ -209,14 +320,17 @@ func runAudit() error {
 	fmt.Println("  No memory content is ever printed by this command.")
 	fmt.Println()
 
-	// ── Section 4: Network Verification ────────────────────────────────
+	// ── Section 5: Network Verification ────────────────────────────────
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("🌐 Section 4: Network Verification")
+	fmt.Println("🌐 Section 5: Network Verification")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println()
 	fmt.Println("  Phloem makes zero network connections. Verify by running")
 	fmt.Println("  the commands below while phloem is active:")
 	fmt.Println()
+	fmt.Println("  (If you run 'phloem serve --http', expect one listener on")
+	fmt.Println("  127.0.0.1 or ::1 only — never on an external interface.)")
+	fmt.Println()
 
 	if runtime.GOOS == "darwin" {
 		fmt.Println("  macOS:")
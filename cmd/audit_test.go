@@ -64,6 +64,10 @@ func TestRunAudit_WithMemories(t *testing.T) {
 	if !strings.Contains(out, "memories.db") {
 		t.Errorf("expected memories.db in data inventory: %q", out)
 	}
+	// Should show how embeddings are stored
+	if !strings.Contains(out, "Embeddings: 1 vector(s) (float32: 1)") || !strings.Contains(out, "as JSON:") {
+		t.Errorf("expected embedding storage in output: %q", out)
+	}
 }
 
 func TestExecute_Audit(t *testing.T) {
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+	"github.com/spf13/cobra"
+)
+
+var consolidateCmd = &cobra.Command{
+	Use:   "consolidate",
+	Short: "Merge near-duplicate memories",
+	Long: `Find memories that say the same thing in different words and merge
+each group into its newest memory.
+
+Tags are combined, citations and graph edges move to the kept memory, and
+the merged IDs keep resolving to it. Memories in different scopes, memories
+linked as superseding or contradicting each other, and memories whose texts
+differ in numbers or negation ("15 minutes" and "30 minutes") are never merged.
+The same pass runs as part of nightly curation.
+
+Examples:
+  phloem consolidate --dry-run        # review proposed merges
+  phloem consolidate
+  phloem consolidate --threshold 0.95 # only merge very close matches`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+		return runConsolidate(dryRun, threshold)
+	},
+}
+
+func init() {
+	consolidateCmd.Flags().Bool("dry-run", false, "Report proposed merges without changing anything")
+	consolidateCmd.Flags().Float64("threshold", memory.DefaultConsolidationThreshold, "Minimum similarity (0-1) to treat memories as duplicates")
+}
+
+// runConsolidate merges (or with dryRun, reports) clusters of near-duplicate memories
+func runConsolidate(dryRun bool, threshold float64) error {
+	if threshold <= 0 || threshold > 1 {
+		return fmt.Errorf("threshold must be between 0 and 1, got %g", threshold)
+	}
+
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	result, err := store.Consolidate(context.Background(), memory.ConsolidateOptions{Threshold: threshold, DryRun: dryRun})
+	if err != nil {
+		return fmt.Errorf("consolidation failed: %w", err)
+	}
+
+	if len(result.Groups) == 0 {
+		fmt.Printf("✅ No near-duplicates found (threshold %.2f)\n", threshold)
+		return nil
+	}
+
+	duplicates := 0
+	for _, g := range result.Groups {
+		duplicates += len(g.Duplicates)
+		fmt.Printf("\nKeep  %s  %s\n", g.Canonical.ID, previewContent(g.Canonical.Content))
+		for _, d := range g.Duplicates {
+			fmt.Printf("  ↳ %s  (%.2f)  %s\n", d.ID, d.Similarity, previewContent(d.Content))
+		}
+	}
+	fmt.Println()
+
+	if dryRun {
+		fmt.Printf("Dry run: %d duplicate(s) in %d group(s) would be merged. Run without --dry-run to apply.\n", duplicates, len(result.Groups))
+		return nil
+	}
+	fmt.Printf("✅ Merged %d duplicate(s) into %d memories\n", result.Merged, len(result.Groups))
+	return nil
+}
+
+// previewContent returns the first line of content, shortened for one-line listings
+func previewContent(content string) string {
+	line := strings.TrimSpace(strings.SplitN(content, "\n", 2)[0])
+	if len(line) > 70 {
+		return line[:67] + "..."
+	}
+	return line
+}
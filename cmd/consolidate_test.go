@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+func TestExecute_Consolidate(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("PHLOEM_DATA_DIR", tmpDir)
+	defer os.Unsetenv("PHLOEM_DATA_DIR")
+
+	store, err := memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	first, _ := store.Remember(ctx, "The API uses JWT tokens that expire after 15 minutes", nil, "")
+	dup, _ := store.Remember(ctx, "The API uses JWT tokens which expire after 15 minutes.", nil, "")
+	store.Close()
+
+	restore := setArgs("phloem", "consolidate", "--dry-run")
+	out, _ := captureStdout(func() {
+		if e := Execute(); e != nil {
+			t.Fatalf("Execute(consolidate --dry-run): %v", e)
+		}
+	})
+	restore()
+	if !strings.Contains(out, "Dry run") || !strings.Contains(out, first.ID) {
+		t.Errorf("expected dry-run report listing %s: %s", first.ID, out)
+	}
+
+	// Flag values persist on the shared command between Execute calls
+	consolidateCmd.Flags().Set("dry-run", "false")
+	defer setArgs("phloem", "consolidate")()
+	out, _ = captureStdout(func() {
+		if e := Execute(); e != nil {
+			t.Fatalf("Execute(consolidate): %v", e)
+		}
+	})
+	if !strings.Contains(out, "Merged 1") {
+		t.Errorf("expected merge summary: %s", out)
+	}
+
+	store, err = memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	resolved, err := store.GetMemoryByID(ctx, first.ID)
+	if err != nil || resolved == nil || resolved.ID != dup.ID {
+		t.Errorf("merged ID should resolve to %s, got %v (err %v)", dup.ID, resolved, err)
+	}
+}
+
+func TestRunConsolidate_InvalidThreshold(t *testing.T) {
+	if err := runConsolidate(true, 1.5); err == nil {
+		t.Error("expected error for threshold > 1")
+	}
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <memory_id>",
+	Short: "Edit a memory in place (keeps revision history)",
+	Long: `Edit an existing memory's content, tags, or context.
+
+Prior versions are kept, so you can view how a memory evolved and roll
+back a bad edit. Without --content, --tags or --context the memory
+content is opened in $EDITOR.
+
+Examples:
+  phloem edit abc123 --content "JWT expiry is 30 minutes"
+  phloem edit abc123 --tags "auth,decision"
+  phloem edit abc123                 # open in $EDITOR
+  phloem edit abc123 --history       # list prior versions
+  phloem edit abc123 --revert 2      # restore revision 2`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		history, _ := cmd.Flags().GetBool("history")
+		if history {
+			return runEditHistory(args[0])
+		}
+		if cmd.Flags().Changed("revert") {
+			revision, _ := cmd.Flags().GetInt("revert")
+			return runEditRevert(args[0], revision)
+		}
+
+		var upd memory.MemoryUpdate
+		if cmd.Flags().Changed("content") {
+			content, _ := cmd.Flags().GetString("content")
+			upd.Content = &content
+		}
+		if cmd.Flags().Changed("tags") {
+			tagsStr, _ := cmd.Flags().GetString("tags")
+			tags := []string{}
+			for _, t := range strings.Split(tagsStr, ",") {
+				if s := strings.TrimSpace(t); s != "" {
+					tags = append(tags, s)
+				}
+			}
+			upd.Tags = &tags
+		}
+		if cmd.Flags().Changed("context") {
+			memContext, _ := cmd.Flags().GetString("context")
+			upd.Context = &memContext
+		}
+		return runEdit(args[0], upd)
+	},
+}
+
+func init() {
+	editCmd.Flags().String("content", "", "New content")
+	editCmd.Flags().String("tags", "", "Replacement comma-separated tags")
+	editCmd.Flags().String("context", "", "Replacement context")
+	editCmd.Flags().Bool("history", false, "Show revision history instead of editing")
+	editCmd.Flags().Int("revert", 0, "Restore the given revision number")
+}
+
+// runEdit applies an update to a memory, opening $EDITOR when no fields are given
+func runEdit(memoryID string, upd memory.MemoryUpdate) error {
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+
+	if upd.Content == nil && upd.Tags == nil && upd.Context == nil {
+		mem, err := store.GetMemoryByID(ctx, memoryID)
+		if err != nil {
+			return fmt.Errorf("failed to load memory: %w", err)
+		}
+		if mem == nil {
+			return fmt.Errorf("memory not found: %s", memoryID)
+		}
+		content, err := editInEditor(mem.Content)
+		if err != nil {
+			return err
+		}
+		if content == "" || content == mem.Content {
+			fmt.Println("No changes.")
+			return nil
+		}
+		upd.Content = &content
+	}
+
+	mem, err := store.Update(ctx, memoryID, upd)
+	if err != nil {
+		return fmt.Errorf("edit failed: %w", err)
+	}
+	revisions, _ := store.GetRevisions(ctx, memoryID)
+	fmt.Printf("✅ Updated %s (%d prior version(s) kept)\n", mem.ID, len(revisions))
+	return nil
+}
+
+// runEditHistory prints the revision history of a memory
+func runEditHistory(memoryID string) error {
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	mem, err := store.GetMemoryByID(ctx, memoryID)
+	if err != nil {
+		return fmt.Errorf("failed to load memory: %w", err)
+	}
+	if mem == nil {
+		return fmt.Errorf("memory not found: %s", memoryID)
+	}
+	revisions, err := store.GetRevisions(ctx, memoryID)
+	if err != nil {
+		return fmt.Errorf("failed to get revisions: %w", err)
+	}
+
+	if len(revisions) == 0 {
+		fmt.Printf("Memory %s has no prior versions.\n", memoryID)
+	}
+	for _, r := range revisions {
+		fmt.Printf("Revision %d (replaced %s)\n", r.Revision, r.CreatedAt.Format("Jan 2 2006 15:04"))
+		if len(r.Tags) > 0 {
+			fmt.Printf("  Tags: %s\n", strings.Join(r.Tags, ", "))
+		}
+		fmt.Printf("  %s\n\n", r.Content)
+	}
+	fmt.Printf("Current (updated %s)\n", mem.UpdatedAt.Format("Jan 2 2006 15:04"))
+	if len(mem.Tags) > 0 {
+		fmt.Printf("  Tags: %s\n", strings.Join(mem.Tags, ", "))
+	}
+	fmt.Printf("  %s\n", mem.Content)
+	return nil
+}
+
+// runEditRevert restores a memory to a prior revision
+func runEditRevert(memoryID string, revision int) error {
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	if _, err := store.RevertMemory(context.Background(), memoryID, revision); err != nil {
+		return fmt.Errorf("revert failed: %w", err)
+	}
+	fmt.Printf("✅ Reverted %s to revision %d\n", memoryID, revision)
+	return nil
+}
+
+// editInEditor opens content in $EDITOR (default vi) and returns the edited text
+func editInEditor(content string) (string, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "phloem-edit-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	f.Close()
+
+	parts := strings.Fields(editor)
+	c := exec.Command(parts[0], append(parts[1:], f.Name())...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited content: %w", err)
+	}
+	return strings.TrimSpace(string(edited)), nil
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+func TestExecute_Edit(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("PHLOEM_DATA_DIR", tmpDir)
+	defer os.Unsetenv("PHLOEM_DATA_DIR")
+
+	store, err := memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem, err := store.Remember(context.Background(), "original content", nil, "")
+	store.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restore := setArgs("phloem", "edit", mem.ID, "--content", "edited content", "--tags", "a,b")
+	out, _ := captureStdout(func() {
+		if e := Execute(); e != nil {
+			t.Fatalf("Execute(edit): %v", e)
+		}
+	})
+	restore()
+	if !strings.Contains(out, "Updated") {
+		t.Errorf("expected 'Updated' in output: %s", out)
+	}
+
+	restore = setArgs("phloem", "edit", mem.ID, "--history")
+	out, _ = captureStdout(func() {
+		if e := Execute(); e != nil {
+			t.Fatalf("Execute(edit --history): %v", e)
+		}
+	})
+	restore()
+	if !strings.Contains(out, "original content") || !strings.Contains(out, "edited content") {
+		t.Errorf("history should show both versions: %s", out)
+	}
+
+	// Flag values persist on the shared command between Execute calls
+	editCmd.Flags().Set("history", "false")
+	defer setArgs("phloem", "edit", mem.ID, "--revert", "1")()
+	if e := Execute(); e != nil {
+		t.Fatalf("Execute(edit --revert): %v", e)
+	}
+
+	store, err = memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	got, _ := store.GetMemoryByID(context.Background(), mem.ID)
+	if got == nil || got.Content != "original content" {
+		t.Errorf("expected reverted content, got %+v", got)
+	}
+}
+
+func TestExecute_Edit_NotFound(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("PHLOEM_DATA_DIR", tmpDir)
+	defer os.Unsetenv("PHLOEM_DATA_DIR")
+
+	defer setArgs("phloem", "edit", "nope", "--content", "x")()
+	if err := Execute(); err == nil {
+		t.Error("expected error for unknown memory")
+	}
+}
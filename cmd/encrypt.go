@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+	"github.com/spf13/cobra"
+)
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt memory content at rest",
+	Long: `Encrypt the content and context of every memory, their revision history
+and citation snippets in memories.db with AES-256-GCM.
+
+By default a random key is written to ~/.phloem/encryption.key (mode 0600).
+Back it up: without it your memories cannot be recovered. Move it elsewhere
+and point PHLOEM_KEY_FILE at it to keep the key off the data directory.
+
+With --passphrase the key is derived from PHLOEM_PASSPHRASE instead, and
+every phloem process (including 'phloem serve') needs that variable set.
+
+Embeddings are not encrypted because recall needs them, and keyword
+(full-text) search is disabled while the database is encrypted.
+
+Examples:
+  phloem encrypt
+  phloem encrypt --key-file /Volumes/keys/phloem.key
+  PHLOEM_PASSPHRASE=... phloem encrypt --passphrase`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyFile, _ := cmd.Flags().GetString("key-file")
+		passphrase, _ := cmd.Flags().GetBool("passphrase")
+		return runEncrypt(keyFile, passphrase)
+	},
+}
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt memories.db back to plaintext",
+	Long: `Decrypt everything encrypted by 'phloem encrypt' and rebuild the
+full-text index. The key is found the same way as for every other command
+(PHLOEM_KEY_FILE, the key file recorded at encryption time, or
+PHLOEM_PASSPHRASE).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDecrypt()
+	},
+}
+
+func init() {
+	encryptCmd.Flags().String("key-file", "", "Key file to use, created if missing (default ~/.phloem/encryption.key)")
+	encryptCmd.Flags().Bool("passphrase", false, "Derive the key from PHLOEM_PASSPHRASE instead of a key file")
+}
+
+func runEncrypt(keyFile string, usePassphrase bool) error {
+	if usePassphrase && keyFile != "" {
+		return fmt.Errorf("use either --key-file or --passphrase, not both")
+	}
+
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	if store.Encrypted() {
+		fmt.Println("🔒 memories.db is already encrypted")
+		return nil
+	}
+
+	var key []byte
+	var cfg memory.EncryptionConfig
+	if usePassphrase {
+		passphrase := os.Getenv("PHLOEM_PASSPHRASE")
+		if passphrase == "" {
+			return fmt.Errorf("--passphrase needs the passphrase in PHLOEM_PASSPHRASE")
+		}
+		if key, cfg, err = memory.NewPassphraseConfig(passphrase); err != nil {
+			return err
+		}
+	} else {
+		if keyFile == "" {
+			keyFile = filepath.Join(dataDir(), memory.DefaultKeyFileName)
+		}
+		if keyFile, err = filepath.Abs(keyFile); err != nil {
+			return fmt.Errorf("invalid key file path: %w", err)
+		}
+		if _, statErr := os.Stat(keyFile); statErr == nil {
+			key, err = memory.LoadKeyFile(keyFile)
+		} else {
+			key, err = memory.GenerateKeyFile(keyFile)
+		}
+		if err != nil {
+			return err
+		}
+		cfg = memory.EncryptionConfig{Method: memory.EncryptionKeyFile, KeyFile: keyFile}
+	}
+
+	result, err := store.EnableEncryption(context.Background(), key, cfg)
+	if err != nil {
+		return fmt.Errorf("encryption failed: %w", err)
+	}
+
+	fmt.Printf("🔒 Encrypted %d memories, %d revisions and %d citations\n", result.Memories, result.Revisions, result.Citations)
+	if usePassphrase {
+		fmt.Println("   Key: derived from PHLOEM_PASSPHRASE — set it for every phloem process, including your MCP client's config")
+	} else {
+		fmt.Printf("   Key file: %s\n", keyFile)
+		fmt.Println("   ⚠️  Back up this key. Without it your memories cannot be recovered.")
+	}
+	fmt.Println("   Keyword search is disabled while encrypted; semantic recall still works.")
+	return nil
+}
+
+func runDecrypt() error {
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	if !store.Encrypted() {
+		fmt.Println("🔓 memories.db is not encrypted")
+		return nil
+	}
+
+	result, err := store.DisableEncryption(context.Background())
+	if err != nil {
+		return fmt.Errorf("decryption failed: %w", err)
+	}
+	fmt.Printf("🔓 Decrypted %d memories, %d revisions and %d citations\n", result.Memories, result.Revisions, result.Citations)
+	fmt.Println("   The key file is no longer needed and can be deleted.")
+	return nil
+}
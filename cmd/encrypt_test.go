@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+func TestExecute_EncryptDecrypt(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("PHLOEM_DATA_DIR", tmpDir)
+	defer os.Unsetenv("PHLOEM_DATA_DIR")
+
+	store, err := memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Remember(context.Background(), "encrypt me please", nil, "")
+	store.Close()
+
+	defer setArgs("phloem", "encrypt")()
+	out, _ := captureStdout(func() {
+		if err := Execute(); err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Encrypted 1 memories") {
+		t.Errorf("unexpected encrypt output: %s", out)
+	}
+	keyFile := filepath.Join(tmpDir, memory.DefaultKeyFileName)
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected key file with mode 0600: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "memories.db")); strings.Contains(string(data), "encrypt me please") {
+		t.Error("plaintext content found in memories.db")
+	}
+
+	out, _ = captureStdout(func() {
+		if err := runAudit(); err != nil {
+			t.Fatalf("audit: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Status: ✅ encrypted") || !strings.Contains(out, "Memory encryption key") {
+		t.Errorf("expected encryption status in audit: %s", out)
+	}
+
+	defer setArgs("phloem", "decrypt")()
+	out, _ = captureStdout(func() {
+		if err := Execute(); err != nil {
+			t.Fatalf("decrypt: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Decrypted 1 memories") {
+		t.Errorf("unexpected decrypt output: %s", out)
+	}
+
+	out, _ = captureStdout(func() { runAudit() })
+	if !strings.Contains(out, "Status: not encrypted") {
+		t.Errorf("expected plaintext status in audit: %s", out)
+	}
+}
@@ -20,10 +20,19 @@ var graftCmd = &cobra.Command{
 	Short: "Shareable memory bundles",
 	Long: `Create, import, and inspect shareable memory bundles (grafts).
 
+Grafts can be signed with an ed25519 author key. Import and inspect
+check the signature against your trust store and refuse tampered grafts;
+unsigned grafts and grafts from untrusted signers need --allow-unsigned.
+
 Examples:
-  phloem graft export --tags "architecture,patterns" --output arch.graft
+  phloem graft keygen --name "Jane Doe"
+  phloem graft export --tags "architecture,patterns" --output arch.graft --sign-key ~/.phloem/keys/graft.key
+  phloem graft trust add jane.pub
   phloem graft import arch.graft
-  phloem graft inspect arch.graft`,
+  phloem graft inspect arch.graft
+  phloem graft list
+  phloem graft upgrade arch-v2.graft
+  phloem graft remove "Architecture Patterns"`,
 }
 
 func init() {
@@ -38,7 +47,8 @@ func init() {
 			name, _ := cmd.Flags().GetString("name")
 			desc, _ := cmd.Flags().GetString("desc")
 			author, _ := cmd.Flags().GetString("author")
-			return runGraftExport(tags, since, output, name, desc, author)
+			signKey, _ := cmd.Flags().GetString("sign-key")
+			return runGraftExport(tags, since, output, name, desc, author, signKey)
 		},
 	}
 	exportCmd.Flags().String("tags", "", "Comma-separated tags to include")
@@ -46,7 +56,8 @@ func init() {
 	exportCmd.Flags().String("output", "", "Output filename (required)")
 	exportCmd.Flags().String("name", "", "Graft name")
 	exportCmd.Flags().String("desc", "", "Graft description")
-	exportCmd.Flags().String("author", "", "Author name")
+	exportCmd.Flags().String("author", "", "Author name (defaults to the signing key's name)")
+	exportCmd.Flags().String("sign-key", "", "Sign the graft with this private key (see 'phloem graft keygen')")
 	graftCmd.AddCommand(exportCmd)
 
 	// graft import
@@ -60,28 +71,139 @@ func init() {
 			if len(args) >= 1 {
 				filePath = args[0]
 			}
-			return runGraftImport(from, filePath)
+			allowUnsigned, _ := cmd.Flags().GetBool("allow-unsigned")
+			return runGraftImport(from, filePath, allowUnsigned)
 		},
 	}
 	importCmd.Flags().String("from", "", "Import from registry URL")
+	importCmd.Flags().Bool("allow-unsigned", false, "Accept grafts that are unsigned or signed by a key not in your trust store")
 	graftCmd.AddCommand(importCmd)
 
 	// graft inspect
-	graftCmd.AddCommand(&cobra.Command{
+	inspectCmd := &cobra.Command{
 		Use:   "inspect <file.graft>",
-		Short: "View graft manifest without importing",
+		Short: "View graft manifest and signer without importing",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			allowUnsigned, _ := cmd.Flags().GetBool("allow-unsigned")
+			return runGraftInspect(args[0], allowUnsigned)
+		},
+	}
+	inspectCmd.Flags().Bool("allow-unsigned", false, "Show grafts that are unsigned or signed by a key not in your trust store")
+	graftCmd.AddCommand(inspectCmd)
+
+	// graft list
+	graftCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List installed grafts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGraftList()
+		},
+	})
+
+	// graft remove
+	graftCmd.AddCommand(&cobra.Command{
+		Use:   "remove <name | name:author>",
+		Short: "Remove the memories installed from a graft",
+		Long: `Remove every memory installed from a graft, along with its citations and edges.
+
+Memories you created yourself are never removed, even if a graft contained
+the same content.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGraftRemove(args[0])
+		},
+	})
+
+	// graft upgrade
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade <file.graft>",
+		Short: "Upgrade an installed graft to a newer version",
+		Long: `Upgrade an installed graft in place.
+
+Memories are matched to the installed copy of the same graft (same name and
+author): changed memories are updated (keeping their revision history), new
+ones are added and ones no longer in the graft are removed. Memories you
+created yourself are left untouched. A graft that is not installed yet is
+simply imported.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			allowUnsigned, _ := cmd.Flags().GetBool("allow-unsigned")
+			return runGraftUpgrade(args[0], allowUnsigned)
+		},
+	}
+	upgradeCmd.Flags().Bool("allow-unsigned", false, "Accept grafts that are unsigned or signed by a key not in your trust store")
+	graftCmd.AddCommand(upgradeCmd)
+
+	// graft keygen
+	keygenCmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Create an ed25519 key for signing grafts",
+		Long: `Create an ed25519 key pair for signing grafts.
+
+The private key is written to --output (default ~/.phloem/keys/graft.key)
+and the public key next to it with a .pub suffix. Share the .pub file so
+others can add it with 'phloem graft trust add'. Your own key is trusted
+automatically.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _ := cmd.Flags().GetString("name")
+			output, _ := cmd.Flags().GetString("output")
+			force, _ := cmd.Flags().GetBool("force")
+			return runGraftKeygen(name, output, force)
+		},
+	}
+	keygenCmd.Flags().String("name", "", "Signer name shown to people who import your grafts (required)")
+	keygenCmd.Flags().String("output", "", "Private key path (default ~/.phloem/keys/graft.key)")
+	keygenCmd.Flags().Bool("force", false, "Overwrite an existing key")
+	graftCmd.AddCommand(keygenCmd)
+
+	// graft trust add|list|remove
+	trustCmd := &cobra.Command{
+		Use:   "trust",
+		Short: "Manage the public keys of graft authors you trust",
+	}
+	trustCmd.AddCommand(&cobra.Command{
+		Use:   "add <key.pub | \"ed25519 <key> <name>\">",
+		Short: "Trust an author's public key",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGraftInspect(args[0])
+			return runGraftTrustAdd(args[0])
+		},
+	})
+	trustCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List trusted keys",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGraftTrustList()
 		},
 	})
+	trustCmd.AddCommand(&cobra.Command{
+		Use:   "remove <name | fingerprint>",
+		Short: "Stop trusting a key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGraftTrustRemove(args[0])
+		},
+	})
+	graftCmd.AddCommand(trustCmd)
 }
 
-func runGraftExport(tagsStr, sinceStr, output, name, desc, author string) error {
+func runGraftExport(tagsStr, sinceStr, output, name, desc, author, signKeyPath string) error {
 	if output == "" {
 		return fmt.Errorf("--output is required")
 	}
 
+	var signKey *graft.SigningKey
+	if signKeyPath != "" {
+		key, err := graft.LoadSigningKey(signKeyPath)
+		if err != nil {
+			return err
+		}
+		signKey = key
+	}
+
 	store, err := memory.NewStore()
 	if err != nil {
 		return fmt.Errorf("failed to open store: %w", err)
@@ -154,21 +276,122 @@ func runGraftExport(tagsStr, sinceStr, output, name, desc, author string) error
 	if manifest.Name == "" {
 		manifest.Name = fmt.Sprintf("Export %s", time.Now().Format("2006-01-02"))
 	}
+	if manifest.Author == "" && signKey != nil {
+		manifest.Author = signKey.Signer
+	}
 	if manifest.Author == "" {
 		user, _ := os.UserHomeDir()
 		manifest.Author = filepath.Base(user)
 	}
 
-	err = graft.Package(manifest, filteredMemories, nil, output)
-	if err != nil {
+	citations, edges := graftRelations(context.Background(), store, filteredMemories)
+	manifest.CitationCount = len(citations)
+	manifest.EdgeCount = len(edges)
+
+	if err := writeGraft(output, manifest, filteredMemories, citations, edges); err != nil {
 		return fmt.Errorf("failed to package graft: %w", err)
 	}
 
-	fmt.Printf("📦 Created %s (%d memories)\n", output, len(filteredMemories))
+	fmt.Printf("📦 Created %s (%d memories, %d citations, %d edges)\n", output, len(filteredMemories), len(citations), len(edges))
+
+	if signKey != nil {
+		if err := graft.Sign(output, signKey); err != nil {
+			return fmt.Errorf("failed to sign graft: %w", err)
+		}
+		pub := signKey.PublicKey()
+		fmt.Printf("🔏 Signed by %s (%s)\n", signKey.Signer, pub.Fingerprint())
+	}
+	return nil
+}
+
+// graftRelations collects the citations of the exported memories and the edges between them.
+func graftRelations(ctx context.Context, store *memory.Store, memories []memory.Memory) ([]memory.Citation, []memory.Edge) {
+	exported := make(map[string]bool, len(memories))
+	for _, m := range memories {
+		exported[m.ID] = true
+	}
+
+	var citations []memory.Citation
+	var edges []memory.Edge
+	for _, m := range memories {
+		if cs, err := store.GetCitations(ctx, m.ID); err == nil {
+			for _, c := range cs {
+				c.RepoRoot = "" // Local checkout path; FilePath stays repo-relative
+				citations = append(citations, c)
+			}
+		}
+		from, err := store.GetEdgesFrom(ctx, m.ID, "")
+		if err != nil {
+			continue
+		}
+		for _, e := range from {
+			// Edges to memories outside the graft would dangle on import
+			if e.TargetID == "" || exported[e.TargetID] {
+				edges = append(edges, e)
+			}
+		}
+	}
+	return citations, edges
+}
+
+// writeGraft streams memories, then citations and edges, into a graft file.
+func writeGraft(path string, manifest graft.Manifest, memories []memory.Memory, citations []memory.Citation, edges []memory.Edge) error {
+	w, err := graft.NewWriter(path, manifest)
+	if err != nil {
+		return err
+	}
+	for _, m := range memories {
+		if err := w.WriteMemory(m); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	for _, c := range citations {
+		if err := w.WriteCitation(c); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	for _, e := range edges {
+		if err := w.WriteEdge(e); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// checkGraftSignature verifies a graft against the trust store and prints the signer.
+// Tampered grafts are always refused; unsigned or untrusted ones only with allowUnsigned.
+func checkGraftSignature(path string, allowUnsigned bool) error {
+	trust, err := graft.LoadTrustStore(dataDir())
+	if err != nil {
+		return err
+	}
+	v, err := trust.Check(path)
+	if err != nil {
+		fmt.Println("❌ Signature check failed")
+		return fmt.Errorf("refusing graft: %w", err)
+	}
+
+	switch {
+	case v.Signature == nil:
+		if !allowUnsigned {
+			return fmt.Errorf("refusing unsigned graft; pass --allow-unsigned if you trust its source")
+		}
+		fmt.Println("⚠️  Graft is unsigned (allowed by --allow-unsigned)")
+	case !v.Trusted:
+		fmt.Printf("⚠️  Signed by %q (%s), which is not in your trust store\n", v.Signer, v.Fingerprint)
+		if !allowUnsigned {
+			return fmt.Errorf("refusing graft from untrusted signer; add their key with 'phloem graft trust add' or pass --allow-unsigned")
+		}
+	default:
+		fmt.Printf("🔏 Signed by %s (%s) — trusted\n", v.Signer, v.Fingerprint)
+	}
 	return nil
 }
 
-func runGraftImport(fromURL, filePath string) error {
+func runGraftImport(fromURL, filePath string, allowUnsigned bool) error {
 	var inputPath string
 	if fromURL != "" {
 		inputPath = downloadGraftFromRegistry(fromURL)
@@ -183,15 +406,20 @@ func runGraftImport(fromURL, filePath string) error {
 	}
 
 	fmt.Printf("📦 Reading %s...\n", inputPath)
-	payload, err := graft.Unpack(inputPath)
+	if err := checkGraftSignature(inputPath, allowUnsigned); err != nil {
+		return err
+	}
+	reader, err := graft.Open(inputPath)
 	if err != nil {
 		return fmt.Errorf("failed to unpack graft: %w", err)
 	}
+	defer reader.Close()
+	manifest := reader.Manifest
 
 	fmt.Printf("🔓 Verifying format... OK\n")
-	fmt.Printf("📄 Manifest: %s by %s\n", payload.Manifest.Name, payload.Manifest.Author)
-	if payload.Manifest.Description != "" {
-		fmt.Printf("   %s\n", payload.Manifest.Description)
+	fmt.Printf("📄 Manifest: %s by %s\n", manifest.Name, manifest.Author)
+	if manifest.Description != "" {
+		fmt.Printf("   %s\n", manifest.Description)
 	}
 
 	store, err := memory.NewStore()
@@ -200,35 +428,128 @@ func runGraftImport(fromURL, filePath string) error {
 	}
 	defer store.Close()
 
-	source := fmt.Sprintf("graft:%s:%s", payload.Manifest.Name, payload.Manifest.Author)
+	source := graftSource(manifest)
 
-	count := 0
+	// Graft memory IDs are remapped to local IDs; citations and edges follow the map
+	idMap := make(map[string]string)
+	count, citations, edges := 0, 0, 0
 	ctx := context.Background()
-	for _, m := range payload.Memories {
-		m.Source = source
-
-		hasGraftTag := false
-		for _, t := range m.Tags {
-			if t == "graft" {
-				hasGraftTag = true
-				break
-			}
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			break
 		}
-		if !hasGraftTag {
-			m.Tags = append(m.Tags, "graft")
+		if err != nil {
+			return fmt.Errorf("failed to read graft: %w", err)
 		}
 
-		if err := store.Add(ctx, m); err != nil {
-			fmt.Printf("⚠️ Failed to import memory %s: %v\n", m.ID, err)
-			continue
+		switch {
+		case rec.Memory != nil:
+			m := graftMemory(*rec.Memory, source)
+			id, err := store.Import(ctx, m)
+			if err != nil {
+				fmt.Printf("⚠️ Failed to import memory %s: %v\n", m.ID, err)
+				continue
+			}
+			if m.ID != "" {
+				idMap[m.ID] = id
+			}
+			count++
+
+		case rec.Citation != nil:
+			if importGraftCitation(ctx, store, idMap, *rec.Citation) {
+				citations++
+			}
+
+		case rec.Edge != nil:
+			if importGraftEdge(ctx, store, idMap, *rec.Edge) {
+				edges++
+			}
 		}
-		count++
 	}
 
 	fmt.Printf("✨ Imported %d memories (source: %s)\n", count, source)
+	if citations > 0 || edges > 0 {
+		fmt.Printf("   with %d citations and %d edges\n", citations, edges)
+	}
 	return nil
 }
 
+// graftSourcePrefix starts the source of every memory imported from a graft
+const graftSourcePrefix = "graft:"
+
+// graftSource is the memory source recorded for everything imported from a graft
+func graftSource(manifest graft.Manifest) string {
+	return fmt.Sprintf("%s%s:%s", graftSourcePrefix, manifest.Name, manifest.Author)
+}
+
+// parseGraftSource splits a graft source into its name and author.
+// Names may contain colons, so the author is taken after the last one.
+func parseGraftSource(source string) (name, author string) {
+	rest := strings.TrimPrefix(source, graftSourcePrefix)
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		return rest[:i], rest[i+1:]
+	}
+	return rest, ""
+}
+
+// graftMemory prepares a graft memory for import: attributed to source and tagged "graft".
+func graftMemory(m memory.Memory, source string) memory.Memory {
+	m.Source = source
+	for _, t := range m.Tags {
+		if t == "graft" {
+			return m
+		}
+	}
+	m.Tags = append(append([]string{}, m.Tags...), "graft")
+	return m
+}
+
+// importGraftCitation attaches a graft citation to the local copy of its memory,
+// skipping citations the memory already has. Reports whether one was added.
+func importGraftCitation(ctx context.Context, store *memory.Store, idMap map[string]string, c memory.Citation) bool {
+	memID, ok := idMap[c.MemoryID]
+	if !ok {
+		return false
+	}
+	existing, _ := store.GetCitations(ctx, memID)
+	for _, e := range existing {
+		if e.FilePath == c.FilePath && e.StartLine == c.StartLine && e.EndLine == c.EndLine {
+			return false
+		}
+	}
+	if c.Symbol != "" {
+		// Re-resolve the symbol when the cited file exists here; otherwise keep the line range
+		if _, err := store.AddSymbolCitation(ctx, memID, c.FilePath, c.Symbol, c.CommitSHA); err == nil {
+			return true
+		}
+	}
+	_, err := store.AddCitation(ctx, memID, c.FilePath, c.StartLine, c.EndLine, c.CommitSHA, c.Content)
+	return err == nil
+}
+
+// importGraftEdge recreates a graft edge between the local copies of its endpoints,
+// skipping edges that already exist. Reports whether one was added.
+func importGraftEdge(ctx context.Context, store *memory.Store, idMap map[string]string, e memory.Edge) bool {
+	source, ok := idMap[e.SourceID]
+	if !ok {
+		return false
+	}
+	target := ""
+	if e.TargetID != "" {
+		if target, ok = idMap[e.TargetID]; !ok || target == source {
+			return false
+		}
+	}
+	existing, _ := store.GetEdgesFrom(ctx, source, e.EdgeType)
+	for _, x := range existing {
+		if x.TargetID == target {
+			return false
+		}
+	}
+	return store.AddEdge(ctx, source, target, e.EdgeType, e.Payload) == nil
+}
+
 // downloadGraftFromRegistry downloads a .graft file from a URL to a temp file
 func downloadGraftFromRegistry(rawURL string) string {
 	fmt.Printf("📥 Downloading graft from %s...\n", rawURL)
@@ -269,7 +590,10 @@ func downloadGraftFromRegistry(rawURL string) string {
 	return tmpFile.Name()
 }
 
-func runGraftInspect(inputPath string) error {
+func runGraftInspect(inputPath string, allowUnsigned bool) error {
+	if err := checkGraftSignature(inputPath, allowUnsigned); err != nil {
+		return err
+	}
 	manifest, err := graft.Inspect(inputPath)
 	if err != nil {
 		return fmt.Errorf("failed to inspect graft: %w", err)
@@ -283,7 +607,317 @@ func runGraftInspect(inputPath string) error {
 	fmt.Printf("Version:     %s\n", manifest.Version)
 	fmt.Printf("Created:     %s\n", manifest.CreatedAt)
 	fmt.Printf("Memories:    %d\n", manifest.MemoryCount)
+	if manifest.CitationCount > 0 || manifest.EdgeCount > 0 {
+		fmt.Printf("Citations:   %d\n", manifest.CitationCount)
+		fmt.Printf("Edges:       %d\n", manifest.EdgeCount)
+	}
 	fmt.Printf("Tags:        %v\n", manifest.Tags)
 
 	return nil
 }
+
+func runGraftList() error {
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	defer store.Close()
+
+	sources, err := store.Sources(context.Background(), graftSourcePrefix)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		fmt.Println("No grafts installed. Import one with 'phloem graft import <file.graft>'.")
+		return nil
+	}
+
+	fmt.Println("📦 Installed grafts")
+	for _, src := range sources {
+		name, author := parseGraftSource(src.Source)
+		fmt.Printf("  %s by %s (%d memories)\n", name, author, src.Count)
+	}
+	return nil
+}
+
+func runGraftRemove(nameOrSource string) error {
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	sources, err := store.Sources(ctx, graftSourcePrefix)
+	if err != nil {
+		return err
+	}
+
+	// Match "name:author" exactly, or a bare name from any author
+	var matches []string
+	for _, src := range sources {
+		name, _ := parseGraftSource(src.Source)
+		if src.Source == graftSourcePrefix+nameOrSource {
+			matches = []string{src.Source}
+			break
+		}
+		if name == nameOrSource {
+			matches = append(matches, src.Source)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("no installed graft named %q (see 'phloem graft list')", nameOrSource)
+	case 1:
+	default:
+		var options []string
+		for _, m := range matches {
+			options = append(options, strings.TrimPrefix(m, graftSourcePrefix))
+		}
+		return fmt.Errorf("%q is installed from several authors; use one of: %s", nameOrSource, strings.Join(options, ", "))
+	}
+
+	removed, err := store.ForgetSource(ctx, matches[0])
+	if err != nil {
+		return fmt.Errorf("failed to remove graft: %w", err)
+	}
+	name, author := parseGraftSource(matches[0])
+	fmt.Printf("🗑️  Removed %d memories from %s by %s\n", removed, name, author)
+	return nil
+}
+
+func runGraftUpgrade(inputPath string, allowUnsigned bool) error {
+	fmt.Printf("📦 Reading %s...\n", inputPath)
+	if err := checkGraftSignature(inputPath, allowUnsigned); err != nil {
+		return err
+	}
+	reader, err := graft.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to unpack graft: %w", err)
+	}
+	defer reader.Close()
+	manifest := reader.Manifest
+
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	source := graftSource(manifest)
+	installed, err := store.ListBySource(ctx, source)
+	if err != nil {
+		return err
+	}
+	if len(installed) == 0 {
+		fmt.Printf("ℹ️  %s by %s is not installed; importing it\n", manifest.Name, manifest.Author)
+	}
+
+	// Match installed memories by their ID in the graft, falling back to content
+	// for memories installed before source references were recorded
+	byRef := make(map[string]*memory.Memory)
+	byContent := make(map[string]*memory.Memory)
+	for _, m := range installed {
+		if m.SourceRef != "" {
+			byRef[m.SourceRef] = m
+		}
+		byContent[m.Content] = m
+	}
+
+	idMap := make(map[string]string)
+	kept := make(map[string]bool)
+	added, updated, unchanged, citations, edges := 0, 0, 0, 0, 0
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read graft: %w", err)
+		}
+
+		switch {
+		case rec.Memory != nil:
+			m := graftMemory(*rec.Memory, source)
+			// Installed memories were stored with their secrets redacted
+			content, memContext := store.Scrub(m.Content), store.Scrub(m.Context)
+			existing := byRef[m.ID]
+			if existing == nil || kept[existing.ID] {
+				existing = byContent[content]
+			}
+
+			if existing != nil && !kept[existing.ID] {
+				kept[existing.ID] = true
+				if m.ID != "" {
+					idMap[m.ID] = existing.ID
+				}
+				if existing.Content == content && existing.Context == memContext && sameTagSet(existing.Tags, m.Tags) {
+					unchanged++
+					continue
+				}
+				if _, err := store.Update(ctx, existing.ID, memory.MemoryUpdate{Content: &m.Content, Tags: &m.Tags, Context: &m.Context}); err != nil {
+					fmt.Printf("⚠️ Failed to update memory %s: %v\n", existing.ID, err)
+					continue
+				}
+				updated++
+				continue
+			}
+
+			id, err := store.Import(ctx, m)
+			if err != nil {
+				fmt.Printf("⚠️ Failed to import memory %s: %v\n", m.ID, err)
+				continue
+			}
+			if m.ID != "" {
+				idMap[m.ID] = id
+			}
+			kept[id] = true
+			added++
+
+		case rec.Citation != nil:
+			if importGraftCitation(ctx, store, idMap, *rec.Citation) {
+				citations++
+			}
+
+		case rec.Edge != nil:
+			if importGraftEdge(ctx, store, idMap, *rec.Edge) {
+				edges++
+			}
+		}
+	}
+
+	// Anything installed from this graft that the new version dropped goes away
+	removed := 0
+	for _, m := range installed {
+		if kept[m.ID] {
+			continue
+		}
+		if err := store.Forget(ctx, m.ID); err != nil {
+			fmt.Printf("⚠️ Failed to remove memory %s: %v\n", m.ID, err)
+			continue
+		}
+		removed++
+	}
+
+	fmt.Printf("✨ Upgraded %s by %s", manifest.Name, manifest.Author)
+	if manifest.Version != "" {
+		fmt.Printf(" to %s", manifest.Version)
+	}
+	fmt.Printf(": %d added, %d updated, %d removed, %d unchanged\n", added, updated, removed, unchanged)
+	if citations > 0 || edges > 0 {
+		fmt.Printf("   with %d new citations and %d new edges\n", citations, edges)
+	}
+	return nil
+}
+
+// sameTagSet reports whether a and b hold the same tags, ignoring order and duplicates
+func sameTagSet(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, t := range a {
+		set[t] = true
+	}
+	other := make(map[string]bool, len(b))
+	for _, t := range b {
+		if !set[t] {
+			return false
+		}
+		other[t] = true
+	}
+	return len(set) == len(other)
+}
+
+func runGraftKeygen(name, output string, force bool) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("--name is required")
+	}
+	if output == "" {
+		output = filepath.Join(dataDir(), "keys", "graft.key")
+	}
+	if _, err := os.Stat(output); err == nil && !force {
+		return fmt.Errorf("%s already exists; pass --force to replace it", output)
+	}
+
+	key, err := graft.GenerateKey(name)
+	if err != nil {
+		return err
+	}
+	if err := key.Save(output); err != nil {
+		return err
+	}
+
+	// Trust your own key so your grafts import without --allow-unsigned
+	trust, err := graft.LoadTrustStore(dataDir())
+	if err != nil {
+		return err
+	}
+	pub := key.PublicKey()
+	trust.Add(pub)
+	if err := trust.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("🔑 Created signing key for %s\n", key.Signer)
+	fmt.Printf("   Private key: %s (keep this secret)\n", output)
+	fmt.Printf("   Public key:  %s.pub\n", output)
+	fmt.Printf("   Fingerprint: %s\n\n", pub.Fingerprint())
+	fmt.Println("Share your public key so others can trust your grafts:")
+	fmt.Printf("  %s\n", pub)
+	return nil
+}
+
+func runGraftTrustAdd(keyOrPath string) error {
+	line := keyOrPath
+	if data, err := os.ReadFile(keyOrPath); err == nil {
+		line = string(data)
+	}
+	key, err := graft.ParsePublicKey(line)
+	if err != nil {
+		return err
+	}
+
+	trust, err := graft.LoadTrustStore(dataDir())
+	if err != nil {
+		return err
+	}
+	if !trust.Add(key) {
+		fmt.Printf("Key %s is already trusted\n", key.Fingerprint())
+		return nil
+	}
+	if err := trust.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Trusted %s (%s)\n", key.Signer, key.Fingerprint())
+	return nil
+}
+
+func runGraftTrustList() error {
+	trust, err := graft.LoadTrustStore(dataDir())
+	if err != nil {
+		return err
+	}
+	if len(trust.Keys) == 0 {
+		fmt.Println("No trusted keys. Add one with 'phloem graft trust add <key.pub>'.")
+		return nil
+	}
+	for _, k := range trust.Keys {
+		fmt.Printf("%s  %s\n", k.Fingerprint(), k.Signer)
+	}
+	return nil
+}
+
+func runGraftTrustRemove(nameOrFingerprint string) error {
+	trust, err := graft.LoadTrustStore(dataDir())
+	if err != nil {
+		return err
+	}
+	removed := trust.Remove(nameOrFingerprint)
+	if removed == 0 {
+		return fmt.Errorf("no trusted key matches %q", nameOrFingerprint)
+	}
+	if err := trust.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Removed %d key(s)\n", removed)
+	return nil
+}
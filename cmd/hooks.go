@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/CanopyHQ/phloem/internal/git"
+	"github.com/CanopyHQ/phloem/internal/memory"
+	"github.com/spf13/cobra"
+)
+
+// gitHooks are the hooks phloem installs; each re-verifies citations of the files it changed
+var gitHooks = []string{"post-commit", "post-checkout", "post-merge"}
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Re-verify citations automatically on commit, checkout and merge",
+	Long: `Install git hooks in the current repository that re-verify the citations
+of every file a commit, checkout or merge changed, so citation confidence
+follows the code without running 'phloem verify' by hand.
+
+The hooks are added as a marked block, so existing hooks keep working and
+'phloem hooks uninstall' removes only what phloem added. They do nothing if
+phloem is not on PATH and never block git.
+
+Examples:
+  phloem hooks install
+  phloem hooks uninstall`,
+}
+
+func init() {
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Add phloem to the post-commit, post-checkout and post-merge hooks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHooksInstall()
+		},
+	}
+
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove phloem from the repository's hooks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHooksUninstall()
+		},
+	}
+
+	// Called by the installed hooks with the hook name and git's hook arguments
+	runCmd := &cobra.Command{
+		Use:    "run <hook> [args...]",
+		Short:  "Re-verify citations of the files changed by a hook's commit range",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHook(args[0], args[1:])
+		},
+	}
+
+	hooksCmd.AddCommand(installCmd, uninstallCmd, runCmd)
+}
+
+// currentHooksDir finds the work tree of the current directory and its hooks directory
+func currentHooksDir() (root, dir string, err error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	if root, err = git.WorkTreeRoot(cwd); err != nil {
+		return "", "", err
+	}
+	if dir, err = git.HooksDir(root); err != nil {
+		return "", "", err
+	}
+	return root, dir, nil
+}
+
+// hookScript is the block added to each hook
+func hookScript(hook string) string {
+	return fmt.Sprintf("command -v phloem >/dev/null 2>&1 && phloem hooks run %s \"$@\" || true", hook)
+}
+
+func runHooksInstall() error {
+	root, dir, err := currentHooksDir()
+	if err != nil {
+		return err
+	}
+	for _, hook := range gitHooks {
+		added, err := git.InstallHook(dir, hook, hookScript(hook))
+		if err != nil {
+			return err
+		}
+		if added {
+			fmt.Printf("✅ Installed %s hook\n", hook)
+		} else {
+			fmt.Printf("   %s hook already installed\n", hook)
+		}
+	}
+	fmt.Printf("\nCitations in %s are now re-verified when commits, checkouts and merges change their files.\n", root)
+	return nil
+}
+
+func runHooksUninstall() error {
+	_, dir, err := currentHooksDir()
+	if err != nil {
+		return err
+	}
+	removed := 0
+	for _, hook := range gitHooks {
+		ok, err := git.UninstallHook(dir, hook)
+		if err != nil {
+			return err
+		}
+		if ok {
+			removed++
+			fmt.Printf("🗑️  Removed phloem from %s hook\n", hook)
+		}
+	}
+	if removed == 0 {
+		fmt.Println("No phloem hooks installed in this repository.")
+	}
+	return nil
+}
+
+// runHook re-verifies the citations of the files changed by the commit range a hook reports
+func runHook(hook string, args []string) error {
+	root, _, err := currentHooksDir()
+	if err != nil {
+		return err
+	}
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		root = real // Citations store the resolved repo root
+	}
+
+	var from, to string
+	switch hook {
+	case "post-commit":
+		from, to = "", "HEAD"
+	case "post-merge":
+		from, to = "ORIG_HEAD", "HEAD"
+	case "post-checkout":
+		// Arguments: previous HEAD, new HEAD, 1 for a branch checkout (0 for files)
+		if len(args) < 3 || args[2] != "1" || args[0] == args[1] || strings.Trim(args[0], "0") == "" {
+			return nil // File checkout, new branch at the same commit, or the initial clone
+		}
+		from, to = args[0], args[1]
+	default:
+		return fmt.Errorf("unsupported hook: %s", hook)
+	}
+
+	files, err := git.ChangedFiles(root, from, to)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	// Runs after every commit: skip the embedder and indexes, which verification does not use
+	store, err := memory.NewCitationStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	result, err := store.VerifyAllCitations(context.Background(), memory.VerifyAllOptions{RepoRoot: root, Files: files})
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+	if result.Citations == 0 {
+		return nil
+	}
+	fmt.Printf("🌿 phloem: re-verified %d citation(s) in %d changed file(s): %d valid, %d invalid\n",
+		result.Citations, len(result.Files), result.Valid, result.Invalid)
+	if len(result.Stale) > 0 {
+		fmt.Printf("   %d memories fell below %.0f%% confidence; run 'phloem verify --all' for details\n",
+			len(result.Stale), result.Threshold*100)
+	}
+	return nil
+}
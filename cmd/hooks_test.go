@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+func gitIn(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestExecute_Hooks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("PHLOEM_DATA_DIR", t.TempDir())
+	repo, _ := filepath.EvalSymlinks(t.TempDir())
+	gitIn(t, repo, "init", "-q")
+	src := filepath.Join(repo, "limits.go")
+	os.WriteFile(src, []byte("package api\n\nconst MaxBody = 1 << 20\n"), 0644)
+	gitIn(t, repo, "add", ".")
+	gitIn(t, repo, "commit", "-q", "-m", "initial")
+	t.Chdir(repo)
+
+	defer setArgs("phloem", "hooks", "install")()
+	out, _ := captureStdout(func() {
+		if err := Execute(); err != nil {
+			t.Fatalf("hooks install: %v", err)
+		}
+	})
+	for _, hook := range gitHooks {
+		if !strings.Contains(out, "Installed "+hook) {
+			t.Errorf("expected %s to be installed: %s", hook, out)
+		}
+		data, err := os.ReadFile(filepath.Join(repo, ".git", "hooks", hook))
+		if err != nil || !strings.Contains(string(data), "phloem hooks run "+hook) {
+			t.Errorf("expected %s hook script, got %q (%v)", hook, data, err)
+		}
+	}
+
+	store, err := memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	mem, _ := store.Remember(ctx, "Request bodies are limited to 1MB", nil, "")
+	citation, _ := store.AddCitation(ctx, mem.ID, src, 3, 3, "", "const MaxBody = 1 << 20")
+	store.Close()
+
+	os.WriteFile(src, []byte("package api\n\nconst MaxBody = 8 << 20\n"), 0644)
+	gitIn(t, repo, "commit", "-q", "-am", "raise limit")
+
+	// The hook never waits on an embedding server
+	var embedRequests atomic.Int32
+	embedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		embedRequests.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer embedServer.Close()
+	t.Setenv("PHLOEM_AIR_GAPPED", "")
+	t.Setenv("PHLOEM_EMBEDDINGS", "http")
+	t.Setenv("PHLOEM_EMBED_URL", embedServer.URL)
+
+	defer setArgs("phloem", "hooks", "run", "post-commit")()
+	out, _ = captureStdout(func() {
+		if err := Execute(); err != nil {
+			t.Fatalf("hooks run: %v", err)
+		}
+	})
+	if !strings.Contains(out, "re-verified 1 citation(s) in 1 changed file(s)") {
+		t.Errorf("expected the changed file's citation to be verified: %s", out)
+	}
+	if n := embedRequests.Load(); n != 0 {
+		t.Errorf("expected the hook not to contact the embedding server, got %d request(s)", n)
+	}
+	t.Setenv("PHLOEM_EMBEDDINGS", "local")
+
+	store, err = memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	citations, _ := store.GetCitations(ctx, mem.ID)
+	store.Close()
+	if len(citations) != 1 || citations[0].ID != citation.ID || citations[0].Confidence >= 1.0 {
+		t.Errorf("expected reduced confidence after the change, got %+v", citations)
+	}
+
+	defer setArgs("phloem", "hooks", "uninstall")()
+	captureStdout(func() {
+		if err := Execute(); err != nil {
+			t.Fatalf("hooks uninstall: %v", err)
+		}
+	})
+	for _, hook := range gitHooks {
+		if _, err := os.Stat(filepath.Join(repo, ".git", "hooks", hook)); !os.IsNotExist(err) {
+			t.Errorf("expected %s hook to be removed", hook)
+		}
+	}
+}
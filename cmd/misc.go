@@ -32,15 +32,45 @@ Examples:
 }
 
 var verifyCmd = &cobra.Command{
-	Use:   "verify <memory_id>",
-	Short: "Verify all citations for a memory",
+	Use:   "verify [memory_id]",
+	Short: "Verify all citations for a memory, or every citation with --all",
 	Long: `Verify all citations for a given memory by checking if the
 referenced files and code snippets still exist.
 
+With --all, every citation is verified (files in parallel) and the results
+are grouped by file. Memories whose aggregate confidence falls below
+--threshold are listed as stale; --tag-stale tags them 'stale' (and removes
+the tag from memories whose citations hold again). --scope limits the run to
+one scope; "repo" means the repository of the current directory.
+
 Examples:
-  phloem verify abc123`,
-	Args: cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error { return runVerify(args[0]) },
+  phloem verify abc123
+  phloem verify --all
+  phloem verify --all --scope repo --threshold 0.7 --tag-stale`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		if all {
+			if len(args) > 0 {
+				return fmt.Errorf("--all verifies every memory; don't pass a memory ID")
+			}
+			scope, _ := cmd.Flags().GetString("scope")
+			threshold, _ := cmd.Flags().GetFloat64("threshold")
+			tagStale, _ := cmd.Flags().GetBool("tag-stale")
+			return runVerifyAll(scope, threshold, tagStale)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("a memory ID is required (or use --all)")
+		}
+		return runVerify(args[0])
+	},
+}
+
+func init() {
+	verifyCmd.Flags().Bool("all", false, "Verify every citation")
+	verifyCmd.Flags().String("scope", "", `With --all, only memories in this scope or below it ("repo" for the current repository)`)
+	verifyCmd.Flags().Float64("threshold", memory.DefaultStaleThreshold, "With --all, report memories below this aggregate confidence as stale")
+	verifyCmd.Flags().Bool("tag-stale", false, "With --all, tag stale memories 'stale'")
 }
 
 // runVerify verifies all citations for a memory
@@ -82,10 +112,10 @@ func runVerify(memoryID string) error {
 			if c.ID == citation.ID {
 				if valid {
 					verified++
-					fmt.Printf("✅ %s (confidence: %.0f%%)\n", c.FilePath, c.Confidence*100)
+					fmt.Printf("✅ %s (confidence: %.0f%%)\n", citationLabel(c), c.Confidence*100)
 				} else {
 					invalid++
-					fmt.Printf("❌ %s (confidence: %.0f%%)\n", c.FilePath, c.Confidence*100)
+					fmt.Printf("❌ %s (confidence: %.0f%%)\n", citationLabel(c), c.Confidence*100)
 				}
 				break
 			}
@@ -101,6 +131,17 @@ func runVerify(memoryID string) error {
 	return nil
 }
 
+// citationLabel describes where a citation points, e.g. "limiter.go:12-30" or "limiter.go RateLimiter.Allow"
+func citationLabel(c memory.Citation) string {
+	if c.Symbol != "" {
+		return fmt.Sprintf("%s %s", c.FilePath, c.Symbol)
+	}
+	if c.StartLine > 0 {
+		return fmt.Sprintf("%s:%d-%d", c.FilePath, c.StartLine, c.EndLine)
+	}
+	return c.FilePath
+}
+
 // runDecay applies time-based decay to citation confidence scores
 func runDecay() error {
 	store, err := memory.NewStore()
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+	"github.com/spf13/cobra"
+)
+
+var reembedCmd = &cobra.Command{
+	Use:   "reembed",
+	Short: "Re-embed memories stored with a different embedding model",
+	Long: `Re-embed memories whose vectors come from another embedding model than
+the current one (see PHLOEM_EMBEDDINGS), so they can be recalled by meaning
+again after switching models. Until then recall leaves them out of semantic
+search, since vectors from different models cannot be compared.
+
+Memories are re-embedded newest first in batches, each saved as soon as it
+is done: interrupt it at any time and run it again to continue.
+
+Examples:
+  phloem reembed --status          # which models the stored vectors come from
+  phloem reembed
+  phloem reembed --limit 500       # re-embed a slice now, the rest later`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		statusOnly, _ := cmd.Flags().GetBool("status")
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+		limit, _ := cmd.Flags().GetInt("limit")
+		return runReembed(statusOnly, batchSize, limit)
+	},
+}
+
+func init() {
+	reembedCmd.Flags().Bool("status", false, "Show embedding models in use without re-embedding")
+	reembedCmd.Flags().Int("batch-size", memory.DefaultReembedBatchSize, "Memories per embedding request")
+	reembedCmd.Flags().Int("limit", 0, "Re-embed at most this many memories (0 = all)")
+}
+
+func runReembed(statusOnly bool, batchSize, limit int) error {
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	status, err := store.EmbeddingStatus(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Current model: %s (%d dimensions)\n", status.Model, status.Dimensions)
+	models := make([]string, 0, len(status.ByModel))
+	for model := range status.ByModel {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		name := model
+		if name == "" {
+			name = "(none or unknown)"
+		}
+		fmt.Printf("  %-50s %6d memories\n", name, status.ByModel[model])
+	}
+
+	if status.Queued > 0 {
+		fmt.Printf("  %d memories are queued because the embedder was unavailable when they were stored\n", status.Queued)
+	}
+
+	if status.Pending == 0 {
+		fmt.Println("✅ Every memory is embedded with the current model")
+		return nil
+	}
+	if statusOnly {
+		fmt.Printf("%d memories need re-embedding. Run `phloem reembed` to re-embed them.\n", status.Pending)
+		return nil
+	}
+
+	result, err := store.Reembed(ctx, memory.ReembedOptions{
+		BatchSize: batchSize,
+		Limit:     limit,
+		Progress: func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\r🧠 Re-embedded %d/%d", done, total)
+		},
+	})
+	if result != nil && result.Reembedded > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err != nil {
+		if result != nil {
+			fmt.Printf("Re-embedded %d memories before stopping; %d remain. Run `phloem reembed` again to continue.\n",
+				result.Reembedded, result.Remaining)
+		}
+		return fmt.Errorf("re-embedding stopped: %w", err)
+	}
+
+	fmt.Printf("✅ Re-embedded %d memories with %s", result.Reembedded, result.Model)
+	if result.Remaining > 0 {
+		fmt.Printf("; %d remain", result.Remaining)
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+func TestExecute_Reembed(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("PHLOEM_DATA_DIR", tmpDir)
+	defer os.Unsetenv("PHLOEM_DATA_DIR")
+
+	store, err := memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	store.Remember(ctx, "Embedded by a model that is no longer configured", nil, "")
+	store.Remember(ctx, "Embedded with the current model", nil, "")
+	// Pretend the first one came from a previous model
+	store.GetDB().Exec(`UPDATE memories SET embedding_model = 'openai:text-embedding-3-small'
+		WHERE id = (SELECT id FROM memories ORDER BY created_at LIMIT 1)`)
+	store.Close()
+
+	restore := setArgs("phloem", "reembed", "--status")
+	out, _ := captureStdout(func() {
+		if e := Execute(); e != nil {
+			t.Fatalf("Execute(reembed --status): %v", e)
+		}
+	})
+	restore()
+	if !strings.Contains(out, "openai:text-embedding-3-small") || !strings.Contains(out, "1 memories need re-embedding") {
+		t.Errorf("expected the models in use and a pending count: %s", out)
+	}
+
+	// Flag values persist on the shared command between Execute calls
+	reembedCmd.Flags().Set("status", "false")
+	defer setArgs("phloem", "reembed")()
+	out, _ = captureStdout(func() {
+		if e := Execute(); e != nil {
+			t.Fatalf("Execute(reembed): %v", e)
+		}
+	})
+	if !strings.Contains(out, "Re-embedded 1 memories") {
+		t.Errorf("expected a re-embedding summary: %s", out)
+	}
+
+	out, _ = captureStdout(func() {
+		if e := Execute(); e != nil {
+			t.Fatalf("Execute(reembed): %v", e)
+		}
+	})
+	if !strings.Contains(out, "Every memory is embedded with the current model") {
+		t.Errorf("expected nothing left to do: %s", out)
+	}
+}
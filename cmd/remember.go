@@ -16,19 +16,24 @@ var rememberCmd = &cobra.Command{
 
 Examples:
   phloem remember "always use snake_case for Go test names"
-  phloem remember "prefer composition over inheritance" --tags "architecture,patterns"`,
+  phloem remember "prefer composition over inheritance" --tags "architecture,patterns"
+  phloem remember "JWT expiry is 30 minutes" --supersedes abc123`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tagsStr, _ := cmd.Flags().GetString("tags")
-		return runRemember(args[0], tagsStr)
+		supersedes, _ := cmd.Flags().GetString("supersedes")
+		contradicts, _ := cmd.Flags().GetString("contradicts")
+		return runRemember(args[0], tagsStr, splitList(supersedes), splitList(contradicts))
 	},
 }
 
 func init() {
 	rememberCmd.Flags().String("tags", "", "Comma-separated tags")
+	rememberCmd.Flags().String("supersedes", "", "Comma-separated IDs of memories this one replaces")
+	rememberCmd.Flags().String("contradicts", "", "Comma-separated IDs of memories this one conflicts with")
 }
 
-func runRemember(content, tagsStr string) error {
+func runRemember(content, tagsStr string, supersedes, contradicts []string) error {
 	if content == "" {
 		fmt.Println("Usage: phloem remember \"<content>\" [--tags \"tag1,tag2,...\"]")
 		return nil
@@ -38,18 +43,41 @@ func runRemember(content, tagsStr string) error {
 		return fmt.Errorf("failed to open memory store: %w", err)
 	}
 	defer store.Close()
-	var tags []string
-	if tagsStr != "" {
-		for _, t := range strings.Split(tagsStr, ",") {
-			if s := strings.TrimSpace(t); s != "" {
-				tags = append(tags, s)
-			}
+	tags := splitList(tagsStr)
+	ctx := context.Background()
+	for _, id := range append(append([]string{}, supersedes...), contradicts...) {
+		if mem, err := store.GetMemoryByID(ctx, id); err != nil || mem == nil {
+			return fmt.Errorf("memory not found: %s", id)
 		}
 	}
-	ctx := context.Background()
-	if _, err := store.Remember(ctx, content, tags, ""); err != nil {
+	mem, err := store.Remember(ctx, content, tags, "")
+	if err != nil {
 		return fmt.Errorf("remember failed: %w", err)
 	}
+	for _, id := range supersedes {
+		if err := store.Supersede(ctx, mem.ID, id); err != nil {
+			return fmt.Errorf("supersede %s failed: %w", id, err)
+		}
+	}
+	for _, id := range contradicts {
+		if err := store.Contradict(ctx, mem.ID, id); err != nil {
+			return fmt.Errorf("contradict %s failed: %w", id, err)
+		}
+	}
 	fmt.Println("✅ Remembered.")
 	return nil
 }
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	if s == "" {
+		return out
+	}
+	for _, t := range strings.Split(s, ",") {
+		if v := strings.TrimSpace(t); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
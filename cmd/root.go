@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+
 	"github.com/spf13/cobra"
 )
 
@@ -23,12 +26,21 @@ var rootCmd = &cobra.Command{
 	Short: "Phloem MCP - AI Memory Layer",
 	Long:  "Local-first memory for AI tools via Model Context Protocol.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runServe()
+		return runServe(false)
 	},
 	SilenceUsage:  true,
 	SilenceErrors: true,
 }
 
+// dataDir returns the Phloem data directory (PHLOEM_DATA_DIR or ~/.phloem)
+func dataDir() string {
+	if dir := os.Getenv("PHLOEM_DATA_DIR"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".phloem")
+}
+
 // Execute runs the phloem command
 func Execute() error {
 	return rootCmd.Execute()
@@ -55,6 +67,22 @@ func init() {
 	// remember (defined in remember.go)
 	rootCmd.AddCommand(rememberCmd)
 
+	// edit (defined in edit.go)
+	rootCmd.AddCommand(editCmd)
+
+	// consolidate (defined in consolidate.go)
+	rootCmd.AddCommand(consolidateCmd)
+
+	// reembed (defined in reembed.go)
+	rootCmd.AddCommand(reembedCmd)
+
+	// scan (defined in scan.go)
+	rootCmd.AddCommand(scanCmd)
+
+	// encrypt, decrypt (defined in encrypt.go)
+	rootCmd.AddCommand(encryptCmd)
+	rootCmd.AddCommand(decryptCmd)
+
 	// setup (defined in setup.go)
 	rootCmd.AddCommand(setupCmd)
 
@@ -63,4 +91,10 @@ func init() {
 
 	// audit (defined in audit.go)
 	rootCmd.AddCommand(auditCmd)
+
+	// hooks (defined in hooks.go)
+	rootCmd.AddCommand(hooksCmd)
+
+	// scopes (defined in scopes.go)
+	rootCmd.AddCommand(scopesCmd)
 }
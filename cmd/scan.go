@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+	"github.com/spf13/cobra"
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Find and scrub secrets already stored in memories",
+	Long: `Scan every memory, its edit history and its citation snippets for
+credentials (AWS keys, GitHub/Slack/Stripe tokens, private keys, JWTs,
+secret assignments such as .env lines, and long high-entropy strings)
+and replace them with [REDACTED:<kind>] placeholders.
+
+New memories are scrubbed automatically when they are stored; set
+PHLOEM_REDACT=off to disable that.
+
+Examples:
+  phloem scan --dry-run   # report without changing anything
+  phloem scan`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return runScan(dryRun)
+	},
+}
+
+func init() {
+	scanCmd.Flags().Bool("dry-run", false, "Report secrets without scrubbing them")
+}
+
+func runScan(dryRun bool) error {
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	result, err := store.ScanSecrets(context.Background(), dryRun)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+
+	if len(result.Hits) == 0 {
+		fmt.Printf("✅ No secrets found in %d memories\n", result.Scanned)
+		return nil
+	}
+
+	for _, hit := range result.Hits {
+		kinds := make([]string, 0, len(hit.Findings))
+		for _, f := range hit.Findings {
+			kinds = append(kinds, f.Kind)
+		}
+		fmt.Printf("🔑 %s  %s (in %s)\n", hit.MemoryID, strings.Join(kinds, ", "), strings.Join(hit.Fields, ", "))
+		fmt.Printf("   %s\n", hit.Preview)
+	}
+	fmt.Println()
+
+	if dryRun {
+		fmt.Printf("Found secrets in %d of %d memories. Run without --dry-run to scrub them.\n", len(result.Hits), result.Scanned)
+		return nil
+	}
+	fmt.Printf("🧹 Redacted %d secret(s) in %d of %d memories\n", result.Redacted, len(result.Hits), result.Scanned)
+	return nil
+}
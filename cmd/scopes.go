@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+	"github.com/spf13/cobra"
+)
+
+var scopesCmd = &cobra.Command{
+	Use:   "scopes",
+	Short: "List, inspect and reorganize memory scopes",
+	Long: `Scopes group memories by project: a repository's host and path
+(github.com/owner/repo), an organization or group above it, or a monorepo
+directory below it. Renaming or merging a scope carries everything below it
+along, so memories follow a repository that was renamed or moved to another
+host. Anywhere a scope is expected, "repo" means the repository of the
+current directory.
+
+Examples:
+  phloem scopes list
+  phloem scopes show repo
+  phloem scopes rename github.com/acme/api gitlab.acme.dev/platform/api
+  phloem scopes merge github.com/acme/api-v1 github.com/acme/api
+  phloem scopes delete github.com/me/scratch --forget-memories`,
+}
+
+func init() {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List scopes with their memory counts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScopesList()
+		},
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show <scope>",
+		Short: "Show a scope, its parents and children, and its newest memories",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, _ := cmd.Flags().GetInt("limit")
+			return runScopesShow(args[0], limit)
+		},
+	}
+	showCmd.Flags().Int("limit", 5, "Number of recent memories to show")
+
+	renameCmd := &cobra.Command{
+		Use:   "rename <scope> <new-scope>",
+		Short: "Rename a scope and everything below it",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScopesMove(args[0], args[1], false)
+		},
+	}
+
+	mergeCmd := &cobra.Command{
+		Use:   "merge <scope> <into-scope>",
+		Short: "Move a scope's memories into another scope",
+		Long: `Move the memories of a scope, and of everything below it, into another
+scope. A memory whose text is already stored in the target is merged into
+that memory: tags are combined and its ID keeps resolving.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScopesMove(args[0], args[1], true)
+		},
+	}
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete <scope>",
+		Short: "Remove a scope; its memories become unscoped unless --forget-memories is given",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			forget, _ := cmd.Flags().GetBool("forget-memories")
+			return runScopesDelete(args[0], forget)
+		},
+	}
+	deleteCmd.Flags().Bool("forget-memories", false, "Delete the scope's memories instead of unscoping them")
+
+	scopesCmd.AddCommand(listCmd, showCmd, renameCmd, mergeCmd, deleteCmd)
+}
+
+func runScopesList() error {
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	scopes, err := store.ListScopes(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(scopes) == 0 {
+		fmt.Println("No scopes yet. Memories stored from an MCP session in a git repository are scoped to it.")
+		return nil
+	}
+	for _, sc := range scopes {
+		kind := sc.Type
+		if kind == "" {
+			kind = "-"
+		}
+		fmt.Printf("%-60s %-6s %5d memories\n", sc.ID, kind, sc.Memories)
+	}
+	return nil
+}
+
+func runScopesShow(scope string, limit int) error {
+	scope, err := resolveScopeFlag(scope)
+	if err != nil {
+		return err
+	}
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	detail, err := store.ShowScope(context.Background(), scope, limit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Scope:       %s\n", detail.ID)
+	fmt.Printf("Name:        %s\n", detail.Name)
+	if detail.Type != "" {
+		fmt.Printf("Type:        %s\n", detail.Type)
+	}
+	if !detail.Registered {
+		fmt.Println("Registered:  no")
+	}
+	if detail.Description != "" {
+		fmt.Printf("Description: %s\n", detail.Description)
+	}
+	keys := make([]string, 0, len(detail.Metadata))
+	for k := range detail.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("%-12s %s\n", strings.ToUpper(k[:1])+k[1:]+":", detail.Metadata[k])
+	}
+	fmt.Printf("Memories:    %d (%d including scopes below)\n", detail.Memories, detail.TotalMemories)
+
+	if len(detail.Ancestors) > 0 {
+		fmt.Printf("\nParents:\n")
+		for _, a := range detail.Ancestors {
+			fmt.Printf("  %s\n", a)
+		}
+	}
+	if len(detail.Descendants) > 0 {
+		fmt.Printf("\nBelow:\n")
+		for _, d := range detail.Descendants {
+			fmt.Printf("  %-58s %5d memories\n", d.ID, d.Memories)
+		}
+	}
+	if len(detail.Recent) > 0 {
+		fmt.Printf("\nRecent memories:\n")
+		for _, m := range detail.Recent {
+			fmt.Printf("  %s  %s\n", m.ID, previewContent(m.Content))
+		}
+	}
+	return nil
+}
+
+// runScopesMove renames a scope, or with merge moves it into an existing one
+func runScopesMove(from, to string, merge bool) error {
+	from, err := resolveScopeFlag(from)
+	if err != nil {
+		return err
+	}
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	var result *memory.ScopeMoveResult
+	if merge {
+		result, err = store.MergeScope(ctx, from, to)
+	} else {
+		result, err = store.RenameScope(ctx, from, to)
+	}
+	if err != nil {
+		return err
+	}
+
+	verb := "Renamed"
+	if merge {
+		verb = "Merged"
+	}
+	fmt.Printf("✅ %s %s → %s: %d memories moved", verb, result.From, result.To, result.Moved)
+	if result.Merged > 0 {
+		fmt.Printf(", %d merged into identical memories", result.Merged)
+	}
+	fmt.Println()
+	return nil
+}
+
+func runScopesDelete(scope string, forget bool) error {
+	scope, err := resolveScopeFlag(scope)
+	if err != nil {
+		return err
+	}
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	n, err := store.DeleteScope(context.Background(), scope, forget)
+	if err != nil {
+		return err
+	}
+	if forget {
+		fmt.Printf("🗑️  Deleted scope %s and forgot %d memories\n", scope, n)
+	} else {
+		fmt.Printf("🗑️  Deleted scope %s; %d memories are now unscoped\n", scope, n)
+	}
+	return nil
+}
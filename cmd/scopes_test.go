@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+func TestExecute_Scopes(t *testing.T) {
+	t.Setenv("PHLOEM_DATA_DIR", t.TempDir())
+
+	store, err := memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	store.RememberWithScope(ctx, "Deploys run from main", nil, "", "github.com/acme/api")
+	store.RememberWithScope(ctx, "Handlers live in internal/http", nil, "", "github.com/acme/api/services/gateway")
+	store.RememberWithScope(ctx, "Deploys run from main", nil, "", "github.com/acme/api-legacy")
+	store.Close()
+
+	run := func(args ...string) string {
+		t.Helper()
+		defer setArgs(append([]string{"phloem", "scopes"}, args...)...)()
+		out, _ := captureStdout(func() {
+			if err := Execute(); err != nil {
+				t.Fatalf("scopes %v: %v", args, err)
+			}
+		})
+		return out
+	}
+
+	out := run("list")
+	for _, want := range []string{"github.com/acme/api ", "github.com/acme/api/services/gateway", "github.com/acme/api-legacy"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in list: %s", want, out)
+		}
+	}
+
+	out = run("show", "github.com/acme/api")
+	for _, want := range []string{"Memories:    1 (2 including scopes below)", "github.com/acme/api/services/gateway", "Deploys run from main"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in show: %s", want, out)
+		}
+	}
+
+	out = run("rename", "github.com/acme/api", "gitlab.acme.dev/platform/api")
+	if !strings.Contains(out, "2 memories moved") {
+		t.Errorf("unexpected rename output: %s", out)
+	}
+
+	out = run("merge", "github.com/acme/api-legacy", "gitlab.acme.dev/platform/api")
+	if !strings.Contains(out, "0 memories moved, 1 merged into identical memories") {
+		t.Errorf("unexpected merge output: %s", out)
+	}
+
+	out = run("delete", "gitlab.acme.dev/platform/api/services/gateway")
+	if !strings.Contains(out, "1 memories are now unscoped") {
+		t.Errorf("unexpected delete output: %s", out)
+	}
+
+	out = run("list")
+	if strings.Contains(out, "github.com/acme") || strings.Contains(out, "gateway") {
+		t.Errorf("expected only the renamed scope to remain: %s", out)
+	}
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/CanopyHQ/phloem/internal/mcp"
 	"github.com/spf13/cobra"
@@ -17,10 +20,38 @@ var serveCmd = &cobra.Command{
 The server communicates via JSON-RPC over stdin/stdout and is designed
 to be connected to by an MCP client such as Claude Code, Cursor, etc.
 
+With --http the server instead runs as a long-lived daemon speaking the
+MCP Streamable HTTP transport at http://<addr>/mcp, so several editors can
+share one process. It only binds to localhost and requires the bearer
+token stored in ~/.phloem/http-token (created on first run).
+
+With --watch, files referenced by citations are checked for changes while
+the server runs; their citations are re-verified (debounced) and clients
+get notifications/resources/updated for memories whose confidence changed.
+
+Requests run concurrently and can be cancelled by the client. Tuning:
+  PHLOEM_MCP_WORKERS           concurrent stdio requests (default 4)
+  PHLOEM_TOOL_TIMEOUT          per-call timeout, e.g. 30s (default 60s, 0 = none)
+  PHLOEM_TOOL_TIMEOUT_<TOOL>   override for one tool, e.g. PHLOEM_TOOL_TIMEOUT_COMPOSE=2m
+
 Examples:
   phloem serve
-  phloem mcp`,
-	RunE: func(cmd *cobra.Command, args []string) error { return runServe() },
+  phloem mcp
+  phloem serve --http :7777
+  phloem serve --watch`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		httpAddr, _ := cmd.Flags().GetString("http")
+		watch, _ := cmd.Flags().GetBool("watch")
+		if httpAddr != "" {
+			return runServeHTTP(httpAddr, watch)
+		}
+		return runServe(watch)
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("http", "", "Serve Streamable HTTP on this localhost address (e.g. :7777) instead of stdio")
+	serveCmd.Flags().Bool("watch", false, "Re-verify citations when their files change and notify clients")
 }
 
 var versionCmd = &cobra.Command{
@@ -42,7 +73,7 @@ Examples:
 	RunE: func(cmd *cobra.Command, args []string) error { return runStatus() },
 }
 
-func runServe() error {
+func runServe(watch bool) error {
 	fmt.Fprintln(os.Stderr, "🧠 Phloem MCP - AI Memory Layer")
 	fmt.Fprintln(os.Stderr, "Starting MCP server (stdio transport)...")
 	fmt.Fprintln(os.Stderr, "")
@@ -58,9 +89,39 @@ func runServe() error {
 		return fmt.Errorf("failed to create server: %w", err)
 	}
 
+	if watch {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go server.WatchCitations(ctx, mcp.WatchOptions{})
+	}
+
 	return server.Start()
 }
 
+func runServeHTTP(addr string, watch bool) error {
+	mcp.Version = Version
+
+	server, err := mcp.NewServer()
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
+	defer server.Stop()
+
+	dataDir := server.DataDir()
+	token, err := mcp.LoadOrCreateToken(dataDir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "🔑 Bearer token: %s\n", mcp.TokenPath(dataDir))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if watch {
+		go server.WatchCitations(ctx, mcp.WatchOptions{})
+	}
+	return server.StartHTTP(ctx, addr, token)
+}
+
 func runStatus() error {
 	server, err := mcp.NewServer()
 	if err != nil {
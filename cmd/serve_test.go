@@ -45,3 +45,19 @@ func TestExecute_Status(t *testing.T) {
 		t.Errorf("status output: %q", out)
 	}
 }
+
+func TestExecute_ServeHTTP_RejectsNonLoopback(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("PHLOEM_DATA_DIR", tmpDir)
+	defer os.Unsetenv("PHLOEM_DATA_DIR")
+
+	defer setArgs("phloem", "serve", "--http", "0.0.0.0:0")()
+	err := Execute()
+	serveCmd.Flags().Set("http", "")
+	if err == nil || !strings.Contains(err.Error(), "non-loopback") {
+		t.Fatalf("expected non-loopback error, got %v", err)
+	}
+	if _, statErr := os.Stat(tmpDir + "/http-token"); statErr != nil {
+		t.Errorf("token should be created on first run: %v", statErr)
+	}
+}
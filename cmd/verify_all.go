@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CanopyHQ/phloem/internal/git"
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+// runVerifyAll verifies every citation, optionally limited to one scope and those below it
+func runVerifyAll(scope string, threshold float64, tagStale bool) error {
+	scope, err := resolveScopeFlag(scope)
+	if err != nil {
+		return err
+	}
+
+	store, err := memory.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to open memory store: %w", err)
+	}
+	defer store.Close()
+
+	result, err := store.VerifyAllCitations(context.Background(), memory.VerifyAllOptions{
+		Scope:     scope,
+		Threshold: threshold,
+		TagStale:  tagStale,
+	})
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+
+	if result.Citations == 0 {
+		if scope != "" {
+			fmt.Printf("No citations to verify in scope %s.\n", scope)
+		} else {
+			fmt.Println("No citations to verify.")
+		}
+		return nil
+	}
+
+	fmt.Printf("Verified %d citation(s) across %d file(s)\n\n", result.Citations, len(result.Files))
+	for _, f := range result.Files {
+		icon := "✅"
+		if f.Invalid > 0 {
+			icon = "❌"
+		}
+		fmt.Printf("%s %s (%d/%d valid)\n", icon, f.FilePath, f.Valid, f.Citations)
+	}
+
+	fmt.Printf("\nResults: %d valid, %d invalid\n", result.Valid, result.Invalid)
+	if len(result.Stale) > 0 {
+		fmt.Printf("\nStale memories (confidence below %.0f%%):\n", result.Threshold*100)
+		for _, m := range result.Stale {
+			fmt.Printf("  %s  %3.0f%%  %s\n", m.MemoryID, m.Confidence*100, m.Preview)
+		}
+	}
+	if tagStale {
+		fmt.Printf("\n🏷️  Tagged %d memories '%s', untagged %d that recovered\n", result.Tagged, memory.StaleTag, result.Untagged)
+	}
+	return nil
+}
+
+// resolveScopeFlag expands a --scope value: "repo" is the repository of the current directory
+func resolveScopeFlag(scope string) (string, error) {
+	if scope != "repo" {
+		return scope, nil
+	}
+	repo, err := git.GetCurrentRepository()
+	if err != nil {
+		return "", fmt.Errorf("--scope repo needs a git repository: %w", err)
+	}
+	return repo.Scope(), nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+func TestExecute_VerifyAll(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PHLOEM_DATA_DIR", tmpDir)
+
+	src := filepath.Join(t.TempDir(), "pool.go")
+	os.WriteFile(src, []byte("package db\n\nconst PoolSize = 10\n"), 0644)
+
+	store, err := memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	ok, _ := store.Remember(ctx, "Pool size is 10", nil, "")
+	gone, _ := store.Remember(ctx, "Cache TTL is 5m", nil, "")
+	store.AddCitation(ctx, ok.ID, src, 3, 3, "", "const PoolSize = 10")
+	store.AddCitation(ctx, gone.ID, filepath.Join(filepath.Dir(src), "cache.go"), 3, 3, "", "const TTL = 5 * time.Minute")
+	store.Close()
+
+	defer setArgs("phloem", "verify", "--all", "--tag-stale")()
+	out, _ := captureStdout(func() {
+		if err := Execute(); err != nil {
+			t.Fatalf("verify --all: %v", err)
+		}
+	})
+	verifyCmd.Flags().Set("all", "false")
+	verifyCmd.Flags().Set("tag-stale", "false")
+
+	for _, want := range []string{"Verified 2 citation(s) across 2 file(s)", "✅ " + src + " (1/1 valid)", "cache.go (0/1 valid)", gone.ID, "Tagged 1 memories 'stale'"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output: %s", want, out)
+		}
+	}
+
+	store, err = memory.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	m, _ := store.GetMemoryByID(ctx, gone.ID)
+	if len(m.Tags) != 1 || m.Tags[0] != memory.StaleTag {
+		t.Errorf("expected stale tag, got %v", m.Tags)
+	}
+}
+
+func TestExecute_VerifyRequiresMemoryOrAll(t *testing.T) {
+	t.Setenv("PHLOEM_DATA_DIR", t.TempDir())
+	defer setArgs("phloem", "verify")()
+	if err := Execute(); err == nil || !strings.Contains(err.Error(), "--all") {
+		t.Errorf("expected error mentioning --all, got %v", err)
+	}
+}
@@ -1,6 +1,6 @@
 // Command generate creates starter .graft files for common development domains.
 // Each graft bundles curated, high-value memory seeds that teams can import
-// via `phloem graft import <file.graft>`.
+// via `phloem graft import <file.graft> --allow-unsigned` (the files are not signed).
 //
 // Usage:
 //
@@ -91,7 +91,7 @@ func main() {
 		fmt.Printf("Created %s (%d memories)\n", outPath, len(g.memories))
 	}
 
-	fmt.Println("\nDone. Import with: phloem graft import <file.graft>")
+	fmt.Println("\nDone. Import with: phloem graft import <file.graft> --allow-unsigned")
 }
 
 // helper creates a Memory with sensible defaults.
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// WorkTreeRoot returns the top-level directory of the work tree containing path.
+// path may be a file or a directory; it does not need a remote.
+func WorkTreeRoot(path string) (string, error) {
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", path)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// HeadCommit returns the SHA of HEAD in the repository at root.
+func HeadCommit(root string) (string, error) {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// FollowRenames returns where the file at relPath in commit sha lives now, following
+// renames committed since sha and renames staged in the index. relPath is relative
+// to root. It returns "" if the file was deleted and not renamed.
+func FollowRenames(root, sha, relPath string) (string, error) {
+	path := filepath.ToSlash(relPath)
+
+	// Commits since sha, oldest first, so chained renames (a -> b -> c) resolve in order
+	out, err := exec.Command("git", "-C", root, "log", "--reverse", "--format=", "--name-status", "-M",
+		sha+"..HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read history since %s: %w", sha, err)
+	}
+	path = applyNameStatus(path, string(out))
+
+	// Uncommitted renames (git mv) show up against HEAD
+	if path != "" {
+		if out, err := exec.Command("git", "-C", root, "diff", "--name-status", "-M", "HEAD").Output(); err == nil {
+			path = applyNameStatus(path, string(out))
+		}
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// applyNameStatus applies "git --name-status" lines to path: a rename moves it, a delete clears it.
+func applyNameStatus(path, nameStatus string) string {
+	for _, line := range strings.Split(nameStatus, "\n") {
+		fields := strings.Split(line, "\t")
+		if path == "" || len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		switch fields[0][0] {
+		case 'R':
+			if len(fields) == 3 && fields[1] == path {
+				path = fields[2]
+			}
+		case 'D':
+			if fields[1] == path {
+				path = ""
+			}
+		}
+	}
+	return path
+}
+
+// ChangedFiles lists the files that differ between commits from and to, relative to
+// root. Renames are reported as a delete plus an add so both paths are included. With
+// an empty from, it lists the files changed by commit to itself (including a root commit).
+func ChangedFiles(root, from, to string) ([]string, error) {
+	args := []string{"-C", root, "diff", "--name-only", "--no-renames", from, to}
+	if from == "" {
+		args = []string{"-C", root, "diff-tree", "--root", "--no-commit-id", "--name-only", "--no-renames", "-r", to}
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, filepath.FromSlash(line))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initTestRepo creates a git repository with one commit containing a.go
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nfunc A() {}\n"), 0644))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestWorkTreeRoot(t *testing.T) {
+	dir := initTestRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+
+	root, err := WorkTreeRoot(filepath.Join(dir, "sub", "missing.go"))
+	require.NoError(t, err)
+	want, _ := filepath.EvalSymlinks(dir)
+	got, _ := filepath.EvalSymlinks(root)
+	assert.Equal(t, want, got)
+
+	_, err = WorkTreeRoot(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestFollowRenames(t *testing.T) {
+	dir := initTestRepo(t)
+	sha, err := HeadCommit(dir)
+	require.NoError(t, err)
+
+	runGit(t, dir, "mv", "a.go", "b.go")
+	runGit(t, dir, "commit", "-q", "-m", "rename to b")
+	runGit(t, dir, "mv", "b.go", "c.go") // staged, not committed
+
+	path, err := FollowRenames(dir, sha, "a.go")
+	require.NoError(t, err)
+	assert.Equal(t, "c.go", path)
+
+	runGit(t, dir, "commit", "-q", "-m", "rename to c")
+	runGit(t, dir, "rm", "-q", "c.go")
+	runGit(t, dir, "commit", "-q", "-m", "delete")
+	path, err = FollowRenames(dir, sha, "a.go")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	_, err = FollowRenames(dir, "0000000000000000000000000000000000000000", "a.go")
+	assert.Error(t, err)
+}
+
+func TestApplyNameStatus(t *testing.T) {
+	log := "M\tother.go\nR087\told.go\tnew.go\n\nR100\tnew.go\tdir/final.go\n"
+	assert.Equal(t, "dir/final.go", applyNameStatus("old.go", log))
+	assert.Equal(t, "untouched.go", applyNameStatus("untouched.go", log))
+	assert.Equal(t, "", applyNameStatus("gone.go", "D\tgone.go\n"))
+}
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Markers delimiting the block phloem adds to a hook script, so an existing hook
+// keeps working and the block can be removed again without touching the rest.
+const (
+	hookBlockStart = "# >>> phloem >>>"
+	hookBlockEnd   = "# <<< phloem <<<"
+)
+
+// HooksDir returns the directory git runs hooks from for the repository at root,
+// honouring core.hooksPath.
+func HooksDir(root string) (string, error) {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate hooks directory: %w", err)
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return dir, nil
+}
+
+// InstallHook adds body to the named hook in dir, creating an executable shell script
+// if there is none. Returns false if the hook already has a phloem block.
+func InstallHook(dir, name, body string) (bool, error) {
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read %s hook: %w", name, err)
+	}
+	script := string(existing)
+	if strings.Contains(script, hookBlockStart) {
+		return false, nil
+	}
+
+	if script == "" {
+		script = "#!/bin/sh\n"
+	} else if !strings.HasSuffix(script, "\n") {
+		script += "\n"
+	}
+	script += hookBlockStart + "\n" + strings.TrimRight(body, "\n") + "\n" + hookBlockEnd + "\n"
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return false, fmt.Errorf("failed to write %s hook: %w", name, err)
+	}
+	// WriteFile keeps the mode of an existing file; the hook must be executable
+	if err := os.Chmod(path, 0755); err != nil {
+		return false, fmt.Errorf("failed to make %s hook executable: %w", name, err)
+	}
+	return true, nil
+}
+
+// UninstallHook removes the phloem block from the named hook in dir, deleting the
+// hook if nothing but a shebang is left. Returns false if there was no block.
+func UninstallHook(dir, name string) (bool, error) {
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s hook: %w", name, err)
+	}
+
+	script := string(existing)
+	start := strings.Index(script, hookBlockStart)
+	end := strings.Index(script, hookBlockEnd)
+	if start < 0 || end < start {
+		return false, nil
+	}
+	end += len(hookBlockEnd)
+	if end < len(script) && script[end] == '\n' {
+		end++
+	}
+	script = script[:start] + script[end:]
+
+	if rest := strings.TrimSpace(script); rest == "" || (strings.HasPrefix(rest, "#!") && !strings.Contains(rest, "\n")) {
+		if err := os.Remove(path); err != nil {
+			return false, fmt.Errorf("failed to remove %s hook: %w", name, err)
+		}
+		return true, nil
+	}
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return false, fmt.Errorf("failed to write %s hook: %w", name, err)
+	}
+	return true, nil
+}
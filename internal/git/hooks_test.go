@@ -0,0 +1,82 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooksDir(t *testing.T) {
+	dir := initTestRepo(t)
+	hooks, err := HooksDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "hooks"), hooks)
+
+	runGit(t, dir, "config", "core.hooksPath", ".githooks")
+	hooks, err = HooksDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".githooks"), hooks)
+}
+
+func TestInstallHook_NewAndExisting(t *testing.T) {
+	dir := t.TempDir()
+
+	added, err := InstallHook(dir, "post-commit", "phloem hooks run post-commit")
+	require.NoError(t, err)
+	assert.True(t, added)
+	info, err := os.Stat(filepath.Join(dir, "post-commit"))
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0100, "hook must be executable")
+
+	added, err = InstallHook(dir, "post-commit", "phloem hooks run post-commit")
+	require.NoError(t, err)
+	assert.False(t, added, "second install is a no-op")
+
+	removed, err := UninstallHook(dir, "post-commit")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	_, err = os.Stat(filepath.Join(dir, "post-commit"))
+	assert.True(t, os.IsNotExist(err), "hook with only phloem's block is deleted")
+
+	// An existing hook keeps its own commands
+	original := "#!/bin/sh\necho existing\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "post-merge"), []byte(original), 0644))
+	_, err = InstallHook(dir, "post-merge", "phloem hooks run post-merge")
+	require.NoError(t, err)
+	data, _ := os.ReadFile(filepath.Join(dir, "post-merge"))
+	assert.Contains(t, string(data), "echo existing\n# >>> phloem >>>\nphloem hooks run post-merge\n# <<< phloem <<<\n")
+
+	removed, err = UninstallHook(dir, "post-merge")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	data, _ = os.ReadFile(filepath.Join(dir, "post-merge"))
+	assert.Equal(t, original, string(data))
+
+	removed, err = UninstallHook(dir, "post-checkout")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := initTestRepo(t)
+
+	files, err := ChangedFiles(dir, "", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go"}, files, "root commit lists its files")
+
+	runGit(t, dir, "mv", "a.go", "b.go")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.go"), []byte("package a\n"), 0644))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "rename and add")
+
+	files, err = ChangedFiles(dir, "", "HEAD")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a.go", "b.go", "c.go"}, files)
+
+	files, err = ChangedFiles(dir, "HEAD~1", "HEAD")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a.go", "b.go", "c.go"}, files)
+}
@@ -1,6 +1,8 @@
 package git
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,41 +10,57 @@ import (
 	"strings"
 )
 
+// LocalScopePrefix starts the scope of a repository without a usable remote; the rest
+// is a hash of its work tree path, so the scope is stable as long as the clone stays put.
+const LocalScopePrefix = "local/"
+
 // Repository represents a Git repository
 type Repository struct {
-	Path   string
-	Owner  string
+	Path   string // Work tree root
+	Host   string // Remote host, e.g. "github.com" or "gitlab.example.com"; empty without a remote
+	Owner  string // Everything before the repository name: "owner", or "group/subgroup" on GitLab
 	Name   string
 	Remote string
+	Subdir string // Slash-separated path of the detected directory below Path ("" at the root)
 }
 
-// DetectRepository detects the current Git repository
+// DetectRepository detects the Git repository containing path. The scope comes from
+// the origin remote (or the first remote when there is no origin); repositories without
+// a remote that parses get a local scope derived from their path.
 func DetectRepository(path string) (*Repository, error) {
-	// Find .git directory
-	gitDir, err := findGitDir(path)
+	abs, err := filepath.Abs(path)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
 	}
-
-	// Get remote URL
-	cmd := exec.Command("git", "-C", gitDir, "remote", "get-url", "origin")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get remote URL: %w", err)
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
 	}
 
-	remoteURL := strings.TrimSpace(string(output))
-	owner, name, err := parseRemoteURL(remoteURL)
+	// Find the work tree root
+	root, err := findGitDir(abs)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Repository{
-		Path:   gitDir,
-		Owner:  owner,
-		Name:   name,
-		Remote: remoteURL,
-	}, nil
+	repo := &Repository{Path: root}
+	if rel, err := filepath.Rel(root, abs); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		repo.Subdir = filepath.ToSlash(rel)
+	}
+
+	remoteURL := remoteURL(root)
+	if remoteURL == "" {
+		return repo, nil
+	}
+	repo.Remote = remoteURL
+	host, repoPath, err := parseRemoteURL(remoteURL)
+	if err != nil {
+		return repo, nil // Unrecognised remote (local path, file://): keep the local scope
+	}
+	repo.Host = host
+	if i := strings.LastIndex(repoPath, "/"); i >= 0 {
+		repo.Owner, repo.Name = repoPath[:i], repoPath[i+1:]
+	}
+	return repo, nil
 }
 
 // GetCurrentRepository detects the repository for the current working directory
@@ -55,17 +73,42 @@ func GetCurrentRepository() (*Repository, error) {
 	return DetectRepository(cwd)
 }
 
-// Scope returns the scope identifier for this repository (e.g., "github.com/owner/repo")
+// Scope returns the scope identifier for this repository: the remote host and full
+// path (e.g. "github.com/owner/repo" or "gitlab.example.com/group/subgroup/repo"), or
+// LocalScopePrefix plus a path hash when the repository has no remote.
 func (r *Repository) Scope() string {
-	return fmt.Sprintf("github.com/%s/%s", r.Owner, r.Name)
+	if r.Host == "" {
+		sum := sha256.Sum256([]byte(r.Path))
+		return LocalScopePrefix + hex.EncodeToString(sum[:])[:12]
+	}
+	return r.Host + "/" + r.Owner + "/" + r.Name
+}
+
+// OrgScope returns the scope of the owner or group the repository belongs to
+// (e.g. "github.com/owner"), or "" for a local repository.
+func (r *Repository) OrgScope() string {
+	if r.Host == "" {
+		return ""
+	}
+	return r.Host + "/" + r.Owner
+}
+
+// SubdirScope returns the scope of the directory the repository was detected from:
+// the repository scope plus the subdirectory, for packages of a monorepo.
+func (r *Repository) SubdirScope() string {
+	if r.Subdir == "" {
+		return r.Scope()
+	}
+	return r.Scope() + "/" + r.Subdir
 }
 
-// findGitDir finds the .git directory starting from the given path
+// findGitDir finds the work tree root (the directory holding .git) starting from the given path.
+// .git may be a file, as in linked worktrees and submodules.
 func findGitDir(startPath string) (string, error) {
 	path := startPath
 	for {
 		gitPath := filepath.Join(path, ".git")
-		if info, err := os.Stat(gitPath); err == nil && info.IsDir() {
+		if _, err := os.Stat(gitPath); err == nil {
 			return path, nil
 		}
 
@@ -77,45 +120,75 @@ func findGitDir(startPath string) (string, error) {
 	}
 }
 
-// parseRemoteURL parses a Git remote URL to extract owner and repo name
-// Supports both HTTPS and SSH formats:
+// remoteURL returns the URL of the origin remote, falling back to the first configured
+// remote, or "" when there is none
+func remoteURL(root string) string {
+	if out, err := exec.Command("git", "-C", root, "remote", "get-url", "origin").Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	out, err := exec.Command("git", "-C", root, "remote").Output()
+	if err != nil {
+		return ""
+	}
+	remotes := strings.Fields(string(out))
+	if len(remotes) == 0 {
+		return ""
+	}
+	out, err = exec.Command("git", "-C", root, "remote", "get-url", remotes[0]).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// parseRemoteURL parses a Git remote URL into its host and repository path.
+// Supports HTTPS, SSH and git protocol URLs as well as scp-style SSH:
 // - https://github.com/owner/repo.git
+// - https://gitlab.example.com:8443/group/subgroup/repo
+// - ssh://git@host:2222/owner/repo.git
 // - git@github.com:owner/repo.git
-func parseRemoteURL(url string) (owner, repo string, err error) {
+//
+// The host is lowercased and stripped of credentials and port, so every protocol
+// yields the same scope. The path keeps every segment (GitLab subgroups) and needs at
+// least an owner and a name.
+func parseRemoteURL(url string) (host, path string, err error) {
 	url = strings.TrimSpace(url)
 
-	// Remove .git suffix
-	url = strings.TrimSuffix(url, ".git")
-
-	// Handle SSH format: git@github.com:owner/repo
-	if strings.HasPrefix(url, "git@") {
-		parts := strings.Split(url, ":")
-		if len(parts) != 2 {
-			return "", "", fmt.Errorf("invalid SSH URL format: %s", url)
+	var rest string
+	if i := strings.Index(url, "://"); i >= 0 {
+		switch url[:i] {
+		case "https", "http", "ssh", "git", "git+ssh", "ssh+git":
+		default:
+			return "", "", fmt.Errorf("unsupported URL format: %s", url)
 		}
-		path := parts[1]
-		pathParts := strings.Split(path, "/")
-		if len(pathParts) != 2 {
-			return "", "", fmt.Errorf("invalid repository path: %s", path)
+		rest = url[i+3:]
+		slash := strings.Index(rest, "/")
+		if slash < 0 {
+			return "", "", fmt.Errorf("invalid URL format: %s", url)
 		}
-		return pathParts[0], pathParts[1], nil
-	}
-
-	// Handle HTTPS format: https://github.com/owner/repo
-	if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
-		// Remove protocol
-		url = strings.TrimPrefix(url, "https://")
-		url = strings.TrimPrefix(url, "http://")
-
-		// Split by /
-		parts := strings.Split(url, "/")
-		if len(parts) < 3 {
-			return "", "", fmt.Errorf("invalid HTTPS URL format: %s", url)
+		host, path = rest[:slash], rest[slash+1:]
+	} else {
+		// scp-style SSH: [user@]host:path
+		colon := strings.Index(url, ":")
+		if colon < 0 || strings.Contains(url[:colon], "/") {
+			return "", "", fmt.Errorf("unsupported URL format: %s", url)
 		}
+		host, path = url[:colon], url[colon+1:]
+	}
 
-		// parts[0] is domain (github.com), parts[1] is owner, parts[2] is repo
-		return parts[1], parts[2], nil
+	// Drop credentials and port
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
 	}
+	if colon := strings.Index(host, ":"); colon >= 0 {
+		host = host[:colon]
+	}
+	host = strings.ToLower(host)
 
-	return "", "", fmt.Errorf("unsupported URL format: %s", url)
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	if host == "" || strings.Count(path, "/") < 1 || strings.Contains(path, "//") {
+		return "", "", fmt.Errorf("invalid repository path in %s", url)
+	}
+	return host, path, nil
 }
@@ -1,11 +1,7 @@
 package graft
 
 import (
-	"compress/gzip"
-	"encoding/binary"
-	"encoding/json"
-	"fmt"
-	"os"
+	"io"
 	"time"
 
 	"github.com/CanopyHQ/phloem/internal/memory"
@@ -14,118 +10,96 @@ import (
 // Magic bytes for .graft files: PHLO
 var MagicBytes = []byte{0x50, 0x48, 0x4C, 0x4F}
 
-// Version 1
-const Version = 1
+// Version is the format written by Package and Writer. Version 1 files (a single
+// gzip JSON payload) are still readable.
+const Version = 2
+
+// VersionV1 is the original single-payload format
+const VersionV1 = 1
 
 // Manifest describes the graft metadata
 type Manifest struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Author      string    `json:"author"`
-	Version     string    `json:"version"`
-	CreatedAt   time.Time `json:"created_at"`
-	MemoryCount int       `json:"memory_count"`
-	Tags        []string  `json:"tags"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Author        string    `json:"author"`
+	Version       string    `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+	MemoryCount   int       `json:"memory_count"`
+	CitationCount int       `json:"citation_count,omitempty"`
+	EdgeCount     int       `json:"edge_count,omitempty"`
+	Tags          []string  `json:"tags"`
 }
 
-// Payload is the JSON content inside the gzip stream
+// Payload is a fully loaded graft. Version 1 files store exactly this as gzip JSON.
 type Payload struct {
 	Manifest  Manifest          `json:"manifest"`
 	Memories  []memory.Memory   `json:"memories"`
 	Citations []memory.Citation `json:"citations,omitempty"`
+	Edges     []memory.Edge     `json:"edges,omitempty"`
 }
 
 // Package creates a .graft file from memories
 func Package(manifest Manifest, memories []memory.Memory, citations []memory.Citation, outputPath string) error {
-	// Create payload
-	payload := Payload{
-		Manifest:  manifest,
-		Memories:  memories,
-		Citations: citations,
+	if manifest.CitationCount == 0 {
+		manifest.CitationCount = len(citations)
 	}
-
-	// Create file
-	f, err := os.Create(outputPath)
+	w, err := NewWriter(outputPath, manifest)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer f.Close()
-
-	// Write Magic Bytes
-	if _, err := f.Write(MagicBytes); err != nil {
-		return fmt.Errorf("failed to write magic bytes: %w", err)
+		return err
 	}
-
-	// Write Version
-	if err := binary.Write(f, binary.LittleEndian, uint8(Version)); err != nil {
-		return fmt.Errorf("failed to write version: %w", err)
+	for _, m := range memories {
+		if err := w.WriteMemory(m); err != nil {
+			w.Close()
+			return err
+		}
 	}
-
-	// Create Gzip Writer
-	gz := gzip.NewWriter(f)
-	defer gz.Close()
-
-	// Encode JSON to Gzip
-	encoder := json.NewEncoder(gz)
-	if err := encoder.Encode(payload); err != nil {
-		return fmt.Errorf("failed to encode payload: %w", err)
+	for _, c := range citations {
+		if err := w.WriteCitation(c); err != nil {
+			w.Close()
+			return err
+		}
 	}
-
-	return nil
+	return w.Close()
 }
 
-// Unpack reads a .graft file
+// Unpack reads a whole .graft file into memory. Use Open to stream large grafts.
+// The signature block, if any, is skipped; use Verify to check it.
 func Unpack(inputPath string) (*Payload, error) {
-	f, err := os.Open(inputPath)
+	r, err := Open(inputPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer f.Close()
+	defer r.Close()
 
-	// Check Magic Bytes
-	magic := make([]byte, 4)
-	if _, err := f.Read(magic); err != nil {
-		return nil, fmt.Errorf("failed to read magic bytes: %w", err)
-	}
-	for i := 0; i < 4; i++ {
-		if magic[i] != MagicBytes[i] {
-			return nil, fmt.Errorf("invalid file format: not a .graft file")
+	payload := &Payload{Manifest: r.Manifest}
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case rec.Memory != nil:
+			payload.Memories = append(payload.Memories, *rec.Memory)
+		case rec.Citation != nil:
+			payload.Citations = append(payload.Citations, *rec.Citation)
+		case rec.Edge != nil:
+			payload.Edges = append(payload.Edges, *rec.Edge)
 		}
 	}
-
-	// Check Version
-	var version uint8
-	if err := binary.Read(f, binary.LittleEndian, &version); err != nil {
-		return nil, fmt.Errorf("failed to read version: %w", err)
-	}
-	if version != Version {
-		return nil, fmt.Errorf("unsupported version: %d (expected %d)", version, Version)
-	}
-
-	// Read Gzip
-	gz, err := gzip.NewReader(f)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-	defer gz.Close()
-
-	// Decode JSON
-	var payload Payload
-	if err := json.NewDecoder(gz).Decode(&payload); err != nil {
-		return nil, fmt.Errorf("failed to decode payload: %w", err)
-	}
-
-	return &payload, nil
+	return payload, nil
 }
 
-// Inspect returns just the manifest without fully loading memories
-// Note: Currently we have to decompress the stream, but we could optimize
-// by putting manifest first in a separate block in v2.
+// Inspect returns just the manifest. For version 2 files only the header is read;
+// version 1 files have to be decompressed in full.
 func Inspect(inputPath string) (*Manifest, error) {
-	payload, err := Unpack(inputPath)
+	r, err := Open(inputPath)
 	if err != nil {
 		return nil, err
 	}
-	return &payload.Manifest, nil
+	defer r.Close()
+	return &r.Manifest, nil
 }
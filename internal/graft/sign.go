@@ -0,0 +1,282 @@
+package graft
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Signed grafts carry a trailer after the payload:
+//
+//	[PHLO][version][gzip payload][signature JSON][uint32 LE length of signature JSON][PSIG]
+//
+// The signature is ed25519 over sha256(signatureContext || magic || version || gzip payload),
+// so any change to the payload or to the header invalidates it. Readers that only
+// understand unsigned grafts still see a valid payload up to the trailer.
+
+// SignatureMagic marks the end of a signed graft
+var SignatureMagic = []byte{0x50, 0x53, 0x49, 0x47} // PSIG
+
+// AlgorithmEd25519 is the only supported signature algorithm
+const AlgorithmEd25519 = "ed25519"
+
+const signatureContext = "phloem-graft-signature-v1\n"
+
+// maxSignatureBlock bounds the trailer so a corrupt length can't trigger a huge read
+const maxSignatureBlock = 64 * 1024
+
+// ErrInvalidSignature is returned when a graft's signature does not match its contents
+var ErrInvalidSignature = errors.New("graft signature is invalid: the file was modified after signing or the signature is corrupt")
+
+// Signature is the signature block stored in a signed graft.
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	Signer    string `json:"signer"`     // Name the author gave their key (self-asserted)
+	PublicKey string `json:"public_key"` // base64 ed25519 public key
+	Signature string `json:"signature"`  // base64 ed25519 signature
+}
+
+// Fingerprint returns the fingerprint of the signing key, or "" if the key is malformed.
+func (s *Signature) Fingerprint() string {
+	key, err := base64.StdEncoding.DecodeString(s.PublicKey)
+	if err != nil {
+		return ""
+	}
+	return fingerprint(key)
+}
+
+// SigningKey is an author's private ed25519 key.
+type SigningKey struct {
+	Signer string
+	Key    ed25519.PrivateKey
+}
+
+// PublicKey is an author's public key as shared with others and kept in a trust store.
+type PublicKey struct {
+	Signer string
+	Key    ed25519.PublicKey
+}
+
+type signingKeyFile struct {
+	Algorithm string `json:"algorithm"`
+	Signer    string `json:"signer"`
+	Seed      string `json:"seed"` // base64 ed25519 seed
+}
+
+// GenerateKey creates a new signing key for the named author.
+func GenerateKey(signer string) (*SigningKey, error) {
+	if strings.TrimSpace(signer) == "" {
+		return nil, fmt.Errorf("signer name is required")
+	}
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return &SigningKey{Signer: strings.TrimSpace(signer), Key: priv}, nil
+}
+
+// PublicKey returns the public half of the signing key.
+func (k *SigningKey) PublicKey() PublicKey {
+	return PublicKey{Signer: k.Signer, Key: k.Key.Public().(ed25519.PublicKey)}
+}
+
+// Save writes the private key to path (mode 0600) and the public key to path + ".pub".
+func (k *SigningKey) Save(path string) error {
+	data, _ := json.MarshalIndent(signingKeyFile{
+		Algorithm: AlgorithmEd25519,
+		Signer:    k.Signer,
+		Seed:      base64.StdEncoding.EncodeToString(k.Key.Seed()),
+	}, "", "  ")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create key dir: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := os.WriteFile(path+".pub", []byte(k.PublicKey().String()+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+	return nil
+}
+
+// LoadSigningKey reads a private key written by SigningKey.Save.
+func LoadSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	var f signingKeyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid signing key file: %w", err)
+	}
+	if f.Algorithm != AlgorithmEd25519 {
+		return nil, fmt.Errorf("unsupported key algorithm: %q", f.Algorithm)
+	}
+	seed, err := base64.StdEncoding.DecodeString(f.Seed)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid signing key file: malformed seed")
+	}
+	return &SigningKey{Signer: f.Signer, Key: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// String formats the key as a single line: "ed25519 <base64 key> <signer>".
+func (p PublicKey) String() string {
+	return fmt.Sprintf("%s %s %s", AlgorithmEd25519, base64.StdEncoding.EncodeToString(p.Key), p.Signer)
+}
+
+// Fingerprint returns a short, stable identifier for the key.
+func (p PublicKey) Fingerprint() string {
+	return fingerprint(p.Key)
+}
+
+// ParsePublicKey parses a line produced by PublicKey.String.
+func ParsePublicKey(line string) (PublicKey, error) {
+	fields := strings.Fields(strings.TrimSpace(line))
+	if len(fields) < 2 {
+		return PublicKey{}, fmt.Errorf("invalid public key: expected \"ed25519 <key> <name>\"")
+	}
+	if fields[0] != AlgorithmEd25519 {
+		return PublicKey{}, fmt.Errorf("unsupported key algorithm: %q", fields[0])
+	}
+	key, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return PublicKey{}, fmt.Errorf("invalid public key: malformed key data")
+	}
+	return PublicKey{Signer: strings.Join(fields[2:], " "), Key: key}, nil
+}
+
+func fingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "SHA256:" + hex.EncodeToString(sum[:8])
+}
+
+// Sign adds (or replaces) the signature block on a graft file.
+func Sign(path string, key *SigningKey) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	payload, _, err := splitSignature(f)
+	if err != nil {
+		return err
+	}
+	digest, err := payloadDigest(payload)
+	if err != nil {
+		return err
+	}
+
+	sig := Signature{
+		Algorithm: AlgorithmEd25519,
+		Signer:    key.Signer,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key.Key, digest)),
+	}
+	block, _ := json.Marshal(sig)
+
+	// Drop any previous signature, then append the new trailer
+	if err := f.Truncate(payload.Size()); err != nil {
+		return fmt.Errorf("failed to truncate file: %w", err)
+	}
+	if _, err := f.Seek(payload.Size(), io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+	var trailer bytes.Buffer
+	trailer.Write(block)
+	binary.Write(&trailer, binary.LittleEndian, uint32(len(block)))
+	trailer.Write(SignatureMagic)
+	if _, err := f.Write(trailer.Bytes()); err != nil {
+		return fmt.Errorf("failed to write signature: %w", err)
+	}
+	return nil
+}
+
+// Verify checks a graft's signature. It returns (nil, nil) for an unsigned graft,
+// and the signature together with ErrInvalidSignature if the contents don't match it.
+func Verify(path string) (*Signature, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	payload, sig, err := splitSignature(f)
+	if err != nil || sig == nil {
+		return nil, err
+	}
+	if sig.Algorithm != AlgorithmEd25519 {
+		return sig, fmt.Errorf("unsupported signature algorithm: %q", sig.Algorithm)
+	}
+	pub, err := base64.StdEncoding.DecodeString(sig.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return sig, ErrInvalidSignature
+	}
+	signature, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return sig, ErrInvalidSignature
+	}
+	digest, err := payloadDigest(payload)
+	if err != nil {
+		return sig, err
+	}
+	if !ed25519.Verify(pub, digest, signature) {
+		return sig, ErrInvalidSignature
+	}
+	return sig, nil
+}
+
+// splitSignature returns the unsigned part of a graft and its signature block, if any.
+func splitSignature(f *os.File) (*io.SectionReader, *Signature, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	size := info.Size()
+	whole := io.NewSectionReader(f, 0, size)
+	if size < 8 {
+		return whole, nil, nil
+	}
+
+	tail := make([]byte, 8)
+	if _, err := f.ReadAt(tail, size-8); err != nil {
+		return nil, nil, fmt.Errorf("failed to read trailer: %w", err)
+	}
+	if !bytes.Equal(tail[4:], SignatureMagic) {
+		return whole, nil, nil
+	}
+
+	blockLen := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if blockLen == 0 || blockLen > maxSignatureBlock || blockLen > size-8 {
+		return nil, nil, fmt.Errorf("invalid signature block")
+	}
+	block := make([]byte, blockLen)
+	if _, err := f.ReadAt(block, size-8-blockLen); err != nil {
+		return nil, nil, fmt.Errorf("failed to read signature block: %w", err)
+	}
+	var sig Signature
+	if err := json.Unmarshal(block, &sig); err != nil {
+		return nil, nil, fmt.Errorf("invalid signature block: %w", err)
+	}
+	return io.NewSectionReader(f, 0, size-8-blockLen), &sig, nil
+}
+
+func payloadDigest(payload *io.SectionReader) ([]byte, error) {
+	h := sha256.New()
+	h.Write([]byte(signatureContext))
+	if _, err := io.Copy(h, io.NewSectionReader(payload, 0, payload.Size())); err != nil {
+		return nil, fmt.Errorf("failed to hash graft: %w", err)
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,200 @@
+package graft
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+func writeTestGraft(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "signed.graft")
+	manifest := Manifest{ID: "signed", Name: "Signed Graft", Author: "Jane", CreatedAt: time.Now(), MemoryCount: 1}
+	memories := []memory.Memory{{ID: "mem-1", Content: "Signed content", CreatedAt: time.Now()}}
+	if err := Package(manifest, memories, nil, path); err != nil {
+		t.Fatalf("Package: %v", err)
+	}
+	return path
+}
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestGraft(t, dir)
+
+	sig, err := Verify(path)
+	if err != nil || sig != nil {
+		t.Fatalf("unsigned graft: sig=%v err=%v", sig, err)
+	}
+
+	key, err := GenerateKey("Jane Doe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Sign(path, key); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	sig, err = Verify(path)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if sig.Signer != "Jane Doe" || sig.Fingerprint() != key.PublicKey().Fingerprint() {
+		t.Errorf("unexpected signature: %+v", sig)
+	}
+
+	// Signed grafts still unpack
+	payload, err := Unpack(path)
+	if err != nil {
+		t.Fatalf("Unpack signed graft: %v", err)
+	}
+	if payload.Memories[0].Content != "Signed content" {
+		t.Errorf("unexpected content: %q", payload.Memories[0].Content)
+	}
+
+	// Re-signing replaces the signature instead of stacking trailers
+	other, _ := GenerateKey("Someone Else")
+	if err := Sign(path, other); err != nil {
+		t.Fatal(err)
+	}
+	sig, err = Verify(path)
+	if err != nil || sig.Signer != "Someone Else" {
+		t.Errorf("re-sign: sig=%v err=%v", sig, err)
+	}
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestGraft(t, dir)
+	key, _ := GenerateKey("Jane Doe")
+	if err := Sign(path, key); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[10] ^= 0xFF // Flip a byte inside the gzip payload
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Verify(path)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestSigningKey_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys", "graft.key")
+	key, _ := GenerateKey("Jane Doe")
+	if err := key.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("private key should be 0600, got %v", info.Mode().Perm())
+	}
+
+	loaded, err := LoadSigningKey(path)
+	if err != nil {
+		t.Fatalf("LoadSigningKey: %v", err)
+	}
+	if !loaded.Key.Equal(key.Key) || loaded.Signer != "Jane Doe" {
+		t.Error("loaded key does not match")
+	}
+
+	pubLine, err := os.ReadFile(path + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKey(string(pubLine))
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+	if !pub.Key.Equal(key.PublicKey().Key) || pub.Signer != "Jane Doe" {
+		t.Errorf("public key round trip failed: %+v", pub)
+	}
+}
+
+func TestParsePublicKey_Invalid(t *testing.T) {
+	for _, line := range []string{"", "ed25519", "rsa AAAA name", "ed25519 not-base64! name", "ed25519 AAAA name"} {
+		if _, err := ParsePublicKey(line); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
+func TestTrustStore(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestGraft(t, dir)
+	key, _ := GenerateKey("Jane Doe")
+	if err := Sign(path, key); err != nil {
+		t.Fatal(err)
+	}
+
+	trust, err := LoadTrustStore(dir)
+	if err != nil {
+		t.Fatalf("LoadTrustStore on empty dir: %v", err)
+	}
+	v, err := trust.Check(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Trusted || v.Signer != "Jane Doe" {
+		t.Errorf("unknown key should be untrusted: %+v", v)
+	}
+
+	// The trust store name wins over the self-asserted one
+	pub := key.PublicKey()
+	pub.Signer = "Jane (work)"
+	if !trust.Add(pub) {
+		t.Error("Add should report a new key")
+	}
+	if trust.Add(pub) {
+		t.Error("Add should ignore a duplicate key")
+	}
+	if err := trust.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadTrustStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = reloaded.Check(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Trusted || v.Signer != "Jane (work)" {
+		t.Errorf("expected trusted signer from store: %+v", v)
+	}
+
+	if n := reloaded.Remove(pub.Fingerprint()); n != 1 {
+		t.Errorf("Remove by fingerprint removed %d", n)
+	}
+	if len(reloaded.Keys) != 0 {
+		t.Errorf("expected empty store, got %d keys", len(reloaded.Keys))
+	}
+}
+
+func TestLoadTrustStore_Malformed(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(TrustStorePath(dir), []byte("# comment\ned25519 garbage\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadTrustStore(dir)
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("expected error with line number, got %v", err)
+	}
+}
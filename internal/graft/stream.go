@@ -0,0 +1,257 @@
+package graft
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+// Version 2 layout:
+//
+//	[PHLO][02][uint32 LE manifest length][manifest JSON][gzip stream of newline-delimited records]
+//
+// The manifest is stored uncompressed ahead of the records so it can be read without
+// touching the payload, and records are written and read one at a time so a graft
+// never has to fit in memory. An optional signature trailer follows (see sign.go).
+
+// Record types in a version 2 payload
+const (
+	RecordMemory   = "memory"
+	RecordCitation = "citation"
+	RecordEdge     = "edge"
+)
+
+// maxManifestSize bounds the manifest block so a corrupt length can't trigger a huge read
+const maxManifestSize = 1024 * 1024
+
+// Record is one entry in a graft payload. Exactly one of Memory, Citation or Edge is set.
+// Citation.MemoryID and Edge source/target refer to memory IDs within the graft.
+type Record struct {
+	Type     string           `json:"type"`
+	Memory   *memory.Memory   `json:"memory,omitempty"`
+	Citation *memory.Citation `json:"citation,omitempty"`
+	Edge     *memory.Edge     `json:"edge,omitempty"`
+}
+
+// Writer streams records into a version 2 graft file.
+type Writer struct {
+	f   *os.File
+	gz  *gzip.Writer
+	enc *json.Encoder
+}
+
+// NewWriter creates a graft at outputPath and writes its header and manifest.
+// Write memories before the citations and edges that refer to them, then Close.
+func NewWriter(outputPath string, manifest Manifest) (*Writer, error) {
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	header := append([]byte{}, MagicBytes...)
+	header = append(header, uint8(Version))
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(manifestJSON)))
+	header = append(header, manifestJSON...)
+	if _, err := f.Write(header); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	gz := gzip.NewWriter(f)
+	return &Writer{f: f, gz: gz, enc: json.NewEncoder(gz)}, nil
+}
+
+// WriteMemory appends a memory record
+func (w *Writer) WriteMemory(m memory.Memory) error {
+	return w.write(Record{Type: RecordMemory, Memory: &m})
+}
+
+// WriteCitation appends a citation record
+func (w *Writer) WriteCitation(c memory.Citation) error {
+	return w.write(Record{Type: RecordCitation, Citation: &c})
+}
+
+// WriteEdge appends an edge record
+func (w *Writer) WriteEdge(e memory.Edge) error {
+	return w.write(Record{Type: RecordEdge, Edge: &e})
+}
+
+func (w *Writer) write(rec Record) error {
+	if err := w.enc.Encode(rec); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", rec.Type, err)
+	}
+	return nil
+}
+
+// Close flushes the payload and closes the file
+func (w *Writer) Close() error {
+	gzErr := w.gz.Close()
+	fErr := w.f.Close()
+	if gzErr != nil {
+		return fmt.Errorf("failed to finish payload: %w", gzErr)
+	}
+	return fErr
+}
+
+// Reader streams records from a graft file of any supported version.
+type Reader struct {
+	Manifest Manifest
+	Version  int
+
+	file *os.File
+	gz   *gzip.Reader
+	dec  *json.Decoder
+
+	pending []Record // Version 1 files are decoded up front
+}
+
+// Open reads a graft's header and manifest. Call Next to read records and Close when done.
+// The signature block, if any, is skipped; use Verify to check it.
+func Open(inputPath string) (*Reader, error) {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	r := &Reader{file: file}
+	if err := r.readHeader(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reader) readHeader() error {
+	f, _, err := splitSignature(r.file)
+	if err != nil {
+		return err
+	}
+
+	// Check Magic Bytes
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return fmt.Errorf("failed to read magic bytes: %w", err)
+	}
+	for i := 0; i < 4; i++ {
+		if magic[i] != MagicBytes[i] {
+			return fmt.Errorf("invalid file format: not a .graft file")
+		}
+	}
+
+	// Check Version
+	var version uint8
+	if err := binary.Read(f, binary.LittleEndian, &version); err != nil {
+		return fmt.Errorf("failed to read version: %w", err)
+	}
+	r.Version = int(version)
+
+	switch r.Version {
+	case VersionV1:
+		return r.readV1(f)
+	case Version:
+		var size uint32
+		if err := binary.Read(f, binary.LittleEndian, &size); err != nil {
+			return fmt.Errorf("failed to read manifest length: %w", err)
+		}
+		if size > maxManifestSize {
+			return fmt.Errorf("manifest too large: %d bytes", size)
+		}
+		manifestJSON := make([]byte, size)
+		if _, err := io.ReadFull(f, manifestJSON); err != nil {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+		if err := json.Unmarshal(manifestJSON, &r.Manifest); err != nil {
+			return fmt.Errorf("failed to decode manifest: %w", err)
+		}
+		// The payload is opened lazily so reading the manifest stays cheap
+		r.dec = json.NewDecoder(&lazyGzip{r: f, reader: r})
+		return nil
+	default:
+		return fmt.Errorf("unsupported version: %d (expected %d or %d)", version, VersionV1, Version)
+	}
+}
+
+// readV1 decodes a version 1 payload, which is a single gzip JSON document.
+func (r *Reader) readV1(f io.Reader) error {
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gz.Close()
+
+	var payload Payload
+	if err := json.NewDecoder(gz).Decode(&payload); err != nil {
+		return fmt.Errorf("failed to decode payload: %w", err)
+	}
+	r.Manifest = payload.Manifest
+	for i := range payload.Memories {
+		r.pending = append(r.pending, Record{Type: RecordMemory, Memory: &payload.Memories[i]})
+	}
+	for i := range payload.Citations {
+		r.pending = append(r.pending, Record{Type: RecordCitation, Citation: &payload.Citations[i]})
+	}
+	return nil
+}
+
+// Next returns the next record, or io.EOF after the last one.
+// Records of unknown types (from newer writers) are skipped.
+func (r *Reader) Next() (*Record, error) {
+	if r.dec == nil {
+		if len(r.pending) == 0 {
+			return nil, io.EOF
+		}
+		rec := r.pending[0]
+		r.pending = r.pending[1:]
+		return &rec, nil
+	}
+
+	for {
+		var rec Record
+		if err := r.dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to decode record: %w", err)
+		}
+		switch {
+		case rec.Type == RecordMemory && rec.Memory != nil,
+			rec.Type == RecordCitation && rec.Citation != nil,
+			rec.Type == RecordEdge && rec.Edge != nil:
+			return &rec, nil
+		}
+	}
+}
+
+// Close releases the underlying file
+func (r *Reader) Close() error {
+	if r.gz != nil {
+		r.gz.Close()
+	}
+	return r.file.Close()
+}
+
+// lazyGzip opens the gzip stream on first read.
+type lazyGzip struct {
+	r      io.Reader
+	reader *Reader
+}
+
+func (l *lazyGzip) Read(p []byte) (int, error) {
+	if l.reader.gz == nil {
+		gz, err := gzip.NewReader(l.r)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		l.reader.gz = gz
+	}
+	return l.reader.gz.Read(p)
+}
@@ -0,0 +1,158 @@
+package graft
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+// writeV1 writes a graft in the original single-payload format
+func writeV1(t *testing.T, path string, payload Payload) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write(MagicBytes)
+	f.Write([]byte{VersionV1})
+	gz := gzip.NewWriter(f)
+	if err := json.NewEncoder(gz).Encode(payload); err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+}
+
+func TestWriter_StreamsAllRecordTypes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v2.graft")
+	manifest := Manifest{ID: "v2", Name: "V2", MemoryCount: 2, CitationCount: 1, EdgeCount: 1, CreatedAt: time.Now()}
+
+	w, err := NewWriter(path, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteMemory(memory.Memory{ID: "a", Content: "cause"})
+	w.WriteMemory(memory.Memory{ID: "b", Content: "effect"})
+	w.WriteCitation(memory.Citation{ID: "c1", MemoryID: "a", FilePath: "main.go", StartLine: 1, EndLine: 2})
+	w.WriteEdge(memory.Edge{ID: "e1", SourceID: "a", TargetID: "b", EdgeType: "causal"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Version != Version || r.Manifest.EdgeCount != 1 {
+		t.Errorf("unexpected header: version=%d manifest=%+v", r.Version, r.Manifest)
+	}
+
+	var types []string
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, rec.Type)
+	}
+	want := []string{RecordMemory, RecordMemory, RecordCitation, RecordEdge}
+	if len(types) != len(want) {
+		t.Fatalf("records = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("record %d = %s, want %s", i, types[i], want[i])
+		}
+	}
+
+	payload, err := Unpack(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Edges) != 1 || payload.Edges[0].TargetID != "b" {
+		t.Errorf("edges not unpacked: %+v", payload.Edges)
+	}
+}
+
+func TestInspect_ReadsOnlyManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v2.graft")
+	manifest := Manifest{ID: "header-only", Name: "Header Only", MemoryCount: 1}
+	if err := Package(manifest, []memory.Memory{{ID: "m", Content: "x"}}, nil, path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt everything after the manifest; Inspect must not notice
+	data, _ := os.ReadFile(path)
+	manifestLen := int(data[5]) | int(data[6])<<8 | int(data[7])<<16 | int(data[8])<<24
+	payloadStart := 9 + manifestLen
+	for i := payloadStart; i < len(data); i++ {
+		data[i] = 0
+	}
+	os.WriteFile(path, data, 0644)
+
+	inspected, err := Inspect(path)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if inspected.Name != "Header Only" {
+		t.Errorf("name = %q", inspected.Name)
+	}
+	if _, err := Unpack(path); err == nil {
+		t.Error("Unpack should fail on a corrupt payload")
+	}
+}
+
+func TestOpen_ReadsV1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v1.graft")
+	writeV1(t, path, Payload{
+		Manifest:  Manifest{ID: "old", Name: "Old Graft", MemoryCount: 1},
+		Memories:  []memory.Memory{{ID: "m1", Content: "from v1"}},
+		Citations: []memory.Citation{{ID: "c1", MemoryID: "m1", FilePath: "a.go"}},
+	})
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Version != VersionV1 || r.Manifest.Name != "Old Graft" {
+		t.Errorf("unexpected header: version=%d manifest=%+v", r.Version, r.Manifest)
+	}
+
+	payload, err := Unpack(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Memories) != 1 || payload.Memories[0].Content != "from v1" || len(payload.Citations) != 1 {
+		t.Errorf("unexpected v1 payload: %+v", payload)
+	}
+}
+
+func TestOpen_SignedV2(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signed-v2.graft")
+	if err := Package(Manifest{Name: "Signed"}, []memory.Memory{{ID: "m", Content: "signed v2"}}, nil, path); err != nil {
+		t.Fatal(err)
+	}
+	key, _ := GenerateKey("Jane")
+	if err := Sign(path, key); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := Unpack(path)
+	if err != nil {
+		t.Fatalf("Unpack signed v2: %v", err)
+	}
+	if len(payload.Memories) != 1 {
+		t.Errorf("expected 1 memory, got %d", len(payload.Memories))
+	}
+}
@@ -0,0 +1,136 @@
+package graft
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const trustStoreFileName = "trusted_keys"
+
+// TrustStore is the local list of author public keys whose grafts are accepted.
+// It is a text file with one key per line in PublicKey.String format; lines
+// starting with # are comments.
+type TrustStore struct {
+	path string
+	Keys []PublicKey
+}
+
+// Verification is the outcome of checking a graft against a trust store.
+type Verification struct {
+	Signature   *Signature // nil for an unsigned graft
+	Trusted     bool       // Signed by a key in the trust store
+	Signer      string     // Name from the trust store if trusted, otherwise the self-asserted name
+	Fingerprint string
+}
+
+// TrustStorePath returns where the trust store lives in dataDir
+func TrustStorePath(dataDir string) string {
+	return filepath.Join(dataDir, trustStoreFileName)
+}
+
+// LoadTrustStore reads the trust store from dataDir. A missing file is an empty store.
+func LoadTrustStore(dataDir string) (*TrustStore, error) {
+	t := &TrustStore{path: TrustStorePath(dataDir)}
+	data, err := os.ReadFile(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := ParsePublicKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", t.path, lineNo, err)
+		}
+		t.Keys = append(t.Keys, key)
+	}
+	return t, scanner.Err()
+}
+
+// Add adds a key to the store. It returns false if the key was already trusted.
+func (t *TrustStore) Add(key PublicKey) bool {
+	if _, ok := t.Lookup(key.Key); ok {
+		return false
+	}
+	t.Keys = append(t.Keys, key)
+	return true
+}
+
+// Remove removes keys matching a signer name or fingerprint and returns how many were removed.
+func (t *TrustStore) Remove(nameOrFingerprint string) int {
+	kept := t.Keys[:0]
+	removed := 0
+	for _, k := range t.Keys {
+		if k.Signer == nameOrFingerprint || k.Fingerprint() == nameOrFingerprint {
+			removed++
+			continue
+		}
+		kept = append(kept, k)
+	}
+	t.Keys = kept
+	return removed
+}
+
+// Lookup returns the trusted entry for a public key.
+func (t *TrustStore) Lookup(key ed25519.PublicKey) (PublicKey, bool) {
+	for _, k := range t.Keys {
+		if k.Key.Equal(key) {
+			return k, true
+		}
+	}
+	return PublicKey{}, false
+}
+
+// Save writes the store back to disk (mode 0600).
+func (t *TrustStore) Save() error {
+	var buf bytes.Buffer
+	buf.WriteString("# Phloem trusted graft signers: ed25519 <public key> <name>\n")
+	for _, k := range t.Keys {
+		buf.WriteString(k.String() + "\n")
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0700); err != nil {
+		return fmt.Errorf("failed to create data dir: %w", err)
+	}
+	if err := os.WriteFile(t.path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write trust store: %w", err)
+	}
+	return nil
+}
+
+// Check verifies a graft's signature and looks the signer up in the store.
+// A tampered graft returns ErrInvalidSignature; an unsigned one returns a
+// Verification with a nil Signature.
+func (t *TrustStore) Check(path string) (*Verification, error) {
+	sig, err := Verify(path)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verification{Signature: sig}
+	if sig == nil {
+		return v, nil
+	}
+	v.Signer = sig.Signer
+	v.Fingerprint = sig.Fingerprint()
+	pub, _ := base64.StdEncoding.DecodeString(sig.PublicKey)
+	if trusted, ok := t.Lookup(pub); ok {
+		v.Trusted = true
+		v.Signer = trusted.Signer
+	}
+	return v, nil
+}
@@ -0,0 +1,391 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Streamable HTTP transport (MCP 2025-03-26): clients POST JSON-RPC messages to a
+// single endpoint and get JSON or SSE responses; GET opens an SSE stream for
+// server-initiated messages. One daemon can serve several editors at once.
+
+const (
+	httpEndpoint  = "/mcp"
+	sessionHeader = "Mcp-Session-Id"
+	tokenFileName = "http-token"
+	maxHTTPBody   = 1024 * 1024 // Same limit as the stdio scanner
+	sseKeepalive  = 30 * time.Second
+)
+
+type httpTransport struct {
+	server *Server
+	token  string
+
+	mu       sync.Mutex
+	sessions map[string]time.Time   // session ID -> created at
+	streams  map[chan []byte]string // open GET streams -> session ID
+}
+
+// HTTPHandler returns a handler serving MCP over Streamable HTTP at /mcp.
+// Every request must carry "Authorization: Bearer <token>".
+func (s *Server) HTTPHandler(token string) http.Handler {
+	t := &httpTransport{
+		server:   s,
+		token:    token,
+		sessions: make(map[string]time.Time),
+		streams:  make(map[chan []byte]string),
+	}
+	s.sse = t
+
+	mux := http.NewServeMux()
+	mux.Handle(httpEndpoint, t)
+	return mux
+}
+
+// StartHTTP serves MCP over HTTP on a loopback address until ctx is cancelled.
+// A bare port such as ":7777" binds to 127.0.0.1.
+func (s *Server) StartHTTP(ctx context.Context, addr, token string) error {
+	addr, err := loopbackAddr(addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.HTTPHandler(token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+	fmt.Fprintf(os.Stderr, "🧠 Phloem MCP server listening on http://%s%s\n", addr, httpEndpoint)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// loopbackAddr validates that addr is a loopback host:port, defaulting an empty host to 127.0.0.1.
+func loopbackAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if !isLoopbackHost(host) {
+		return "", fmt.Errorf("refusing to listen on non-loopback address %q; use localhost, 127.0.0.1 or ::1", host)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// LoadOrCreateToken returns the bearer token stored in dataDir, generating one on first use.
+func LoadOrCreateToken(dataDir string) (string, error) {
+	path := TokenPath(dataDir)
+	if data, err := os.ReadFile(path); err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create data dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write token: %w", err)
+	}
+	return token, nil
+}
+
+// TokenPath returns where the HTTP bearer token is stored
+func TokenPath(dataDir string) string {
+	return filepath.Join(dataDir, tokenFileName)
+}
+
+func (t *httpTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Browsers send Origin; reject anything that isn't local to block DNS rebinding
+	if !allowedOrigin(r.Header.Get("Origin")) {
+		http.Error(w, "forbidden origin", http.StatusForbidden)
+		return
+	}
+	if !t.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="phloem"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := r.Header.Get(sessionHeader)
+	if sessionID != "" && !t.hasSession(sessionID) {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r, sessionID)
+	case http.MethodGet:
+		t.handleStream(w, r, sessionID)
+	case http.MethodDelete:
+		if sessionID == "" {
+			http.Error(w, "missing "+sessionHeader, http.StatusBadRequest)
+			return
+		}
+		t.closeSession(sessionID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func allowedOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return isLoopbackHost(u.Hostname())
+}
+
+func (t *httpTransport) authorized(r *http.Request) bool {
+	want := "Bearer " + t.token
+	got := r.Header.Get("Authorization")
+	return t.token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// handlePost dispatches one JSON-RPC message or a batch and writes the responses
+// as JSON, or as an SSE stream when the client only accepts text/event-stream.
+func (t *httpTransport) handlePost(w http.ResponseWriter, r *http.Request, sessionID string) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPBody+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHTTPBody {
+		http.Error(w, "message too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	batch := len(body) > 0 && body[0] == '['
+	var messages []json.RawMessage
+	if batch {
+		err = json.Unmarshal(body, &messages)
+	} else {
+		messages = []json.RawMessage{body}
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, newError(nil, -32700, "Parse error", err.Error()))
+		return
+	}
+
+	var responses []*JSONRPCResponse
+	newSession := ""
+	for _, raw := range messages {
+		var req JSONRPCRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			responses = append(responses, newError(nil, -32700, "Parse error", err.Error()))
+			continue
+		}
+		if req.Method == "" {
+			// A response to a server request (roots/list)
+			t.server.handleResponse(withSession(r.Context(), sessionID), raw)
+			continue
+		}
+		if req.Method == "initialize" && sessionID == "" {
+			// Allocate the session up front so initialize can record state for it
+			newSession = t.newSession()
+			sessionID = newSession
+		}
+		if resp := t.server.dispatch(withSession(r.Context(), sessionID), &req); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted) // Only notifications
+		return
+	}
+	if newSession != "" {
+		w.Header().Set(sessionHeader, newSession)
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.Contains(accept, "text/event-stream") && !strings.Contains(accept, "application/json") {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		setSSEHeaders(w)
+		for _, resp := range responses {
+			data, _ := json.Marshal(resp)
+			writeSSE(w, data)
+		}
+		flusher.Flush()
+		return
+	}
+
+	if batch {
+		writeJSON(w, http.StatusOK, responses)
+	} else {
+		writeJSON(w, http.StatusOK, responses[0])
+	}
+}
+
+// handleStream holds open an SSE stream for server-initiated messages.
+func (t *httpTransport) handleStream(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "GET requires Accept: text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan []byte, 16)
+	t.mu.Lock()
+	t.streams[ch] = sessionID
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.streams, ch)
+		t.mu.Unlock()
+	}()
+
+	setSSEHeaders(w)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(sseKeepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data, ok := <-ch:
+			if !ok {
+				return // Session closed
+			}
+			writeSSE(w, data)
+			flusher.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// broadcast sends a message to every open GET stream, dropping it for slow clients.
+func (t *httpTransport) broadcast(data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range t.streams {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// sendTo sends a message to the open GET streams of one session, dropping it for slow clients.
+func (t *httpTransport) sendTo(sessionID string, data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch, sid := range t.streams {
+		if sid != sessionID {
+			continue
+		}
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+func (t *httpTransport) newSession() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	id := hex.EncodeToString(buf)
+	t.mu.Lock()
+	t.sessions[id] = time.Now()
+	t.mu.Unlock()
+	return id
+}
+
+func (t *httpTransport) hasSession(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.sessions[id]
+	return ok
+}
+
+func (t *httpTransport) closeSession(id string) {
+	t.server.forgetSession(id)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sessions, id)
+	for ch, sid := range t.streams {
+		if sid == id {
+			close(ch)
+			delete(t.streams, ch)
+		}
+	}
+}
+
+func setSSEHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+}
+
+func writeSSE(w io.Writer, data []byte) {
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
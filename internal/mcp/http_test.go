@@ -0,0 +1,287 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "test-token"
+
+func setupHTTPServer(t *testing.T) (*Server, *httptest.Server, func()) {
+	t.Helper()
+	server, cleanup := setupTestServer(t)
+	ts := httptest.NewServer(server.HTTPHandler(testToken))
+	return server, ts, func() {
+		ts.Close()
+		cleanup()
+	}
+}
+
+func postMCP(t *testing.T, ts *httptest.Server, body string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestHTTP_Unauthorized(t *testing.T) {
+	_, ts, cleanup := setupHTTPServer(t)
+	defer cleanup()
+
+	resp := postMCP(t, ts, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, map[string]string{"Authorization": "Bearer wrong"})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTP_RejectsRemoteOrigin(t *testing.T) {
+	_, ts, cleanup := setupHTTPServer(t)
+	defer cleanup()
+
+	resp := postMCP(t, ts, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, map[string]string{"Origin": "https://evil.example.com"})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTP_InitializeAndToolCall(t *testing.T) {
+	_, ts, cleanup := setupHTTPServer(t)
+	defer cleanup()
+
+	resp := postMCP(t, ts, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`, nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		t.Fatal("expected Mcp-Session-Id header on initialize")
+	}
+	var initResp JSONRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&initResp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if initResp.Error != nil {
+		t.Fatalf("initialize error: %v", initResp.Error)
+	}
+
+	session := map[string]string{"Mcp-Session-Id": sessionID}
+
+	// Notifications are accepted without a body
+	resp2 := postMCP(t, ts, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, session)
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusAccepted {
+		t.Errorf("expected 202 for notification, got %d", resp2.StatusCode)
+	}
+
+	resp3 := postMCP(t, ts, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"remember","arguments":{"content":"HTTP transport works"}}}`, session)
+	defer resp3.Body.Close()
+	var callResp JSONRPCResponse
+	if err := json.NewDecoder(resp3.Body).Decode(&callResp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	result := callResp.Result.(map[string]interface{})
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "remembered") {
+		t.Errorf("expected 'remembered': %s", text)
+	}
+}
+
+func TestHTTP_Batch(t *testing.T) {
+	_, ts, cleanup := setupHTTPServer(t)
+	defer cleanup()
+
+	body := `[{"jsonrpc":"2.0","id":1,"method":"tools/list"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":2,"method":"prompts/list"}]`
+	resp := postMCP(t, ts, body, nil)
+	defer resp.Body.Close()
+
+	var responses []JSONRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(responses) != 2 {
+		t.Errorf("expected 2 responses (notification gets none), got %d", len(responses))
+	}
+}
+
+func TestHTTP_SSEResponse(t *testing.T) {
+	_, ts, cleanup := setupHTTPServer(t)
+	defer cleanup()
+
+	resp := postMCP(t, ts, `{"jsonrpc":"2.0","id":7,"method":"tools/list"}`, map[string]string{"Accept": "text/event-stream"})
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "event: message\ndata: {") || !strings.Contains(string(body), `"id":7`) {
+		t.Errorf("unexpected SSE body: %s", body)
+	}
+}
+
+func TestHTTP_UnknownSession(t *testing.T) {
+	_, ts, cleanup := setupHTTPServer(t)
+	defer cleanup()
+
+	resp := postMCP(t, ts, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, map[string]string{"Mcp-Session-Id": "nope"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTP_DeleteSession(t *testing.T) {
+	_, ts, cleanup := setupHTTPServer(t)
+	defer cleanup()
+
+	resp := postMCP(t, ts, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`, nil)
+	resp.Body.Close()
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/mcp", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	del, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	del.Body.Close()
+	if del.StatusCode != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", del.StatusCode)
+	}
+
+	resp = postMCP(t, ts, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, map[string]string{"Mcp-Session-Id": sessionID})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTP_StreamReceivesNotifications(t *testing.T) {
+	server, ts, cleanup := setupHTTPServer(t)
+	defer cleanup()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/mcp", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	// Wait for the stream to register before notifying
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		server.sse.mu.Lock()
+		n := len(server.sse.streams)
+		server.sse.mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	server.notify("notifications/resources/updated", map[string]interface{}{"uri": "phloem://memories/recent"})
+
+	reader := bufio.NewReader(resp.Body)
+	lines := make(chan string, 1)
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "data: ") {
+				lines <- line
+				return
+			}
+		}
+	}()
+
+	select {
+	case line := <-lines:
+		if !strings.Contains(line, "notifications/resources/updated") {
+			t.Errorf("unexpected event: %s", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+}
+
+func TestLoopbackAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		want    string
+		wantErr bool
+	}{
+		{":7777", "127.0.0.1:7777", false},
+		{"localhost:7777", "localhost:7777", false},
+		{"[::1]:7777", "[::1]:7777", false},
+		{"0.0.0.0:7777", "", true},
+		{"192.168.1.10:7777", "", true},
+		{"7777", "", true},
+	}
+	for _, tt := range tests {
+		got, err := loopbackAddr(tt.addr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("loopbackAddr(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("loopbackAddr(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestLoadOrCreateToken(t *testing.T) {
+	dir := t.TempDir()
+
+	token, err := LoadOrCreateToken(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 {
+		t.Errorf("expected 64 hex chars, got %d", len(token))
+	}
+
+	info, err := os.Stat(TokenPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("token file should be 0600, got %v", info.Mode().Perm())
+	}
+
+	again, err := LoadOrCreateToken(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != token {
+		t.Error("token should be stable across loads")
+	}
+}
@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Request lifecycle: in-flight tracking for notifications/cancelled, per-tool
+// timeouts and the stdio worker pool size. All knobs are environment variables:
+//
+//	PHLOEM_MCP_WORKERS             concurrent requests on stdio (default 4)
+//	PHLOEM_TOOL_TIMEOUT            default tool timeout, e.g. "30s" or "45" seconds; 0 disables (default 60s)
+//	PHLOEM_TOOL_TIMEOUT_<TOOL>     per-tool override, e.g. PHLOEM_TOOL_TIMEOUT_COMPOSE=2m
+
+const (
+	defaultWorkers     = 4
+	defaultToolTimeout = 60 * time.Second
+)
+
+// errRequestCancelled is the cancellation cause for requests cancelled by the client
+var errRequestCancelled = errors.New("request cancelled by client")
+
+type sessionKey struct{}
+
+// trackedKey marks a context already registered by track.
+type trackedKey struct{}
+
+// withSession tags a request context with the transport session it arrived on,
+// so request IDs from different HTTP clients don't collide.
+func withSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sessionID)
+}
+
+func sessionFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
+
+// inflightKey identifies a request within its session. The JSON type is part of
+// the key because 1 and "1" are different request IDs.
+func inflightKey(ctx context.Context, id interface{}) string {
+	return fmt.Sprintf("%s|%T:%v", sessionFromContext(ctx), id, id)
+}
+
+// track registers a request so notifications/cancelled can cancel it.
+// The returned function must be called when the request finishes. Tracking an
+// already tracked context (a stdio request registered while queued) is a no-op.
+func (s *Server) track(ctx context.Context, id interface{}) (context.Context, func()) {
+	if ctx.Value(trackedKey{}) != nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	if id == nil {
+		return ctx, func() { cancel(nil) }
+	}
+	ctx = context.WithValue(ctx, trackedKey{}, true)
+	key := inflightKey(ctx, id)
+	s.inflightMu.Lock()
+	s.inflight[key] = cancel
+	s.inflightMu.Unlock()
+	return ctx, func() {
+		s.inflightMu.Lock()
+		delete(s.inflight, key)
+		s.inflightMu.Unlock()
+		cancel(nil)
+	}
+}
+
+// handleCancelled processes notifications/cancelled by cancelling the referenced request.
+func (s *Server) handleCancelled(ctx context.Context, req *JSONRPCRequest) {
+	var params struct {
+		RequestID interface{} `json:"requestId"`
+		Reason    string      `json:"reason"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.RequestID == nil {
+		return
+	}
+	key := inflightKey(ctx, params.RequestID)
+	s.inflightMu.Lock()
+	cancel, ok := s.inflight[key]
+	s.inflightMu.Unlock()
+	if ok {
+		cancel(errRequestCancelled)
+	}
+}
+
+// cancelledByClient reports whether ctx was cancelled via notifications/cancelled.
+// Per the MCP spec no response is sent for such requests.
+func cancelledByClient(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errRequestCancelled)
+}
+
+// queuedRequest is a stdio request waiting for a worker. It is tracked from the
+// moment it is read, so it can be cancelled before it starts.
+type queuedRequest struct {
+	req  *JSONRPCRequest
+	ctx  context.Context
+	done func()
+}
+
+// requestQueue is an unbounded FIFO between the stdio reader and the workers.
+// Pushing never blocks, so the reader keeps reading (and applying cancellations)
+// while every worker is busy.
+type requestQueue struct {
+	mu     sync.Mutex
+	ready  *sync.Cond
+	items  []queuedRequest
+	closed bool
+}
+
+func newRequestQueue() *requestQueue {
+	q := &requestQueue{}
+	q.ready = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *requestQueue) push(item queuedRequest) {
+	q.mu.Lock()
+	q.items = append(q.items, item)
+	q.mu.Unlock()
+	q.ready.Signal()
+}
+
+// pop waits for the next request; ok is false once the queue is closed and drained.
+func (q *requestQueue) pop() (item queuedRequest, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.closed {
+		q.ready.Wait()
+	}
+	if len(q.items) == 0 {
+		return queuedRequest{}, false
+	}
+	item = q.items[0]
+	q.items[0] = queuedRequest{}
+	q.items = q.items[1:]
+	return item, true
+}
+
+func (q *requestQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.ready.Broadcast()
+}
+
+// runQueued handles a queued request unless it was cancelled while waiting.
+func (s *Server) runQueued(item queuedRequest) {
+	if cancelledByClient(item.ctx) {
+		item.done()
+		return
+	}
+	resp := s.dispatch(item.ctx, item.req)
+	// Untrack before responding: the client may reuse the ID once it has the response
+	item.done()
+	if resp != nil {
+		s.send(resp)
+	}
+}
+
+// toolTimeout returns the timeout for a tool call, 0 meaning no limit.
+func toolTimeout(tool string) time.Duration {
+	envName := "PHLOEM_TOOL_TIMEOUT_" + strings.ToUpper(tool)
+	if d, ok := parseTimeout(os.Getenv(envName)); ok {
+		return d
+	}
+	if d, ok := parseTimeout(os.Getenv("PHLOEM_TOOL_TIMEOUT")); ok {
+		return d
+	}
+	return defaultToolTimeout
+}
+
+// parseTimeout accepts a Go duration ("90s", "2m") or a number of seconds.
+func parseTimeout(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return d, true
+	}
+	fmt.Fprintf(os.Stderr, "⚠️  Ignoring invalid timeout %q\n", v)
+	return 0, false
+}
+
+// workerCount returns how many stdio requests may run at once.
+func workerCount() int {
+	if n, err := strconv.Atoi(os.Getenv("PHLOEM_MCP_WORKERS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultWorkers
+}
@@ -0,0 +1,287 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockDatabase holds an exclusive write lock on the server's database so tools
+// that write block (up to busy_timeout) until the returned func is called.
+func lockDatabase(t *testing.T, server *Server) func() {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(server.DataDir(), "memories.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ExecContext(context.Background(), "BEGIN EXCLUSIVE"); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		conn.ExecContext(context.Background(), "ROLLBACK")
+		conn.Close()
+		db.Close()
+	}
+}
+
+func rememberRequest(id interface{}) *JSONRPCRequest {
+	params, _ := json.Marshal(map[string]interface{}{
+		"name":      "remember",
+		"arguments": map[string]interface{}{"content": "blocked write"},
+	})
+	return &JSONRPCRequest{JSONRPC: "2.0", ID: id, Method: "tools/call", Params: params}
+}
+
+func TestToolCall_Timeout(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	unlock := lockDatabase(t, server)
+	defer unlock()
+
+	t.Setenv("PHLOEM_TOOL_TIMEOUT_REMEMBER", "100ms")
+
+	start := time.Now()
+	resp := server.dispatch(context.Background(), rememberRequest(1))
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("timeout should return promptly, took %s", elapsed)
+	}
+	if resp == nil {
+		t.Fatal("expected a response")
+	}
+	result := resp.Result.(map[string]interface{})
+	if result["isError"] != true {
+		t.Fatalf("expected isError, got %v", result)
+	}
+	text := result["content"].([]map[string]interface{})[0]["text"].(string)
+	if !strings.Contains(text, "timed out") {
+		t.Errorf("expected timeout message: %s", text)
+	}
+}
+
+func TestNotificationsCancelled(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	unlock := lockDatabase(t, server)
+	defer unlock()
+
+	var wg sync.WaitGroup
+	var resp *JSONRPCResponse
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp = server.dispatch(context.Background(), rememberRequest(float64(42)))
+	}()
+
+	// Wait until the request is in flight
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		server.inflightMu.Lock()
+		n := len(server.inflight)
+		server.inflightMu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel := &JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  json.RawMessage(`{"requestId":42,"reason":"user aborted"}`),
+	}
+	if r := server.dispatch(context.Background(), cancel); r != nil {
+		t.Errorf("notifications get no response, got %v", r)
+	}
+
+	wg.Wait()
+	if resp != nil {
+		t.Errorf("cancelled request should get no response, got %+v", resp)
+	}
+
+	server.inflightMu.Lock()
+	defer server.inflightMu.Unlock()
+	if len(server.inflight) != 0 {
+		t.Errorf("in-flight table should be empty, has %d", len(server.inflight))
+	}
+}
+
+func TestCancelled_ScopedToSession(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ctx, done := server.track(withSession(context.Background(), "a"), float64(1))
+	defer done()
+
+	// Same request ID from another session must not cancel it
+	other := &JSONRPCRequest{Method: "notifications/cancelled", Params: json.RawMessage(`{"requestId":1}`)}
+	server.dispatch(withSession(context.Background(), "b"), other)
+	if ctx.Err() != nil {
+		t.Fatal("request from session a was cancelled by session b")
+	}
+
+	server.dispatch(withSession(context.Background(), "a"), other)
+	if !cancelledByClient(ctx) {
+		t.Error("expected request to be cancelled by its own session")
+	}
+}
+
+func TestStart_ConcurrentRequests(t *testing.T) {
+	var input strings.Builder
+	for i := 1; i <= 20; i++ {
+		// Small responses: captureOutput only drains the pipe after Start returns
+		req, _ := json.Marshal(JSONRPCRequest{JSONRPC: "2.0", ID: i, Method: "prompts/list"})
+		input.Write(req)
+		input.WriteString("\n")
+	}
+	r, w, _ := os.Pipe()
+	w.WriteString(input.String())
+	w.Close()
+
+	// NewServer reads from os.Stdin, so swap it before creating the server
+	oldStdin := os.Stdin
+	os.Stdin = r
+	server, cleanup := setupTestServer(t)
+	os.Stdin = oldStdin
+	defer cleanup()
+
+	output := captureOutput(func() {
+		oldStderr := os.Stderr
+		os.Stderr, _ = os.Open(os.DevNull)
+		defer func() { os.Stderr = oldStderr }()
+		if err := server.Start(); err != nil {
+			t.Errorf("Start: %v", err)
+		}
+	})
+
+	// Every response is a whole line of JSON
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("expected 20 responses, got %d", len(lines))
+	}
+	seen := make(map[float64]bool)
+	for _, line := range lines {
+		var resp JSONRPCResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("interleaved or invalid output line: %v", err)
+		}
+		seen[resp.ID.(float64)] = true
+	}
+	if len(seen) != 20 {
+		t.Errorf("expected 20 distinct IDs, got %d", len(seen))
+	}
+}
+
+func TestStart_CancelWhilePoolSaturated(t *testing.T) {
+	t.Setenv("PHLOEM_MCP_WORKERS", "1")
+
+	// NewServer reads from os.Stdin, so swap it before creating the server
+	stdinR, stdinW, _ := os.Pipe()
+	oldStdin := os.Stdin
+	os.Stdin = stdinR
+	server, cleanup := setupTestServer(t)
+	os.Stdin = oldStdin
+	defer cleanup()
+	unlock := lockDatabase(t, server)
+	defer unlock()
+
+	stdoutR, stdoutW, _ := os.Pipe()
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout = stdoutW
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+	lines := make(chan string, 10)
+	go func() {
+		scanner := bufio.NewScanner(stdoutR)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	finished := make(chan error, 1)
+	go func() { finished <- server.Start() }()
+
+	write := func(req *JSONRPCRequest) {
+		data, _ := json.Marshal(req)
+		stdinW.Write(append(data, '\n'))
+	}
+	cancel := func(id int) *JSONRPCRequest {
+		return &JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/cancelled", Params: json.RawMessage(fmt.Sprintf(`{"requestId":%d}`, id))}
+	}
+
+	// Request 1 holds the only worker and request 2 waits behind it
+	write(rememberRequest(float64(1)))
+	write(rememberRequest(float64(2)))
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		server.inflightMu.Lock()
+		n := len(server.inflight)
+		server.inflightMu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	write(cancel(1))
+	write(cancel(2))
+	write(&JSONRPCRequest{JSONRPC: "2.0", ID: float64(3), Method: "prompts/list"})
+
+	select {
+	case line := <-lines:
+		var resp JSONRPCResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid output line: %v", err)
+		}
+		if resp.ID != float64(3) {
+			t.Errorf("cancelled requests get no response, got one for %v", resp.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancellations were not read while the worker pool was busy")
+	}
+
+	stdinW.Close()
+	if err := <-finished; err != nil {
+		t.Errorf("Start: %v", err)
+	}
+	stdoutW.Close()
+	for line := range lines {
+		t.Errorf("unexpected output after cancellation: %s", line)
+	}
+}
+
+func TestToolTimeoutConfig(t *testing.T) {
+	t.Setenv("PHLOEM_TOOL_TIMEOUT", "")
+	t.Setenv("PHLOEM_TOOL_TIMEOUT_COMPOSE", "")
+	if got := toolTimeout("compose"); got != defaultToolTimeout {
+		t.Errorf("default = %s, want %s", got, defaultToolTimeout)
+	}
+
+	t.Setenv("PHLOEM_TOOL_TIMEOUT", "45")
+	if got := toolTimeout("compose"); got != 45*time.Second {
+		t.Errorf("global seconds = %s", got)
+	}
+
+	t.Setenv("PHLOEM_TOOL_TIMEOUT_COMPOSE", "2m")
+	if got := toolTimeout("compose"); got != 2*time.Minute {
+		t.Errorf("per-tool = %s", got)
+	}
+	if got := toolTimeout("recall"); got != 45*time.Second {
+		t.Errorf("other tools use the global timeout, got %s", got)
+	}
+
+	t.Setenv("PHLOEM_TOOL_TIMEOUT_RECALL", "0")
+	if got := toolTimeout("recall"); got != 0 {
+		t.Errorf("0 disables the timeout, got %s", got)
+	}
+}
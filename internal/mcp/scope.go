@@ -0,0 +1,228 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/CanopyHQ/phloem/internal/git"
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+// Session scope: remember and recall default to the project the client is working in.
+// At initialize the server notes whether the client supports roots; once the client
+// reports notifications/initialized it is asked for roots/list (again after
+// notifications/roots/list_changed), and the first root inside a git repository sets
+// the session's scope. Until then, and for clients without roots, stdio sessions use
+// the server's working directory, which editors set to the project they launched it
+// in. A tool's "scope" argument overrides the default; "*" means every scope.
+
+// allScopes is the scope argument that disables scope filtering
+const allScopes = "*"
+
+// sessionState is what the server knows about one client session
+type sessionState struct {
+	roots bool   // Client declared the roots capability
+	scope string // Default scope; "" until detected
+}
+
+// clientResponse is a client's reply to a server-initiated request
+type clientResponse struct {
+	ID     interface{}     `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RPCError       `json:"error,omitempty"`
+}
+
+// session returns the state of a session, creating it on first use.
+// The caller must hold s.sessionsMu.
+func (s *Server) session(id string) *sessionState {
+	st, ok := s.sessions[id]
+	if !ok {
+		st = &sessionState{}
+		s.sessions[id] = st
+	}
+	return st
+}
+
+// sessionScope returns the default scope of the session ctx belongs to
+func (s *Server) sessionScope(ctx context.Context) string {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	return s.session(sessionFromContext(ctx)).scope
+}
+
+func (s *Server) setSessionScope(sessionID, scope string) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	s.session(sessionID).scope = scope
+}
+
+// forgetSession drops the state of a closed session
+func (s *Server) forgetSession(sessionID string) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	delete(s.sessions, sessionID)
+}
+
+// initSession records the client's capabilities from initialize params and, on stdio,
+// detects a scope from the working directory until the client's roots are known.
+func (s *Server) initSession(ctx context.Context, params json.RawMessage) {
+	var p struct {
+		Capabilities struct {
+			Roots *json.RawMessage `json:"roots"`
+		} `json:"capabilities"`
+	}
+	_ = json.Unmarshal(params, &p)
+
+	sessionID := sessionFromContext(ctx)
+	scope := ""
+	if s.sse == nil {
+		// An HTTP daemon's directory says nothing about its clients
+		if cwd, err := os.Getwd(); err == nil {
+			scope = s.detectScope(ctx, cwd)
+		}
+	}
+
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	st := s.session(sessionID)
+	st.roots = p.Capabilities.Roots != nil
+	st.scope = scope
+}
+
+// requestRoots asks the session's client for its roots if it supports them
+func (s *Server) requestRoots(ctx context.Context) {
+	sessionID := sessionFromContext(ctx)
+	s.sessionsMu.Lock()
+	roots := s.session(sessionID).roots
+	s.sessionsMu.Unlock()
+	if !roots {
+		return
+	}
+
+	s.request(sessionID, "roots/list", nil, func(resp *clientResponse) {
+		if resp.Error != nil {
+			return
+		}
+		var result struct {
+			Roots []struct {
+				URI string `json:"uri"`
+			} `json:"roots"`
+		}
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			return
+		}
+		scope := ""
+		for _, root := range result.Roots {
+			if path := fileURIPath(root.URI); path != "" {
+				if scope = s.detectScope(context.Background(), path); scope != "" {
+					break
+				}
+			}
+		}
+		s.setSessionScope(sessionID, scope)
+		if scope != "" {
+			fmt.Fprintf(os.Stderr, "📁 Session scope: %s\n", scope)
+		}
+	})
+}
+
+// request sends a server-initiated JSON-RPC request to a session's client; onResponse
+// runs when handleResponse receives the reply.
+func (s *Server) request(sessionID, method string, params interface{}, onResponse func(*clientResponse)) {
+	id := fmt.Sprintf("phloem-%d", s.requestSeq.Add(1))
+	s.pendingMu.Lock()
+	s.pending[sessionID+"|"+id] = onResponse
+	s.pendingMu.Unlock()
+
+	msg := map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	data, _ := json.Marshal(msg)
+	if s.sse != nil {
+		s.sse.sendTo(sessionID, data)
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Println(string(data))
+}
+
+// handleResponse routes a client's response to the request it answers
+func (s *Server) handleResponse(ctx context.Context, raw []byte) {
+	var resp clientResponse
+	if err := json.Unmarshal(raw, &resp); err != nil || resp.ID == nil {
+		return
+	}
+	key := fmt.Sprintf("%s|%v", sessionFromContext(ctx), resp.ID)
+	s.pendingMu.Lock()
+	onResponse, ok := s.pending[key]
+	delete(s.pending, key)
+	s.pendingMu.Unlock()
+	if ok {
+		onResponse(&resp)
+	}
+}
+
+// detectScope registers the repository containing dir and returns its most specific
+// scope, or "" when dir is not in a git repository
+func (s *Server) detectScope(ctx context.Context, dir string) string {
+	repo, err := git.DetectRepository(dir)
+	if err != nil {
+		return ""
+	}
+	scope, err := s.store.RegisterRepository(ctx, repo)
+	if err != nil {
+		return repo.SubdirScope()
+	}
+	return scope
+}
+
+// fileURIPath returns the local path of a file:// URI, or "" for other URIs
+func fileURIPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/") // file:///C:/src -> C:/src
+	}
+	return filepath.FromSlash(path)
+}
+
+// writeScope is the scope a remember call stores into: the "scope" argument, or the
+// session's scope. "*" stores the memory unscoped.
+func (s *Server) writeScope(ctx context.Context, args map[string]interface{}) string {
+	scope, _ := args["scope"].(string)
+	switch scope {
+	case "":
+		return s.sessionScope(ctx)
+	case allScopes:
+		return ""
+	}
+	return scope
+}
+
+// readScopes are the scopes a recall call searches: the requested (or session) scope,
+// its ancestors and unscoped memories. Empty, meaning every memory, for "*" or when
+// no scope is known.
+func (s *Server) readScopes(ctx context.Context, args map[string]interface{}) []string {
+	scope, _ := args["scope"].(string)
+	switch scope {
+	case "":
+		scope = s.sessionScope(ctx)
+	case allScopes:
+		return nil
+	}
+	if scope == "" {
+		return nil
+	}
+	return append(memory.ScopeChain(scope), "")
+}
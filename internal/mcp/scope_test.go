@@ -0,0 +1,319 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// initGitRepo creates a git repository with the given origin (none if empty)
+func initGitRepo(t *testing.T, origin string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	if origin != "" {
+		if out, err := exec.Command("git", "-C", dir, "remote", "add", "origin", origin).CombinedOutput(); err != nil {
+			t.Fatalf("git remote add: %v: %s", err, out)
+		}
+	}
+	return dir
+}
+
+func callToolJSON(t *testing.T, server *Server, ctx context.Context, name string, args map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	result, err := server.callTool(ctx, name, args)
+	if err != nil {
+		t.Fatalf("%s failed: %v", name, err)
+	}
+	data, _ := json.Marshal(result)
+	var out map[string]interface{}
+	json.Unmarshal(data, &out)
+	return out
+}
+
+func TestSessionScope_FromWorkingDirectory(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	repo := initGitRepo(t, "git@gitlab.example.com:platform/backend/api.git")
+	t.Chdir(repo)
+
+	ctx := context.Background()
+	captureOutput(func() {
+		server.handleRequest(&JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: json.RawMessage(`{}`)})
+	})
+	if got := server.sessionScope(ctx); got != "gitlab.example.com/platform/backend/api" {
+		t.Fatalf("expected the repository scope, got %q", got)
+	}
+	registered, _ := server.store.GetScope(ctx, "gitlab.example.com/platform/backend")
+	if registered == nil {
+		t.Error("expected the group scope to be registered")
+	}
+
+	remembered := callToolJSON(t, server, ctx, "remember", map[string]interface{}{"content": "The API uses port 8443 for gRPC"})
+	if remembered["scope"] != "gitlab.example.com/platform/backend/api" {
+		t.Errorf("expected remember to default to the session scope, got %v", remembered["scope"])
+	}
+	unscoped := callToolJSON(t, server, ctx, "remember", map[string]interface{}{"content": "The web app uses port 3000", "scope": "*"})
+	if _, ok := unscoped["scope"]; ok {
+		t.Errorf("scope \"*\" should store the memory unscoped, got %v", unscoped["scope"])
+	}
+}
+
+func TestSessionScope_RecallDefaultsAndOverride(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	server.store.RememberWithScope(ctx, "Deploys go through the staging port first", nil, "", "github.com/acme/api")
+	server.store.RememberWithScope(ctx, "Org rule: every port change needs review", nil, "", "github.com/acme")
+	server.store.RememberWithScope(ctx, "The billing service port is 9000", nil, "", "github.com/acme/billing")
+	server.store.Remember(ctx, "Personal note: check the port before deploys", nil, "")
+	server.setSessionScope("", "github.com/acme/api")
+
+	scopesOf := func(result map[string]interface{}) map[string]bool {
+		seen := make(map[string]bool)
+		for _, m := range result["memories"].([]interface{}) {
+			scope, _ := m.(map[string]interface{})["scope"].(string)
+			seen[scope] = true
+		}
+		return seen
+	}
+
+	seen := scopesOf(callToolJSON(t, server, ctx, "recall", map[string]interface{}{"query": "port", "limit": float64(10)}))
+	for _, want := range []string{"github.com/acme/api", "github.com/acme", ""} {
+		if !seen[want] {
+			t.Errorf("expected recall to include scope %q, got %v", want, seen)
+		}
+	}
+	if seen["github.com/acme/billing"] {
+		t.Error("recall should not include another project's memories by default")
+	}
+
+	seen = scopesOf(callToolJSON(t, server, ctx, "recall", map[string]interface{}{"query": "port", "limit": float64(10), "scope": "*"}))
+	if !seen["github.com/acme/billing"] {
+		t.Errorf("scope \"*\" should search every scope, got %v", seen)
+	}
+
+	seen = scopesOf(callToolJSON(t, server, ctx, "recall", map[string]interface{}{"query": "port", "limit": float64(10), "scope": "github.com/acme/billing"}))
+	if !seen["github.com/acme/billing"] || seen["github.com/acme/api"] {
+		t.Errorf("an explicit scope should replace the session scope, got %v", seen)
+	}
+}
+
+func TestSessionScope_ListAndSessionContext(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	server.store.RememberWithScope(ctx, "API decision: retries back off exponentially", []string{"decision"}, "", "github.com/acme/api")
+	server.store.RememberWithScope(ctx, "Billing decision: invoices are immutable", []string{"decision"}, "", "github.com/acme/billing")
+	server.store.Remember(ctx, "Personal note: prefer small pull requests", nil, "")
+
+	for _, tc := range []struct {
+		scope, want, other string
+	}{
+		{"github.com/acme/api", "retries back off", "invoices are immutable"},
+		{"github.com/acme/billing", "invoices are immutable", "retries back off"},
+	} {
+		server.setSessionScope("", tc.scope)
+
+		listed := callToolJSON(t, server, ctx, "list_memories", map[string]interface{}{})
+		var contents []string
+		for _, m := range listed["memories"].([]interface{}) {
+			contents = append(contents, m.(map[string]interface{})["content"].(string))
+		}
+		joined := strings.Join(contents, "\n")
+		if !strings.Contains(joined, tc.want) || !strings.Contains(joined, "small pull requests") {
+			t.Errorf("%s: list_memories should include the session scope and unscoped memories, got %v", tc.scope, contents)
+		}
+		if strings.Contains(joined, tc.other) {
+			t.Errorf("%s: list_memories should not include another project's memories, got %v", tc.scope, contents)
+		}
+
+		sessionContext := callToolJSON(t, server, ctx, "session_context", map[string]interface{}{"hint": "decision"})["context"].(string)
+		if !strings.Contains(sessionContext, tc.want) {
+			t.Errorf("%s: session_context should include the session scope, got:\n%s", tc.scope, sessionContext)
+		}
+		if strings.Contains(sessionContext, tc.other) {
+			t.Errorf("%s: session_context should not include another project's memories, got:\n%s", tc.scope, sessionContext)
+		}
+
+		preload, err := server.buildSessionContext(ctx)
+		if err != nil {
+			t.Fatalf("buildSessionContext: %v", err)
+		}
+		if !strings.Contains(preload, tc.want) || strings.Contains(preload, tc.other) {
+			t.Errorf("%s: the session resource should follow the session scope, got:\n%s", tc.scope, preload)
+		}
+	}
+
+	listed := callToolJSON(t, server, ctx, "list_memories", map[string]interface{}{"scope": "*"})
+	if listed["count"] != float64(3) {
+		t.Errorf("scope \"*\" should list every memory, got %v", listed["count"])
+	}
+}
+
+func TestSessionScope_FromRoots(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	repo := initGitRepo(t, "https://github.com/acme/monorepo.git")
+	pkg := filepath.Join(repo, "services", "api")
+	os.MkdirAll(pkg, 0755)
+	t.Chdir(t.TempDir()) // Not a repository: only the roots can set the scope
+
+	captureOutput(func() {
+		server.handleRequest(&JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize",
+			Params: json.RawMessage(`{"capabilities":{"roots":{"listChanged":true}}}`)})
+	})
+	out := captureOutput(func() {
+		server.handleRequest(&JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+	})
+	var req map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &req); err != nil || req["method"] != "roots/list" {
+		t.Fatalf("expected a roots/list request, got %q", out)
+	}
+
+	reply, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req["id"],
+		"result":  map[string]interface{}{"roots": []map[string]string{{"uri": "file://" + filepath.ToSlash(pkg)}}},
+	})
+	server.handleResponse(ctx, reply)
+	if got := server.sessionScope(ctx); got != "github.com/acme/monorepo/services/api" {
+		t.Errorf("expected the package scope from the root, got %q", got)
+	}
+
+	// A reply to an unknown request is ignored
+	server.handleResponse(ctx, []byte(`{"jsonrpc":"2.0","id":"phloem-999","result":{"roots":[]}}`))
+	if got := server.sessionScope(ctx); got != "github.com/acme/monorepo/services/api" {
+		t.Errorf("unexpected scope change: %q", got)
+	}
+}
+
+func TestSessionScope_NoRootsCapability(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	t.Chdir(t.TempDir())
+
+	captureOutput(func() {
+		server.handleRequest(&JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: json.RawMessage(`{}`)})
+	})
+	out := captureOutput(func() {
+		server.handleRequest(&JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+	})
+	if out != "" {
+		t.Errorf("clients without roots should not be asked for them, got %q", out)
+	}
+	if got := server.sessionScope(context.Background()); got != "" {
+		t.Errorf("expected no scope outside a repository, got %q", got)
+	}
+}
+
+func TestHTTP_SessionScopeFromRoots(t *testing.T) {
+	server, ts, cleanup := setupHTTPServer(t)
+	defer cleanup()
+
+	repo := initGitRepo(t, "git@github.com:acme/web.git")
+	resp := postMCP(t, ts, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{"roots":{}}}}`, nil)
+	resp.Body.Close()
+	sessionID := resp.Header.Get(sessionHeader)
+	if sessionID == "" {
+		t.Fatal("expected a session ID")
+	}
+
+	// Stand in for the client's GET stream
+	stream := make(chan []byte, 4)
+	server.sse.mu.Lock()
+	server.sse.streams[stream] = sessionID
+	server.sse.mu.Unlock()
+
+	resp = postMCP(t, ts, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, map[string]string{sessionHeader: sessionID})
+	resp.Body.Close()
+
+	var req map[string]interface{}
+	select {
+	case data := <-stream:
+		json.Unmarshal(data, &req)
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a roots/list request on the session's stream")
+	}
+	if req["method"] != "roots/list" {
+		t.Fatalf("unexpected request: %v", req)
+	}
+
+	reply, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req["id"],
+		"result":  map[string]interface{}{"roots": []map[string]string{{"uri": "file://" + filepath.ToSlash(repo)}}},
+	})
+	resp = postMCP(t, ts, string(reply), map[string]string{sessionHeader: sessionID})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("expected 202 for a response, got %d", resp.StatusCode)
+	}
+	if got := server.sessionScope(withSession(context.Background(), sessionID)); got != "github.com/acme/web" {
+		t.Errorf("expected the session scope from the root, got %q", got)
+	}
+}
+
+func TestScopeTools(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	server.store.RememberWithScope(ctx, "Releases are tagged from main", nil, "", "github.com/acme/api")
+	server.store.RememberWithScope(ctx, "The worker retries three times", nil, "", "github.com/acme/api/worker")
+	server.store.RememberWithScope(ctx, "Releases are tagged from main", nil, "", "github.com/acme/api-v1")
+	server.setSessionScope("", "github.com/acme/api")
+
+	listed := callToolJSON(t, server, ctx, "list_scopes", nil)
+	if listed["session_scope"] != "github.com/acme/api" {
+		t.Errorf("expected the session scope, got %v", listed["session_scope"])
+	}
+	if n, _ := listed["count"].(float64); n != 3 {
+		t.Errorf("expected 3 scopes, got %v", listed["count"])
+	}
+
+	shown := callToolJSON(t, server, ctx, "show_scope", map[string]interface{}{})
+	if shown["total_memories"] != float64(2) {
+		t.Errorf("expected 2 memories including the worker scope, got %v", shown["total_memories"])
+	}
+	if recent, _ := shown["recent"].([]interface{}); len(recent) != 1 {
+		t.Errorf("expected 1 recent memory, got %v", shown["recent"])
+	}
+
+	if _, err := server.callTool(ctx, "rename_scope", map[string]interface{}{"from": "github.com/acme/api", "to": "github.com/acme/api-v1"}); err == nil {
+		t.Error("renaming onto a scope in use should fail")
+	}
+	renamed := callToolJSON(t, server, ctx, "rename_scope", map[string]interface{}{"from": "github.com/acme/api", "to": "gitlab.acme.dev/platform/api"})
+	if result := renamed["result"].(map[string]interface{}); result["moved"] != float64(2) {
+		t.Errorf("expected 2 memories moved, got %v", result)
+	}
+
+	merged := callToolJSON(t, server, ctx, "merge_scopes", map[string]interface{}{"from": "github.com/acme/api-v1", "into": "gitlab.acme.dev/platform/api"})
+	if result := merged["result"].(map[string]interface{}); result["merged"] != float64(1) {
+		t.Errorf("expected the identical memory to be merged, got %v", result)
+	}
+
+	deleted := callToolJSON(t, server, ctx, "delete_scope", map[string]interface{}{"scope": "gitlab.acme.dev/platform/api"})
+	if deleted["memories"] != float64(2) {
+		t.Errorf("expected 2 memories unscoped, got %v", deleted["memories"])
+	}
+	if _, err := server.callTool(ctx, "show_scope", map[string]interface{}{"scope": "gitlab.acme.dev/platform/api"}); err == nil {
+		t.Error("expected a deleted scope to be gone")
+	}
+}
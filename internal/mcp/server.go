@@ -5,9 +5,12 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/CanopyHQ/phloem/internal/memory"
@@ -16,17 +19,40 @@ import (
 // Version is set by the caller (cmd package) at startup.
 var Version = "dev"
 
-// Server implements the MCP protocol over stdio
+// Server implements the MCP protocol over stdio or Streamable HTTP
 type Server struct {
 	store   *memory.Store
 	scanner *bufio.Scanner
+
+	// HTTP transport, set by HTTPHandler (nil when serving stdio)
+	sse *httpTransport
+
+	// In-flight requests by session and ID, for notifications/cancelled
+	inflightMu sync.Mutex
+	inflight   map[string]context.CancelCauseFunc
+
+	// Serializes writes to stdout
+	outMu sync.Mutex
+
+	// Per-session state (default scope) and server-initiated requests awaiting a reply (see scope.go)
+	sessionsMu sync.Mutex
+	sessions   map[string]*sessionState
+	pendingMu  sync.Mutex
+	pending    map[string]func(*clientResponse)
+	requestSeq atomic.Int64
 }
 
 // MemoryStats contains statistics about the memory store
 type MemoryStats struct {
-	TotalMemories int    `json:"total_memories"`
-	DatabaseSize  string `json:"database_size"`
-	LastActivity  string `json:"last_activity"`
+	TotalMemories  int    `json:"total_memories"`
+	DatabaseSize   string `json:"database_size"`
+	LastActivity   string `json:"last_activity"`
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+	PendingReembed int    `json:"pending_reembed,omitempty"`   // Memories embedded with another model; see phloem reembed
+	QueuedEmbeds   int    `json:"queued_embeddings,omitempty"` // Memories stored while the embedder was unavailable
+
+	EmbeddingCache   *memory.EmbeddingCacheStats   `json:"embedding_cache,omitempty"`   // nil when the embedder is local or the cache is disabled
+	EmbeddingStorage *memory.EmbeddingStorageStats `json:"embedding_storage,omitempty"` // Bytes used by vectors and saved compared with JSON
 }
 
 // NewServer creates a new MCP server
@@ -38,30 +64,65 @@ func NewServer() (*Server, error) {
 	scanner := bufio.NewScanner(os.Stdin)
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // Allow up to 1MB messages
 	return &Server{
-		store:   store,
-		scanner: scanner,
+		store:    store,
+		scanner:  scanner,
+		inflight: make(map[string]context.CancelCauseFunc),
+		sessions: make(map[string]*sessionState),
+		pending:  make(map[string]func(*clientResponse)),
 	}, nil
 }
 
-// Start begins the MCP server loop
+// Start begins the MCP server loop. Requests are handled by a pool of workers so a
+// slow tool call doesn't block others; responses may arrive out of order. The reader
+// never waits for a free worker, so cancellations are applied even when all are busy.
 func (s *Server) Start() error {
 	fmt.Fprintln(os.Stderr, "🧠 Phloem MCP server ready")
 
+	queue := newRequestQueue()
+	var wg sync.WaitGroup
+	for i := 0; i < workerCount(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, ok := queue.pop()
+				if !ok {
+					return
+				}
+				s.runQueued(item)
+			}
+		}()
+	}
+
 	for s.scanner.Scan() {
 		line := s.scanner.Text()
 		if line == "" {
 			continue
 		}
 
-		var request JSONRPCRequest
-		if err := json.Unmarshal([]byte(line), &request); err != nil {
-			s.sendError(nil, -32700, "Parse error", err.Error())
+		request := &JSONRPCRequest{}
+		if err := json.Unmarshal([]byte(line), request); err != nil {
+			s.send(newError(nil, -32700, "Parse error", err.Error()))
+			continue
+		}
+
+		// Responses to our own requests (roots/list) carry no method
+		if request.Method == "" && request.ID != nil {
+			s.handleResponse(context.Background(), []byte(line))
 			continue
 		}
 
-		s.handleRequest(&request)
+		// Cancellations must not queue behind the requests they cancel
+		if request.Method == "notifications/cancelled" {
+			s.handleRequest(request)
+			continue
+		}
+		ctx, done := s.track(context.Background(), request.ID)
+		queue.push(queuedRequest{req: request, ctx: ctx, done: done})
 	}
 
+	queue.close()
+	wg.Wait()
 	return s.scanner.Err()
 }
 
@@ -72,6 +133,11 @@ func (s *Server) Stop() {
 	}
 }
 
+// DataDir returns the directory holding the memory database and server state
+func (s *Server) DataDir() string {
+	return s.store.DataDir()
+}
+
 // GetMemoryStats returns statistics about the memory store
 func (s *Server) GetMemoryStats() MemoryStats {
 	count, _ := s.store.Count(context.Background())
@@ -83,44 +149,76 @@ func (s *Server) GetMemoryStats() MemoryStats {
 		lastActivityStr = lastActivity.Format(time.RFC3339)
 	}
 
-	return MemoryStats{
+	stats := MemoryStats{
 		TotalMemories: count,
 		DatabaseSize:  size,
 		LastActivity:  lastActivityStr,
 	}
+	if embeddings, err := s.store.EmbeddingStatus(context.Background()); err == nil {
+		stats.EmbeddingModel = embeddings.Model
+		stats.PendingReembed = embeddings.Pending
+		stats.QueuedEmbeds = embeddings.Queued
+	}
+	stats.EmbeddingCache = s.store.EmbeddingCacheStats()
+	stats.EmbeddingStorage, _ = s.store.EmbeddingStorage(context.Background())
+	return stats
 }
 
-// handleRequest processes a JSON-RPC request
+// handleRequest processes a JSON-RPC request and writes the response to stdout
 func (s *Server) handleRequest(req *JSONRPCRequest) {
-	ctx := context.Background()
+	if resp := s.dispatch(context.Background(), req); resp != nil {
+		s.send(resp)
+	}
+}
+
+// dispatch processes a JSON-RPC request and returns its response.
+// Notifications (no ID) get no response.
+func (s *Server) dispatch(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	if req.Method == "notifications/cancelled" {
+		s.handleCancelled(ctx, req)
+		return nil
+	}
+	if req.ID == nil && strings.HasPrefix(req.Method, "notifications/") {
+		if req.Method == "notifications/initialized" || req.Method == "notifications/roots/list_changed" {
+			s.requestRoots(ctx)
+		}
+		return nil
+	}
+
+	ctx, done := s.track(ctx, req.ID)
+	defer done()
 
 	switch req.Method {
 	case "initialize":
-		s.handleInitialize(req)
+		s.initSession(ctx, req.Params)
+		return s.handleInitialize(req)
 	case "tools/list":
-		s.handleToolsList(req)
+		return s.handleToolsList(req)
 	case "tools/call":
-		s.handleToolCall(ctx, req)
+		return s.handleToolCall(ctx, req)
 	case "resources/list":
-		s.handleResourcesList(req)
+		return s.handleResourcesList(req)
 	case "resources/read":
-		s.handleResourceRead(ctx, req)
+		return s.handleResourceRead(ctx, req)
+	case "resources/subscribe", "resources/unsubscribe":
+		// Resource updates are sent to every connected client; nothing to record
+		return newResult(req.ID, map[string]interface{}{})
 	case "prompts/list":
-		s.handlePromptsList(req)
+		return s.handlePromptsList(req)
 	case "prompts/get":
-		s.handlePromptsGet(ctx, req)
+		return s.handlePromptsGet(ctx, req)
 	default:
-		s.sendError(req.ID, -32601, "Method not found", req.Method)
+		return newError(req.ID, -32601, "Method not found", req.Method)
 	}
 }
 
 // handleInitialize responds to the initialize request
-func (s *Server) handleInitialize(req *JSONRPCRequest) {
+func (s *Server) handleInitialize(req *JSONRPCRequest) *JSONRPCResponse {
 	result := map[string]interface{}{
 		"protocolVersion": "2024-11-05",
 		"capabilities": map[string]interface{}{
 			"tools":     map[string]interface{}{},
-			"resources": map[string]interface{}{},
+			"resources": map[string]interface{}{"subscribe": true},
 			"prompts":   map[string]interface{}{},
 		},
 		"serverInfo": map[string]interface{}{
@@ -128,11 +226,11 @@ func (s *Server) handleInitialize(req *JSONRPCRequest) {
 			"version": Version,
 		},
 	}
-	s.sendResult(req.ID, result)
+	return newResult(req.ID, result)
 }
 
 // handleToolsList returns available tools
-func (s *Server) handleToolsList(req *JSONRPCRequest) {
+func (s *Server) handleToolsList(req *JSONRPCRequest) *JSONRPCResponse {
 	tools := []map[string]interface{}{
 		{
 			"name":        "remember",
@@ -153,6 +251,10 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 						"type":        "string",
 						"description": "Optional context about when/where this memory applies",
 					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Scope to store the memory in (e.g. \"github.com/owner/repo\"). Defaults to the project of this session; \"*\" stores it unscoped",
+					},
 					"citations": map[string]interface{}{
 						"type":        "array",
 						"description": "Optional citations linking this memory to code/document locations",
@@ -179,17 +281,31 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 									"type":        "string",
 									"description": "Optional content snippet for verification",
 								},
+								"symbol": map[string]interface{}{
+									"type":        "string",
+									"description": "Optional Go symbol to cite instead of lines (e.g. \"RateLimiter.Allow\")",
+								},
 							},
 							"required": []string{"file_path", "start_line", "end_line"},
 						},
 					},
+					"supersedes": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "IDs of memories this one replaces (e.g. an outdated fact). Superseded memories are hidden from recall but kept for lineage.",
+					},
+					"contradicts": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "IDs of memories this one conflicts with. Contradicted memories are down-ranked in recall.",
+					},
 				},
 				"required": []string{"content"},
 			},
 		},
 		{
 			"name":        "recall",
-			"description": "Search memories by semantic similarity. Use this to find relevant past context, decisions, or patterns.",
+			"description": "Search memories by semantic similarity, keyword match, or both. Use this to find relevant past context, decisions, or patterns.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -206,6 +322,19 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 						"items":       map[string]interface{}{"type": "string"},
 						"description": "Filter by tags",
 					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"vector", "lexical", "hybrid"},
+						"description": "Ranking mode: 'vector' (semantic, default), 'lexical' (BM25 keyword match, best for exact identifiers like ERR_TOKEN_EXPIRED), or 'hybrid' (fuses both)",
+					},
+					"include_superseded": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also return memories that have been superseded by newer ones (down-ranked, marked with superseded_by). Default: false",
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Recall from this scope, its parent scopes (e.g. the organization) and unscoped memories. Defaults to the project of this session; \"*\" searches every scope",
+					},
 				},
 				"required": []string{"query"},
 			},
@@ -224,6 +353,33 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 				"required": []string{"id"},
 			},
 		},
+		{
+			"name":        "update_memory",
+			"description": "Edit an existing memory in place (content, tags, or context). The previous version is kept in the memory's revision history.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the memory to update",
+					},
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "New content (re-embedded for semantic search)",
+					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Replacement tags",
+					},
+					"context": map[string]interface{}{
+						"type":        "string",
+						"description": "Replacement context",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
 		{
 			"name":        "list_memories",
 			"description": "List recent memories, optionally filtered by tags",
@@ -239,6 +395,10 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 						"items":       map[string]interface{}{"type": "string"},
 						"description": "Filter by tags",
 					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "List memories from this scope, its parent scopes and unscoped memories. Defaults to the project of this session; \"*\" uses every scope",
+					},
 				},
 			},
 		},
@@ -260,12 +420,16 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 						"type":        "string",
 						"description": "Optional hint about what kind of context to prioritize (e.g., 'phloem architecture', 'business setup')",
 					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Load context from this scope, its parent scopes and unscoped memories. Defaults to the project of this session; \"*\" uses every scope",
+					},
 				},
 			},
 		},
 		{
 			"name":        "add_citation",
-			"description": "Add a citation linking a memory to a specific location in code or documents. Citations enable verification that memories are still accurate.",
+			"description": "Add a citation linking a memory to a specific location in code or documents. Citations enable verification that memories are still accurate; citations inside a git repository follow the code when it moves or the file is renamed.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -289,6 +453,14 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 						"type":        "string",
 						"description": "Snapshot of the cited content for verification",
 					},
+					"commit_sha": map[string]interface{}{
+						"type":        "string",
+						"description": "Commit the line numbers refer to (defaults to HEAD of the file's repository)",
+					},
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Go symbol to cite instead of a line range: a type, func, method (\"RateLimiter.Allow\") or the package name. Only for .go files; reformatting the symbol keeps full confidence",
+					},
 				},
 				"required": []string{"memory_id", "file_path"},
 			},
@@ -335,9 +507,109 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 				"required": []string{"memory_id"},
 			},
 		},
+		{
+			"name":        "verify_all_citations",
+			"description": "Verify every citation (optionally in one scope) against the current files, grouped by file. Reports memories whose confidence fell below a threshold and can tag them 'stale'.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Only verify memories in this scope (e.g. \"github.com/owner/repo\"); all memories if omitted",
+					},
+					"threshold": map[string]interface{}{
+						"type":        "number",
+						"description": "Aggregate confidence below which a memory is stale (default 0.5)",
+					},
+					"tag_stale": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Tag stale memories 'stale' and untag memories that recovered (default false)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "list_scopes",
+			"description": "List memory scopes (repositories, their organizations and monorepo directories) with how many memories each holds, and the scope of this session.",
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "show_scope",
+			"description": "Show a scope: its description and metadata, parent and child scopes, memory counts and newest memories.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Scope to show (e.g. \"github.com/owner/repo\"); defaults to the scope of this session",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of recent memories to include (default: 5)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "rename_scope",
+			"description": "Rename a scope and every scope below it, re-scoping their memories. Use when a repository was renamed or moved to another host.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Current scope",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "New scope; must not exist yet",
+					},
+				},
+				"required": []string{"from", "to"},
+			},
+		},
+		{
+			"name":        "merge_scopes",
+			"description": "Move the memories of a scope (and the scopes below it) into another scope. Memories whose text already exists in the target are merged into it.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Scope to merge away",
+					},
+					"into": map[string]interface{}{
+						"type":        "string",
+						"description": "Scope that receives the memories",
+					},
+				},
+				"required": []string{"from", "into"},
+			},
+		},
+		{
+			"name":        "delete_scope",
+			"description": "Delete a scope and the scopes below it. Their memories become unscoped unless forget_memories is true.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Scope to delete",
+					},
+					"forget_memories": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Delete the memories too (default: false)",
+					},
+				},
+				"required": []string{"scope"},
+			},
+		},
 		{
 			"name":        "causal_query",
-			"description": "Query causal graph: 'neighbors' = memories directly linked by causal edges; 'affected' = memories that would be affected if this memory changed (transitive downstream).",
+			"description": "Query causal graph: 'neighbors' = memories directly linked by causal edges; 'affected' = memories that would be affected if this memory changed (transitive downstream); 'lineage' = how a fact evolved via supersedes/contradicts edges.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -347,7 +619,7 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 					},
 					"query_type": map[string]interface{}{
 						"type":        "string",
-						"description": "One of: 'neighbors' (direct causal neighbors), 'affected' (transitive descendants)",
+						"description": "One of: 'neighbors' (direct causal neighbors), 'affected' (transitive descendants), 'lineage' (supersession chain and contradictions)",
 					},
 				},
 				"required": []string{"memory_id", "query_type"},
@@ -371,6 +643,10 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 						"type":        "integer",
 						"description": "Max results per query before merge (default 5)",
 					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Recall from this scope, its parent scopes and unscoped memories. Defaults to the project of this session; \"*\" uses every scope",
+					},
 				},
 				"required": []string{"query_a", "query_b"},
 			},
@@ -389,6 +665,10 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 						"type":        "integer",
 						"description": "Max suggestions (default 5)",
 					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Suggest memories from this scope, its parent scopes and unscoped memories. Defaults to the project of this session; \"*\" uses every scope",
+					},
 				},
 			},
 		},
@@ -406,81 +686,141 @@ func (s *Server) handleToolsList(req *JSONRPCRequest) {
 						"type":        "integer",
 						"description": "Max suggestions (default: 5)",
 					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Suggest memories from this scope, its parent scopes and unscoped memories. Defaults to the project of this session; \"*\" uses every scope",
+					},
 				},
 				"required": []string{"context"},
 			},
 		},
 	}
 
-	s.sendResult(req.ID, map[string]interface{}{"tools": tools})
+	return newResult(req.ID, map[string]interface{}{"tools": tools})
 }
 
 // handleToolCall executes a tool
-func (s *Server) handleToolCall(ctx context.Context, req *JSONRPCRequest) {
+func (s *Server) handleToolCall(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	var params struct {
 		Name      string                 `json:"name"`
 		Arguments map[string]interface{} `json:"arguments"`
 	}
 
 	if err := json.Unmarshal(req.Params, &params); err != nil {
-		s.sendError(req.ID, -32602, "Invalid params", err.Error())
-		return
+		return newError(req.ID, -32602, "Invalid params", err.Error())
+	}
+
+	timeout := toolTimeout(params.Name)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Run the tool in its own goroutine so a timeout or cancellation returns
+	// promptly even if the tool doesn't check ctx.
+	type outcome struct {
+		result interface{}
+		err    error
 	}
+	ch := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- outcome{err: fmt.Errorf("internal error: %v", r)}
+			}
+		}()
+		result, err := s.callTool(ctx, params.Name, params.Arguments)
+		ch <- outcome{result, err}
+	}()
 
 	var result interface{}
 	var err error
+	select {
+	case o := <-ch:
+		result, err = o.result, o.err
+	case <-ctx.Done():
+		if cancelledByClient(ctx) {
+			return nil
+		}
+		err = fmt.Errorf("%s timed out after %s", params.Name, timeout)
+	}
+	if errors.Is(err, errUnknownTool) {
+		return newError(req.ID, -32602, "Unknown tool", params.Name)
+	}
+	if cancelledByClient(ctx) {
+		return nil
+	}
 
-	switch params.Name {
+	if err != nil {
+		return newResult(req.ID, map[string]interface{}{
+			"content": []map[string]interface{}{
+				{"type": "text", "text": fmt.Sprintf("Error: %v", err)},
+			},
+			"isError": true,
+		})
+	}
+
+	// Format result as MCP content
+	text, _ := json.MarshalIndent(result, "", "  ")
+	return newResult(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			{"type": "text", "text": string(text)},
+		},
+	})
+}
+
+// errUnknownTool is returned by callTool for tool names it doesn't recognize
+var errUnknownTool = errors.New("unknown tool")
+
+// callTool runs a tool by name
+func (s *Server) callTool(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+	switch name {
 	case "remember":
-		result, err = s.toolRemember(ctx, params.Arguments)
+		return s.toolRemember(ctx, args)
 	case "recall":
-		result, err = s.toolRecall(ctx, params.Arguments)
+		return s.toolRecall(ctx, args)
 	case "forget":
-		result, err = s.toolForget(ctx, params.Arguments)
+		return s.toolForget(ctx, args)
+	case "update_memory":
+		return s.toolUpdateMemory(ctx, args)
 	case "list_memories":
-		result, err = s.toolListMemories(ctx, params.Arguments)
+		return s.toolListMemories(ctx, args)
 	case "memory_stats":
-		result, err = s.toolMemoryStats(ctx)
+		return s.toolMemoryStats(ctx)
 	case "session_context":
-		result, err = s.toolSessionContext(ctx, params.Arguments)
+		return s.toolSessionContext(ctx, args)
 	case "add_citation":
-		result, err = s.toolAddCitation(ctx, params.Arguments)
+		return s.toolAddCitation(ctx, args)
 	case "verify_citation":
-		result, err = s.toolVerifyCitation(ctx, params.Arguments)
+		return s.toolVerifyCitation(ctx, args)
 	case "get_citations":
-		result, err = s.toolGetCitations(ctx, params.Arguments)
+		return s.toolGetCitations(ctx, args)
 	case "verify_memory":
-		result, err = s.toolVerifyMemory(ctx, params.Arguments)
+		return s.toolVerifyMemory(ctx, args)
+	case "verify_all_citations":
+		return s.toolVerifyAllCitations(ctx, args)
+	case "list_scopes":
+		return s.toolListScopes(ctx)
+	case "show_scope":
+		return s.toolShowScope(ctx, args)
+	case "rename_scope":
+		return s.toolMoveScope(ctx, args, "to", false)
+	case "merge_scopes":
+		return s.toolMoveScope(ctx, args, "into", true)
+	case "delete_scope":
+		return s.toolDeleteScope(ctx, args)
 	case "causal_query":
-		result, err = s.toolCausalQuery(ctx, params.Arguments)
+		return s.toolCausalQuery(ctx, args)
 	case "compose":
-		result, err = s.toolCompose(ctx, params.Arguments)
+		return s.toolCompose(ctx, args)
 	case "prefetch":
-		result, err = s.toolPrefetch(ctx, params.Arguments)
+		return s.toolPrefetch(ctx, args)
 	case "prefetch_suggest":
-		result, err = s.toolPrefetchSuggest(ctx, params.Arguments)
+		return s.toolPrefetchSuggest(ctx, args)
 	default:
-		s.sendError(req.ID, -32602, "Unknown tool", params.Name)
-		return
+		return nil, errUnknownTool
 	}
-
-	if err != nil {
-		s.sendResult(req.ID, map[string]interface{}{
-			"content": []map[string]interface{}{
-				{"type": "text", "text": fmt.Sprintf("Error: %v", err)},
-			},
-			"isError": true,
-		})
-		return
-	}
-
-	// Format result as MCP content
-	text, _ := json.MarshalIndent(result, "", "  ")
-	s.sendResult(req.ID, map[string]interface{}{
-		"content": []map[string]interface{}{
-			{"type": "text", "text": string(text)},
-		},
-	})
 }
 
 // Tool implementations
@@ -505,12 +845,36 @@ func (s *Server) toolRemember(ctx context.Context, args map[string]interface{})
 		context = c
 	}
 
-	mem, err := s.store.Remember(ctx, content, tags, context)
+	// Validate supersedes/contradicts targets before storing anything
+	supersedes := stringList(args["supersedes"])
+	contradicts := stringList(args["contradicts"])
+	for _, id := range append(append([]string{}, supersedes...), contradicts...) {
+		target, err := s.store.GetMemoryByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil {
+			return nil, fmt.Errorf("memory not found: %s", id)
+		}
+	}
+
+	mem, err := s.store.RememberWithScope(ctx, content, tags, context, s.writeScope(ctx, args))
 	if err != nil {
 		return nil, err
 	}
 	memID := mem.ID
 
+	for _, id := range supersedes {
+		if err := s.store.Supersede(ctx, memID, id); err != nil {
+			return nil, fmt.Errorf("supersedes %s: %w", id, err)
+		}
+	}
+	for _, id := range contradicts {
+		if err := s.store.Contradict(ctx, memID, id); err != nil {
+			return nil, fmt.Errorf("contradicts %s: %w", id, err)
+		}
+	}
+
 	// Add citations if provided
 	var citationsAdded int
 	if citationsRaw, ok := args["citations"].([]interface{}); ok {
@@ -525,9 +889,15 @@ func (s *Server) toolRemember(ctx context.Context, args map[string]interface{})
 			endLine, _ := cit["end_line"].(float64)
 			commitSHA, _ := cit["commit_sha"].(string)
 			citContent, _ := cit["content"].(string)
+			symbol, _ := cit["symbol"].(string)
 
 			if filePath != "" {
-				_, err := s.store.AddCitation(ctx, memID, filePath, int(startLine), int(endLine), commitSHA, citContent)
+				var err error
+				if symbol != "" {
+					_, err = s.store.AddSymbolCitation(ctx, memID, filePath, symbol, commitSHA)
+				} else {
+					_, err = s.store.AddCitation(ctx, memID, filePath, int(startLine), int(endLine), commitSHA, citContent)
+				}
 				if err == nil {
 					citationsAdded++
 				}
@@ -540,14 +910,49 @@ func (s *Server) toolRemember(ctx context.Context, args map[string]interface{})
 		"id":      memID,
 		"message": fmt.Sprintf("Memory stored with ID %s", memID),
 	}
+	if mem.Scope != "" {
+		response["scope"] = mem.Scope
+	}
 	if citationsAdded > 0 {
 		response["citations_added"] = citationsAdded
 		response["message"] = fmt.Sprintf("Memory stored with ID %s and %d citation(s)", memID, citationsAdded)
 	}
+	if len(supersedes) > 0 {
+		response["supersedes"] = supersedes
+	}
+	if len(contradicts) > 0 {
+		response["contradicts"] = contradicts
+	}
+	if len(mem.Redactions) > 0 {
+		kinds := make([]string, 0, len(mem.Redactions))
+		for _, r := range mem.Redactions {
+			kinds = append(kinds, r.Kind)
+		}
+		response["redacted"] = kinds
+		response["message"] = fmt.Sprintf("%s; %d secret(s) were replaced with [REDACTED:...] placeholders", response["message"], len(kinds))
+	}
 
 	return response, nil
 }
 
+// stringList accepts a JSON string or array of strings and returns the non-empty values
+func stringList(raw interface{}) []string {
+	var out []string
+	switch v := raw.(type) {
+	case string:
+		if v != "" {
+			out = append(out, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
 func (s *Server) toolRecall(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	query, ok := args["query"].(string)
 	if !ok || query == "" {
@@ -568,18 +973,42 @@ func (s *Server) toolRecall(ctx context.Context, args map[string]interface{}) (i
 		}
 	}
 
+	modeStr, _ := args["mode"].(string)
+	mode, err := memory.ParseRecallMode(modeStr)
+	if err != nil {
+		return nil, err
+	}
+	includeSuperseded, _ := args["include_superseded"].(bool)
+	scopes := s.readScopes(ctx, args)
+
 	var memories []*memory.Memory
-	var err error
-	if len(tags) > 0 {
-		memories, err = s.store.Recall(ctx, query, limit, tags)
+	if mode != memory.RecallModeVector || (includeSuperseded && len(tags) > 0) {
+		// Overfetch when filtering by tags so the limit still fills
+		fetchLimit := limit
+		if len(tags) > 0 {
+			fetchLimit = limit * 5
+		}
+		options := memory.RecallOptions{ConfidenceWeight: 0.15, Mode: mode, IncludeSuperseded: includeSuperseded, Scopes: scopes}
+		memories, err = s.store.RecallWithRecencyBoost(ctx, query, fetchLimit, options)
+		if err != nil {
+			return nil, err
+		}
+		if len(tags) > 0 {
+			memories = filterByTags(memories, tags)
+		}
+		if len(memories) > limit {
+			memories = memories[:limit]
+		}
+	} else if len(tags) > 0 {
+		memories, err = s.store.RecallWithScopes(ctx, query, limit, tags, scopes)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		options := memory.RecallOptions{ConfidenceWeight: 0.15}
+		options := memory.RecallOptions{ConfidenceWeight: 0.15, IncludeSuperseded: includeSuperseded, Scopes: scopes}
 		memories, err = s.store.RecallWithRecencyBoost(ctx, query, limit, options)
 		if err != nil {
-			memories, err = s.store.Recall(ctx, query, limit, nil)
+			memories, err = s.store.RecallWithScopes(ctx, query, limit, nil, scopes)
 			if err != nil {
 				return nil, err
 			}
@@ -603,13 +1032,59 @@ func (s *Server) toolRecall(ctx context.Context, args map[string]interface{}) (i
 			"confidence": confidence,
 			"source":     "local",
 		}
+		if mem.Scope != "" {
+			results[i]["scope"] = mem.Scope
+		}
+		if mem.SupersededBy != "" {
+			results[i]["superseded_by"] = mem.SupersededBy
+		}
+		if mem.ContradictedBy != "" {
+			results[i]["contradicted_by"] = mem.ContradictedBy
+		}
 	}
 
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"query":    query,
+		"mode":     string(mode),
 		"count":    len(results),
 		"memories": results,
-	}, nil
+	}
+	if len(scopes) > 0 {
+		response["scope"] = scopes[0]
+	}
+	return response, nil
+}
+
+// dropSuperseded removes memories whose IDs are in the superseded set
+func dropSuperseded(memories []*memory.Memory, superseded map[string]bool) []*memory.Memory {
+	if len(superseded) == 0 {
+		return memories
+	}
+	var out []*memory.Memory
+	for _, mem := range memories {
+		if !superseded[mem.ID] {
+			out = append(out, mem)
+		}
+	}
+	return out
+}
+
+// filterByTags keeps memories that have at least one of the given tags
+func filterByTags(memories []*memory.Memory, tags []string) []*memory.Memory {
+	want := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		want[t] = true
+	}
+	var out []*memory.Memory
+	for _, mem := range memories {
+		for _, t := range mem.Tags {
+			if want[t] {
+				out = append(out, mem)
+				break
+			}
+		}
+	}
+	return out
 }
 
 func (s *Server) toolForget(ctx context.Context, args map[string]interface{}) (interface{}, error) {
@@ -629,6 +1104,48 @@ func (s *Server) toolForget(ctx context.Context, args map[string]interface{}) (i
 	}, nil
 }
 
+func (s *Server) toolUpdateMemory(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	var upd memory.MemoryUpdate
+	if c, ok := args["content"].(string); ok {
+		upd.Content = &c
+	}
+	if tagsRaw, ok := args["tags"].([]interface{}); ok {
+		tags := []string{}
+		for _, t := range tagsRaw {
+			if ts, ok := t.(string); ok {
+				tags = append(tags, ts)
+			}
+		}
+		upd.Tags = &tags
+	}
+	if c, ok := args["context"].(string); ok {
+		upd.Context = &c
+	}
+	if upd.Content == nil && upd.Tags == nil && upd.Context == nil {
+		return nil, fmt.Errorf("at least one of content, tags or context is required")
+	}
+
+	mem, err := s.store.Update(ctx, id, upd)
+	if err != nil {
+		return nil, err
+	}
+	revisions, _ := s.store.GetRevisions(ctx, id)
+
+	return map[string]interface{}{
+		"status":    "updated",
+		"id":        mem.ID,
+		"content":   mem.Content,
+		"tags":      mem.Tags,
+		"revisions": len(revisions),
+		"message":   fmt.Sprintf("Memory %s updated (%d prior version(s) kept)", mem.ID, len(revisions)),
+	}, nil
+}
+
 func (s *Server) toolListMemories(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	limit := 10
 	if l, ok := args["limit"].(float64); ok {
@@ -644,7 +1161,7 @@ func (s *Server) toolListMemories(ctx context.Context, args map[string]interface
 		}
 	}
 
-	memories, err := s.store.List(ctx, limit, tags)
+	memories, err := s.store.ListWithScopes(ctx, limit, tags, s.readScopes(ctx, args))
 	if err != nil {
 		return nil, err
 	}
@@ -658,6 +1175,9 @@ func (s *Server) toolListMemories(ctx context.Context, args map[string]interface
 			"created_at": mem.CreatedAt.Format(time.RFC3339),
 			"source":     "local",
 		}
+		if mem.Scope != "" {
+			results[i]["scope"] = mem.Scope
+		}
 	}
 
 	return map[string]interface{}{
@@ -677,9 +1197,17 @@ func (s *Server) toolSessionContext(ctx context.Context, args map[string]interfa
 		hint = h
 	}
 
+	scopes := s.readScopes(ctx, args)
 	var sb strings.Builder
 	seen := make(map[string]bool) // Deduplication
 
+	// Superseded memories are stale facts; never surface them
+	if superseded, err := s.store.SupersededIDs(ctx); err == nil {
+		for id := range superseded {
+			seen[id] = true
+		}
+	}
+
 	sb.WriteString("# Session Context Loaded\n\n")
 
 	// SECTION 1: Hint-based semantic search with recency boost
@@ -693,6 +1221,7 @@ func (s *Server) toolSessionContext(ctx context.Context, args map[string]interfa
 			RecencyWeight:        0.3,
 			ImportanceWeight:     0.1,
 			RecencyHalfLifeHours: 72, // 3-day half-life for session context (more aggressive recency)
+			Scopes:               scopes,
 		})
 		if err == nil && len(relevant) > 0 {
 			count := 0
@@ -723,7 +1252,7 @@ func (s *Server) toolSessionContext(ctx context.Context, args map[string]interfa
 
 	// SECTION 2: Recent Activity (guaranteed last 10 memories)
 	// These ALWAYS appear regardless of hint match
-	recent, _ := s.store.List(ctx, 10, nil)
+	recent, _ := s.store.ListWithScopes(ctx, 10, nil, scopes)
 	if len(recent) > 0 {
 		sb.WriteString("## Recent Activity\n\n")
 		count := 0
@@ -757,7 +1286,7 @@ func (s *Server) toolSessionContext(ctx context.Context, args map[string]interfa
 
 	// SECTION 3: Important memories from last 7 days (guaranteed slots)
 	// These surface critical/milestone/decision items even if not in recent or hint-matched
-	important, _ := s.store.GetRecentImportant(ctx, 7*24*time.Hour, 10)
+	important, _ := s.store.GetRecentImportantWithScopes(ctx, 7*24*time.Hour, 10, scopes)
 	unseenImportant := []*memory.Memory{}
 	for _, mem := range important {
 		if !seen[mem.ID] {
@@ -779,7 +1308,7 @@ func (s *Server) toolSessionContext(ctx context.Context, args map[string]interfa
 
 	// SECTION 4: Key categories (only if not already covered)
 	for _, tag := range []string{"decision", "milestone"} {
-		tagged, _ := s.store.List(ctx, 5, []string{tag})
+		tagged, _ := s.store.ListWithScopes(ctx, 5, []string{tag}, scopes)
 		unseen := []*memory.Memory{}
 		for _, mem := range tagged {
 			if !seen[mem.ID] {
@@ -834,7 +1363,7 @@ func filterInterestingTags(tags []string) []string {
 }
 
 // handleResourcesList returns available resources
-func (s *Server) handleResourcesList(req *JSONRPCRequest) {
+func (s *Server) handleResourcesList(req *JSONRPCRequest) *JSONRPCResponse {
 	resources := []map[string]interface{}{
 		{
 			"uri":         "phloem://memories/recent",
@@ -856,18 +1385,17 @@ func (s *Server) handleResourcesList(req *JSONRPCRequest) {
 		},
 	}
 
-	s.sendResult(req.ID, map[string]interface{}{"resources": resources})
+	return newResult(req.ID, map[string]interface{}{"resources": resources})
 }
 
 // handleResourceRead reads a resource
-func (s *Server) handleResourceRead(ctx context.Context, req *JSONRPCRequest) {
+func (s *Server) handleResourceRead(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	var params struct {
 		URI string `json:"uri"`
 	}
 
 	if err := json.Unmarshal(req.Params, &params); err != nil {
-		s.sendError(req.ID, -32602, "Invalid params", err.Error())
-		return
+		return newError(req.ID, -32602, "Invalid params", err.Error())
 	}
 
 	var content interface{}
@@ -882,10 +1410,9 @@ func (s *Server) handleResourceRead(ctx context.Context, req *JSONRPCRequest) {
 		// Return session context as markdown
 		contextMd, err := s.buildSessionContext(ctx)
 		if err != nil {
-			s.sendError(req.ID, -32603, "Internal error", err.Error())
-			return
+			return newError(req.ID, -32603, "Internal error", err.Error())
 		}
-		s.sendResult(req.ID, map[string]interface{}{
+		return newResult(req.ID, map[string]interface{}{
 			"contents": []map[string]interface{}{
 				{
 					"uri":      params.URI,
@@ -894,19 +1421,20 @@ func (s *Server) handleResourceRead(ctx context.Context, req *JSONRPCRequest) {
 				},
 			},
 		})
-		return
 	default:
-		s.sendError(req.ID, -32602, "Unknown resource", params.URI)
-		return
+		id, ok := strings.CutPrefix(params.URI, memoryResourcePrefix)
+		if !ok || id == "" {
+			return newError(req.ID, -32602, "Unknown resource", params.URI)
+		}
+		content, err = s.memoryResource(ctx, id)
 	}
 
 	if err != nil {
-		s.sendError(req.ID, -32603, "Internal error", err.Error())
-		return
+		return newError(req.ID, -32603, "Internal error", err.Error())
 	}
 
 	text, _ := json.MarshalIndent(content, "", "  ")
-	s.sendResult(req.ID, map[string]interface{}{
+	return newResult(req.ID, map[string]interface{}{
 		"contents": []map[string]interface{}{
 			{
 				"uri":      params.URI,
@@ -917,8 +1445,35 @@ func (s *Server) handleResourceRead(ctx context.Context, req *JSONRPCRequest) {
 	})
 }
 
+// memoryResourcePrefix addresses a single memory, e.g. phloem://memories/<id>. The
+// citation watcher sends notifications/resources/updated for these URIs.
+const memoryResourcePrefix = "phloem://memories/"
+
+func memoryResourceURI(id string) string {
+	return memoryResourcePrefix + id
+}
+
+// memoryResource returns a memory with its citations and aggregate confidence
+func (s *Server) memoryResource(ctx context.Context, id string) (interface{}, error) {
+	m, err := s.store.GetMemoryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("memory not found: %s", id)
+	}
+	m.Embedding = nil
+	citations, _ := s.store.GetCitations(ctx, m.ID)
+	confidence, _ := s.store.GetMemoryConfidence(ctx, m.ID)
+	return map[string]interface{}{
+		"memory":     m,
+		"citations":  citations,
+		"confidence": confidence,
+	}, nil
+}
+
 // handlePromptsList returns available prompts
-func (s *Server) handlePromptsList(req *JSONRPCRequest) {
+func (s *Server) handlePromptsList(req *JSONRPCRequest) *JSONRPCResponse {
 	prompts := []map[string]interface{}{
 		{
 			"name":        "with_memory",
@@ -933,30 +1488,27 @@ func (s *Server) handlePromptsList(req *JSONRPCRequest) {
 		},
 	}
 
-	s.sendResult(req.ID, map[string]interface{}{"prompts": prompts})
+	return newResult(req.ID, map[string]interface{}{"prompts": prompts})
 }
 
 // handlePromptsGet returns a prompt with relevant memories injected
-func (s *Server) handlePromptsGet(ctx context.Context, req *JSONRPCRequest) {
+func (s *Server) handlePromptsGet(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	var params struct {
 		Name      string            `json:"name"`
 		Arguments map[string]string `json:"arguments"`
 	}
 
 	if err := json.Unmarshal(req.Params, &params); err != nil {
-		s.sendError(req.ID, -32602, "Invalid params", err.Error())
-		return
+		return newError(req.ID, -32602, "Invalid params", err.Error())
 	}
 
 	if params.Name != "with_memory" {
-		s.sendError(req.ID, -32602, "Unknown prompt", params.Name)
-		return
+		return newError(req.ID, -32602, "Unknown prompt", params.Name)
 	}
 
 	query := params.Arguments["query"]
 	if query == "" {
-		s.sendError(req.ID, -32602, "Missing required argument", "query")
-		return
+		return newError(req.ID, -32602, "Missing required argument", "query")
 	}
 
 	// Recall relevant memories
@@ -980,7 +1532,7 @@ func (s *Server) handlePromptsGet(ctx context.Context, req *JSONRPCRequest) {
 		},
 	}
 
-	s.sendResult(req.ID, map[string]interface{}{
+	return newResult(req.ID, map[string]interface{}{
 		"description": "Query enhanced with relevant memories",
 		"messages":    messages,
 	})
@@ -1008,18 +1560,16 @@ type RPCError struct {
 	Data    string `json:"data,omitempty"`
 }
 
-func (s *Server) sendResult(id interface{}, result interface{}) {
-	resp := JSONRPCResponse{
+func newResult(id interface{}, result interface{}) *JSONRPCResponse {
+	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Result:  result,
 	}
-	data, _ := json.Marshal(resp)
-	fmt.Println(string(data))
 }
 
-func (s *Server) sendError(id interface{}, code int, message, data string) {
-	resp := JSONRPCResponse{
+func newError(id interface{}, code int, message, data string) *JSONRPCResponse {
+	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Error: &RPCError{
@@ -1028,8 +1578,30 @@ func (s *Server) sendError(id interface{}, code int, message, data string) {
 			Data:    data,
 		},
 	}
-	respData, _ := json.Marshal(resp)
-	fmt.Println(string(respData))
+}
+
+// send writes a response to stdout (stdio transport)
+func (s *Server) send(resp *JSONRPCResponse) {
+	data, _ := json.Marshal(resp)
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Println(string(data))
+}
+
+// notify sends a server-initiated JSON-RPC notification to connected clients
+func (s *Server) notify(method string, params interface{}) {
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+	if s.sse != nil {
+		s.sse.broadcast(data)
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Println(string(data))
 }
 
 func truncate(s string, max int) string {
@@ -1039,18 +1611,23 @@ func truncate(s string, max int) string {
 	return s[:max-3] + "..."
 }
 
-// buildSessionContext creates a markdown summary for session preload
+// buildSessionContext creates a markdown summary for session preload, from the
+// session's scope
 func (s *Server) buildSessionContext(ctx context.Context) (string, error) {
+	scopes := s.readScopes(ctx, nil)
 	var sb strings.Builder
 
+	superseded, _ := s.store.SupersededIDs(ctx)
+
 	sb.WriteString("# Phloem Session Context\n\n")
 	sb.WriteString("*Auto-loaded memory context for this session*\n\n")
 
 	// Get recent memories (last 24 hours of activity)
-	recent, err := s.store.List(ctx, 10, nil)
+	recent, err := s.store.ListWithScopes(ctx, 10, nil, scopes)
 	if err != nil {
 		return "", err
 	}
+	recent = dropSuperseded(recent, superseded)
 
 	if len(recent) > 0 {
 		sb.WriteString("## Recent Context\n\n")
@@ -1073,7 +1650,8 @@ func (s *Server) buildSessionContext(ctx context.Context) (string, error) {
 	}
 
 	// Get key decisions
-	decisions, _ := s.store.List(ctx, 5, []string{"decision"})
+	decisions, _ := s.store.ListWithScopes(ctx, 5, []string{"decision"}, scopes)
+	decisions = dropSuperseded(decisions, superseded)
 	if len(decisions) > 0 {
 		sb.WriteString("## Key Decisions\n\n")
 		for _, mem := range decisions {
@@ -1087,7 +1665,8 @@ func (s *Server) buildSessionContext(ctx context.Context) (string, error) {
 	}
 
 	// Get critical/priority items
-	critical, _ := s.store.List(ctx, 5, []string{"critical"})
+	critical, _ := s.store.ListWithScopes(ctx, 5, []string{"critical"}, scopes)
+	critical = dropSuperseded(critical, superseded)
 	if len(critical) > 0 {
 		sb.WriteString("## Critical Items\n\n")
 		for _, mem := range critical {
@@ -1101,7 +1680,8 @@ func (s *Server) buildSessionContext(ctx context.Context) (string, error) {
 	}
 
 	// Get architecture notes
-	arch, _ := s.store.List(ctx, 3, []string{"architecture"})
+	arch, _ := s.store.ListWithScopes(ctx, 3, []string{"architecture"}, scopes)
+	arch = dropSuperseded(arch, superseded)
 	if len(arch) > 0 {
 		sb.WriteString("## Architecture Notes\n\n")
 		for _, mem := range arch {
@@ -1164,15 +1744,16 @@ func (s *Server) toolAddCitation(ctx context.Context, args map[string]interface{
 		content = c
 	}
 
-	// Get current git commit SHA if available
-	commitSHA := ""
-	// Try to get git SHA from the file's directory
-	if filePath != "" {
-		// This is a simple implementation - in production you'd use git commands
-		commitSHA = "" // Could be enhanced to actually get git SHA
-	}
+	// Files inside a git repo are anchored to HEAD by the store unless a commit is given
+	commitSHA, _ := args["commit_sha"].(string)
 
-	citation, err := s.store.AddCitation(ctx, memoryID, filePath, startLine, endLine, commitSHA, content)
+	var citation *memory.Citation
+	var err error
+	if symbol, _ := args["symbol"].(string); symbol != "" {
+		citation, err = s.store.AddSymbolCitation(ctx, memoryID, filePath, symbol, commitSHA)
+	} else {
+		citation, err = s.store.AddCitation(ctx, memoryID, filePath, startLine, endLine, commitSHA, content)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1294,6 +1875,139 @@ func (s *Server) toolVerifyMemory(ctx context.Context, args map[string]interface
 	}, nil
 }
 
+// toolVerifyAllCitations verifies every citation and reports stale memories
+func (s *Server) toolVerifyAllCitations(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	opts := memory.VerifyAllOptions{}
+	opts.Scope, _ = args["scope"].(string)
+	if t, ok := args["threshold"].(float64); ok {
+		opts.Threshold = t
+	}
+	opts.TagStale, _ = args["tag_stale"].(bool)
+
+	result, err := s.store.VerifyAllCitations(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify citations: %w", err)
+	}
+
+	message := fmt.Sprintf("Verified %d citation(s) across %d file(s): %d valid, %d invalid; %d stale memories",
+		result.Citations, len(result.Files), result.Valid, result.Invalid, len(result.Stale))
+	if opts.TagStale {
+		message += fmt.Sprintf(" (%d tagged, %d untagged)", result.Tagged, result.Untagged)
+	}
+	return map[string]interface{}{
+		"status":  "verified",
+		"result":  result,
+		"message": message,
+	}, nil
+}
+
+func (s *Server) toolListScopes(ctx context.Context) (interface{}, error) {
+	scopes, err := s.store.ListScopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response := map[string]interface{}{
+		"count":  len(scopes),
+		"scopes": scopes,
+	}
+	if scope := s.sessionScope(ctx); scope != "" {
+		response["session_scope"] = scope
+	}
+	return response, nil
+}
+
+func (s *Server) toolShowScope(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	scope, _ := args["scope"].(string)
+	if scope == "" {
+		scope = s.sessionScope(ctx)
+	}
+	if scope == "" {
+		return nil, fmt.Errorf("scope is required (no scope was detected for this session)")
+	}
+	limit := 5
+	if l, ok := args["limit"].(float64); ok {
+		limit = int(l)
+	}
+
+	detail, err := s.store.ShowScope(ctx, scope, limit)
+	if err != nil {
+		return nil, err
+	}
+	recent := make([]map[string]interface{}, len(detail.Recent))
+	for i, mem := range detail.Recent {
+		recent[i] = map[string]interface{}{
+			"id":         mem.ID,
+			"content":    truncate(mem.Content, 200),
+			"tags":       mem.Tags,
+			"created_at": mem.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return map[string]interface{}{
+		"scope":          detail.Scope,
+		"ancestors":      detail.Ancestors,
+		"descendants":    detail.Descendants,
+		"total_memories": detail.TotalMemories,
+		"recent":         recent,
+	}, nil
+}
+
+// toolMoveScope implements rename_scope and merge_scopes; target names the argument holding the new scope
+func (s *Server) toolMoveScope(ctx context.Context, args map[string]interface{}, target string, merge bool) (interface{}, error) {
+	from, _ := args["from"].(string)
+	to, _ := args[target].(string)
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("from and %s are required", target)
+	}
+
+	var result *memory.ScopeMoveResult
+	var err error
+	if merge {
+		result, err = s.store.MergeScope(ctx, from, to)
+	} else {
+		result, err = s.store.RenameScope(ctx, from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := "renamed"
+	if merge {
+		status = "merged"
+	}
+	message := fmt.Sprintf("Moved %d memories from %s to %s", result.Moved, result.From, result.To)
+	if result.Merged > 0 {
+		message += fmt.Sprintf("; %d merged into identical memories", result.Merged)
+	}
+	return map[string]interface{}{
+		"status":  status,
+		"result":  result,
+		"message": message,
+	}, nil
+}
+
+func (s *Server) toolDeleteScope(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	scope, _ := args["scope"].(string)
+	if scope == "" {
+		return nil, fmt.Errorf("scope is required")
+	}
+	forget, _ := args["forget_memories"].(bool)
+
+	n, err := s.store.DeleteScope(ctx, scope, forget)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Deleted scope %s; %d memories are now unscoped", scope, n)
+	if forget {
+		message = fmt.Sprintf("Deleted scope %s and forgot %d memories", scope, n)
+	}
+	return map[string]interface{}{
+		"status":   "deleted",
+		"scope":    scope,
+		"memories": n,
+		"message":  message,
+	}, nil
+}
+
 func (s *Server) toolCausalQuery(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	memoryID, ok := args["memory_id"].(string)
 	if !ok || memoryID == "" {
@@ -1336,8 +2050,41 @@ func (s *Server) toolCausalQuery(ctx context.Context, args map[string]interface{
 			"count":      len(ids),
 			"memory_ids": ids,
 		}, nil
+	case "lineage":
+		lineage, err := s.store.Lineage(ctx, memoryID)
+		if err != nil {
+			return nil, fmt.Errorf("lineage: %w", err)
+		}
+		chain := make([]map[string]interface{}, len(lineage.Chain))
+		for i, m := range lineage.Chain {
+			chain[i] = map[string]interface{}{
+				"id":         m.ID,
+				"content":    m.Content,
+				"tags":       m.Tags,
+				"created_at": m.CreatedAt.Format(time.RFC3339),
+				"current":    m.SupersededBy == "",
+			}
+			if m.SupersededBy != "" {
+				chain[i]["superseded_by"] = m.SupersededBy
+			}
+		}
+		contradicts := make([]map[string]interface{}, len(lineage.Contradicts))
+		for i, m := range lineage.Contradicts {
+			contradicts[i] = map[string]interface{}{
+				"id":         m.ID,
+				"content":    m.Content,
+				"tags":       m.Tags,
+				"created_at": m.CreatedAt.Format(time.RFC3339),
+			}
+		}
+		return map[string]interface{}{
+			"memory_id":   memoryID,
+			"query_type":  "lineage",
+			"chain":       chain,
+			"contradicts": contradicts,
+		}, nil
 	default:
-		return nil, fmt.Errorf("query_type must be 'neighbors' or 'affected', got %q", queryType)
+		return nil, fmt.Errorf("query_type must be 'neighbors', 'affected' or 'lineage', got %q", queryType)
 	}
 }
 
@@ -1365,7 +2112,7 @@ func (s *Server) toolCompose(ctx context.Context, args map[string]interface{}) (
 	if l, ok := args["limit"].(float64); ok && l > 0 {
 		limit = int(l)
 	}
-	composed, err := s.store.Compose(ctx, queries, limit)
+	composed, err := s.store.ComposeWithScopes(ctx, queries, limit, s.readScopes(ctx, args))
 	if err != nil {
 		return nil, err
 	}
@@ -1396,7 +2143,7 @@ func (s *Server) toolPrefetch(ctx context.Context, args map[string]interface{})
 	if l, ok := args["limit"].(float64); ok && l > 0 {
 		limit = int(l)
 	}
-	memories, err := s.store.PrefetchSuggest(ctx, hint, limit)
+	memories, err := s.store.PrefetchSuggestWithScopes(ctx, hint, limit, s.readScopes(ctx, args))
 	if err != nil {
 		return nil, err
 	}
@@ -1433,7 +2180,7 @@ func (s *Server) toolPrefetchSuggest(ctx context.Context, args map[string]interf
 	if limit > 20 {
 		limit = 20
 	}
-	memories, err := s.store.PrefetchSuggest(ctx, ctxStr, limit)
+	memories, err := s.store.PrefetchSuggestWithScopes(ctx, ctxStr, limit, s.readScopes(ctx, args))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/CanopyHQ/phloem/internal/memory"
+)
+
+// Citation watcher: with `phloem serve --watch`, files referenced by citations are
+// polled for changes. Once a changed file has been quiet for the debounce period its
+// citations are re-verified, and every memory whose confidence moved is announced with
+// notifications/resources/updated so clients can re-read phloem://memories/<id>.
+// Polling keeps the server free of platform-specific watch APIs and also works on
+// network filesystems; only cited files are checked, so the cost is a stat per file.
+
+const (
+	defaultWatchInterval = time.Second
+	defaultWatchDebounce = 2 * time.Second
+)
+
+// WatchOptions tunes WatchCitations. Zero values use the defaults.
+type WatchOptions struct {
+	Interval time.Duration // How often cited files are checked (default 1s)
+	Debounce time.Duration // How long a changed file must be quiet before re-verifying (default 2s)
+}
+
+// fileStamp is what the watcher compares to detect a change
+type fileStamp struct {
+	modTime int64
+	size    int64
+	exists  bool
+}
+
+func statStamp(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime().UnixNano(), size: info.Size(), exists: true}
+}
+
+type citationWatcher struct {
+	server   *Server
+	debounce time.Duration
+
+	stamps  map[string]fileStamp        // Last seen state by path on disk
+	files   map[string]memory.CitedFile // Citation file for each watched path
+	pending map[string]time.Time        // Changed paths -> time of the latest change
+}
+
+// WatchCitations re-verifies citations as their files change, until ctx is cancelled.
+func (s *Server) WatchCitations(ctx context.Context, opts WatchOptions) {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultWatchInterval
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = defaultWatchDebounce
+	}
+	w := newCitationWatcher(s, opts.Debounce)
+	fmt.Fprintln(os.Stderr, "👀 Watching cited files for changes")
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	w.poll(ctx, time.Now()) // Baseline: nothing is pending until a file changes after this
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.poll(ctx, now)
+		}
+	}
+}
+
+func newCitationWatcher(s *Server, debounce time.Duration) *citationWatcher {
+	return &citationWatcher{
+		server:   s,
+		debounce: debounce,
+		stamps:   make(map[string]fileStamp),
+		files:    make(map[string]memory.CitedFile),
+		pending:  make(map[string]time.Time),
+	}
+}
+
+// poll checks every cited file once and re-verifies the ones that settled
+func (w *citationWatcher) poll(ctx context.Context, now time.Time) {
+	cited, err := w.server.store.CitedFiles(ctx)
+	if err != nil {
+		return
+	}
+
+	// Files come and go as citations are added, forgotten or follow a rename
+	seen := make(map[string]bool, len(cited))
+	for _, f := range cited {
+		path := f.Path()
+		seen[path] = true
+		stamp := statStamp(path)
+		if prev, known := w.stamps[path]; known && prev != stamp {
+			w.pending[path] = now
+		}
+		w.stamps[path] = stamp
+		w.files[path] = f
+	}
+	for path := range w.stamps {
+		if !seen[path] && w.pending[path].IsZero() {
+			delete(w.stamps, path)
+			delete(w.files, path)
+		}
+	}
+
+	settled := make(map[string][]string) // Repo root -> repo-relative files
+	for path, changed := range w.pending {
+		if now.Sub(changed) < w.debounce {
+			continue
+		}
+		delete(w.pending, path)
+		if f, ok := w.files[path]; ok {
+			settled[f.RepoRoot] = append(settled[f.RepoRoot], f.FilePath)
+		}
+	}
+	for root, files := range settled {
+		w.verify(ctx, root, files)
+	}
+}
+
+// verify re-verifies the citations of files in one repo and notifies clients of
+// memories whose confidence changed
+func (w *citationWatcher) verify(ctx context.Context, root string, files []string) {
+	result, err := w.server.store.VerifyAllCitations(ctx, memory.VerifyAllOptions{RepoRoot: root, Files: files})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Citation re-verification failed: %v\n", err)
+		return
+	}
+	if result.Citations == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "🔄 Re-verified %d citation(s) in %d changed file(s): %d valid, %d invalid\n",
+		result.Citations, len(result.Files), result.Valid, result.Invalid)
+	for _, id := range result.Changed {
+		w.server.notify("notifications/resources/updated", map[string]interface{}{"uri": memoryResourceURI(id)})
+	}
+}
@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCitationWatcher_DebouncesAndNotifies(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "config.go")
+	os.WriteFile(src, []byte("package config\n\nconst Port = 8080\n"), 0644)
+	mem, _ := server.store.Remember(ctx, "The API listens on 8080", nil, "")
+	server.store.AddCitation(ctx, mem.ID, src, 3, 3, "", "const Port = 8080")
+
+	w := newCitationWatcher(server, 2*time.Second)
+	t0 := time.Now()
+	if out := captureOutput(func() { w.poll(ctx, t0) }); out != "" {
+		t.Errorf("baseline poll should not notify: %s", out)
+	}
+
+	os.WriteFile(src, []byte("package config\n\nconst Port = 9090 // moved\n"), 0644)
+	if out := captureOutput(func() { w.poll(ctx, t0.Add(time.Second)) }); out != "" {
+		t.Errorf("change should wait for the debounce: %s", out)
+	}
+	if _, ok := w.pending[src]; !ok {
+		t.Fatal("expected the changed file to be pending")
+	}
+
+	out := captureOutput(func() { w.poll(ctx, t0.Add(3*time.Second+time.Millisecond)) })
+	var note map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &note); err != nil {
+		t.Fatalf("expected one notification, got %q: %v", out, err)
+	}
+	if note["method"] != "notifications/resources/updated" {
+		t.Errorf("unexpected notification: %v", note)
+	}
+	params := note["params"].(map[string]interface{})
+	if params["uri"] != "phloem://memories/"+mem.ID {
+		t.Errorf("expected the memory's URI, got %v", params["uri"])
+	}
+	if len(w.pending) != 0 {
+		t.Errorf("expected nothing pending after the flush, got %v", w.pending)
+	}
+
+	citations, _ := server.store.GetCitations(ctx, mem.ID)
+	if citations[0].Confidence >= 1.0 {
+		t.Errorf("expected confidence to drop after the change, got %f", citations[0].Confidence)
+	}
+}
+
+func TestWatchCitations_StopsOnCancel(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.WatchCitations(ctx, WatchOptions{Interval: 5 * time.Millisecond})
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher did not stop")
+	}
+}
+
+func TestResourceRead_Memory(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	mem, _ := server.store.Remember(context.Background(), "readable memory", nil, "")
+	params, _ := json.Marshal(map[string]interface{}{"uri": "phloem://memories/" + mem.ID})
+	req := &JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "resources/read", Params: params}
+	output := captureOutput(func() { server.handleRequest(req) })
+	if !strings.Contains(output, "readable memory") || !strings.Contains(output, `\"confidence\": 1`) {
+		t.Errorf("expected the memory and its confidence: %s", output)
+	}
+
+	params, _ = json.Marshal(map[string]interface{}{"uri": "phloem://memories/nope"})
+	req = &JSONRPCRequest{JSONRPC: "2.0", ID: 2, Method: "resources/read", Params: params}
+	output = captureOutput(func() { server.handleRequest(req) })
+	if !strings.Contains(output, "memory not found") {
+		t.Errorf("expected not found error: %s", output)
+	}
+}
@@ -4,6 +4,7 @@ package memory
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 	"time"
@@ -524,3 +525,312 @@ func TestStore_VerifyCitation_LinesOutOfRange(t *testing.T) {
 }
 
 // Note: Uses setupTestStore from store_test.go
+
+// --- Git-aware Citation Tests ---
+
+// initCitationRepo creates a git repository with one commit containing file
+func initCitationRepo(t *testing.T, file, content string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = real
+	}
+	gitRun(t, dir, "init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", file, err)
+	}
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestStore_AddCitation_AnchorsToRepoRoot(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	dir := initCitationRepo(t, "main.go", "package main\n\nfunc main() {}\n")
+	mem, _ := store.Remember(ctx, "Entry point", nil, "")
+
+	citation, err := store.AddCitation(ctx, mem.ID, filepath.Join(dir, "main.go"), 3, 3, "", "func main() {}")
+	if err != nil {
+		t.Fatalf("Failed to add citation: %v", err)
+	}
+	if citation.FilePath != "main.go" || citation.RepoRoot != dir {
+		t.Errorf("Expected main.go relative to %s, got %q relative to %q", dir, citation.FilePath, citation.RepoRoot)
+	}
+	if len(citation.CommitSHA) != 40 {
+		t.Errorf("Expected citation anchored to HEAD, got commit %q", citation.CommitSHA)
+	}
+
+	// Verification does not depend on the current directory
+	t.Chdir(t.TempDir())
+	_, valid, err := store.VerifyCitation(ctx, citation.ID)
+	if err != nil || !valid {
+		t.Errorf("Expected valid citation from another directory, got valid=%v err=%v", valid, err)
+	}
+}
+
+func TestStore_VerifyCitation_RelocatesMovedBlock(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	tmpFile := filepath.Join(t.TempDir(), "pool.go")
+	original := "package db\n\nfunc Open() {\n\treturn pool()\n}\n"
+	if err := os.WriteFile(tmpFile, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	mem, _ := store.Remember(ctx, "Open uses the pool", nil, "")
+	citation, _ := store.AddCitation(ctx, mem.ID, tmpFile, 3, 5, "", "func Open() {\n\treturn pool()\n}")
+
+	// Insert code above the cited block
+	moved := "package db\n\nimport \"log\"\n\nvar logger = log.Default()\n\nfunc Open() {\n\treturn pool()\n}\n"
+	if err := os.WriteFile(tmpFile, []byte(moved), 0644); err != nil {
+		t.Fatalf("Failed to rewrite file: %v", err)
+	}
+
+	verified, valid, err := store.VerifyCitation(ctx, citation.ID)
+	if err != nil {
+		t.Fatalf("Failed to verify citation: %v", err)
+	}
+	if !valid || verified.Confidence != 1.0 {
+		t.Errorf("Expected moved block to stay valid at full confidence, got valid=%v confidence=%f", valid, verified.Confidence)
+	}
+	if verified.StartLine != 7 || verified.EndLine != 9 {
+		t.Errorf("Expected lines 7-9, got %d-%d", verified.StartLine, verified.EndLine)
+	}
+
+	stored, _ := store.GetCitations(ctx, mem.ID)
+	if len(stored) != 1 || stored[0].StartLine != 7 || stored[0].EndLine != 9 {
+		t.Errorf("Expected relocated range to be persisted, got %+v", stored)
+	}
+}
+
+func TestStore_VerifyCitation_FuzzyRelocation(t *testing.T) {
+	const (
+		original = "package db\n\nfunc Open(dsn string) error {\n\treturn connect(dsn, poolSize, timeout)\n}\n"
+		snippet  = "func Open(dsn string) error {\n\treturn connect(dsn, poolSize, timeout)\n}"
+		edited   = "func Open(dsn string) error {\n\treturn connect(dsn, poolSize, deadline)\n}\n"
+		header   = "package db\n\nimport \"time\"\n\nvar deadline = time.Minute\n\n"
+	)
+	for _, tc := range []struct {
+		name       string
+		rewritten  string
+		relocated  bool
+		start, end int
+	}{
+		// Moved and lightly edited: the one close match is trusted
+		{"unique close match", header + edited, true, 7, 9},
+		// The same edited block twice: no way to tell which one is cited
+		{"ambiguous match", header + edited + "\n" + edited, false, 3, 5},
+		// Moved and rewritten: the best window is too far from the snippet
+		{"distant match", header + "func Open(url string) (*Pool, error) {\n\treturn dial(url)\n}\n", false, 3, 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store, cleanup := setupTestStore(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			tmpFile := filepath.Join(t.TempDir(), "db.go")
+			if err := os.WriteFile(tmpFile, []byte(original), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			mem, _ := store.Remember(ctx, "Open connects with the pool size and timeout", nil, "")
+			citation, _ := store.AddCitation(ctx, mem.ID, tmpFile, 3, 5, "", snippet)
+			if err := os.WriteFile(tmpFile, []byte(tc.rewritten), 0644); err != nil {
+				t.Fatalf("Failed to rewrite file: %v", err)
+			}
+
+			verified, valid, err := store.VerifyCitation(ctx, citation.ID)
+			if err != nil {
+				t.Fatalf("Failed to verify citation: %v", err)
+			}
+			stored, _ := store.GetCitations(ctx, mem.ID)
+			if len(stored) != 1 || stored[0].StartLine != tc.start || stored[0].EndLine != tc.end {
+				t.Errorf("Expected lines %d-%d to be stored, got %+v", tc.start, tc.end, stored)
+			}
+			if tc.relocated {
+				if !valid || verified.Confidence < minRelocateSimilarity {
+					t.Errorf("Expected the relocated citation to stay valid, got valid=%v confidence=%f", valid, verified.Confidence)
+				}
+				return
+			}
+			if valid || verified.Confidence > staleCitationConfidence {
+				t.Errorf("Expected the citation to be stale, got valid=%v confidence=%f", valid, verified.Confidence)
+			}
+		})
+	}
+}
+
+func TestStore_VerifyCitation_FollowsRename(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	dir := initCitationRepo(t, "limit.go", "package api\n\nconst RateLimit = 100\n")
+	mem, _ := store.Remember(ctx, "Rate limit is 100 req/min", nil, "")
+	citation, err := store.AddCitation(ctx, mem.ID, filepath.Join(dir, "limit.go"), 3, 3, "", "const RateLimit = 100")
+	if err != nil {
+		t.Fatalf("Failed to add citation: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "middleware"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "mv", "limit.go", filepath.Join("middleware", "rate_limit.go"))
+	gitRun(t, dir, "commit", "-q", "-m", "move rate limit")
+
+	verified, valid, err := store.VerifyCitation(ctx, citation.ID)
+	if err != nil {
+		t.Fatalf("Failed to verify citation: %v", err)
+	}
+	if !valid || verified.Confidence != 1.0 {
+		t.Errorf("Expected renamed file to stay valid, got valid=%v confidence=%f", valid, verified.Confidence)
+	}
+	if verified.FilePath != filepath.Join("middleware", "rate_limit.go") {
+		t.Errorf("Expected citation to follow the rename, got %q", verified.FilePath)
+	}
+	if verified.CommitSHA == citation.CommitSHA {
+		t.Error("Expected citation to be re-anchored to the new HEAD")
+	}
+}
+
+func TestLocateSnippet_PrefersNearestOccurrence(t *testing.T) {
+	lines := []string{"x := 1", "}", "pad", "pad", "x := 1", "}"}
+	start, end, ok := locateSnippet(lines, "x := 1\n}", 4)
+	if !ok || start != 5 || end != 6 {
+		t.Errorf("Expected lines 5-6, got %d-%d (found=%v)", start, end, ok)
+	}
+	if _, _, ok := locateSnippet(lines, "missing", 1); ok {
+		t.Error("Expected no match for missing snippet")
+	}
+}
+
+// --- Symbol Citation Tests ---
+
+const limiterGo = `package ratelimit
+
+type RateLimiter struct{ n int }
+
+func (r *RateLimiter) Allow(used int) bool {
+	return used < r.n
+}
+`
+
+func TestStore_AddSymbolCitation(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	tmpFile := filepath.Join(t.TempDir(), "limiter.go")
+	os.WriteFile(tmpFile, []byte(limiterGo), 0644)
+	mem, _ := store.Remember(ctx, "Allow rejects requests at the limit", nil, "")
+
+	citation, err := store.AddSymbolCitation(ctx, mem.ID, tmpFile, "(*RateLimiter).Allow", "")
+	if err != nil {
+		t.Fatalf("Failed to add symbol citation: %v", err)
+	}
+	if citation.Symbol != "RateLimiter.Allow" || citation.ASTHash == "" {
+		t.Errorf("Expected normalized symbol and hash, got %q %q", citation.Symbol, citation.ASTHash)
+	}
+	if citation.StartLine != 5 || citation.EndLine != 7 {
+		t.Errorf("Expected lines 5-7, got %d-%d", citation.StartLine, citation.EndLine)
+	}
+
+	stored, _ := store.GetCitations(ctx, mem.ID)
+	if len(stored) != 1 || stored[0].Symbol != "RateLimiter.Allow" || stored[0].ASTHash != citation.ASTHash {
+		t.Errorf("Expected symbol and hash to be stored, got %+v", stored)
+	}
+
+	if _, err := store.AddSymbolCitation(ctx, mem.ID, tmpFile, "RateLimiter.Deny", ""); err == nil {
+		t.Error("Expected error for a missing symbol")
+	}
+	if _, err := store.AddSymbolCitation(ctx, mem.ID, filepath.Join(t.TempDir(), "notes.md"), "Allow", ""); err == nil {
+		t.Error("Expected error for a non-Go file")
+	}
+}
+
+func TestStore_VerifySymbolCitation_FormattingKeepsConfidence(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	tmpFile := filepath.Join(t.TempDir(), "limiter.go")
+	os.WriteFile(tmpFile, []byte(limiterGo), 0644)
+	mem, _ := store.Remember(ctx, "Allow rejects requests at the limit", nil, "")
+	citation, _ := store.AddSymbolCitation(ctx, mem.ID, tmpFile, "RateLimiter.Allow", "")
+
+	// Reformat, comment and move the method down the file
+	reformatted := `package ratelimit
+
+type RateLimiter struct{ n int }
+
+func New(n int) *RateLimiter { return &RateLimiter{n: n} }
+
+// Allow reports whether another request fits.
+func (r *RateLimiter) Allow(
+	used int,
+) bool {
+	return used<r.n
+}
+`
+	os.WriteFile(tmpFile, []byte(reformatted), 0644)
+
+	verified, valid, err := store.VerifyCitation(ctx, citation.ID)
+	if err != nil {
+		t.Fatalf("Failed to verify citation: %v", err)
+	}
+	if !valid || verified.Confidence != 1.0 {
+		t.Errorf("Expected formatting-only change to keep full confidence, got valid=%v confidence=%f", valid, verified.Confidence)
+	}
+	if verified.StartLine != 8 || verified.EndLine != 12 {
+		t.Errorf("Expected lines 8-12, got %d-%d", verified.StartLine, verified.EndLine)
+	}
+}
+
+func TestStore_VerifySymbolCitation_SemanticChangeReducesConfidence(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	tmpFile := filepath.Join(t.TempDir(), "limiter.go")
+	os.WriteFile(tmpFile, []byte(limiterGo), 0644)
+	mem, _ := store.Remember(ctx, "Allow rejects requests at the limit", nil, "")
+	citation, _ := store.AddSymbolCitation(ctx, mem.ID, tmpFile, "RateLimiter.Allow", "")
+
+	changed := `package ratelimit
+
+type RateLimiter struct{ n int }
+
+func (r *RateLimiter) Allow(used int) bool {
+	if r.n == 0 {
+		return true
+	}
+	return used <= r.n
+}
+`
+	os.WriteFile(tmpFile, []byte(changed), 0644)
+
+	verified, _, err := store.VerifyCitation(ctx, citation.ID)
+	if err != nil {
+		t.Fatalf("Failed to verify citation: %v", err)
+	}
+	if verified.Confidence >= 1.0 || verified.Confidence <= 0 {
+		t.Errorf("Expected reduced but non-zero confidence for a semantic change, got %f", verified.Confidence)
+	}
+	if verified.EndLine != 10 {
+		t.Errorf("Expected range to track the symbol's new end line, got %d", verified.EndLine)
+	}
+}
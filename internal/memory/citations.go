@@ -0,0 +1,226 @@
+// Package memory: git-aware citation anchoring.
+// A citation to a file inside a git work tree stores its path relative to the repo
+// root plus the commit it was anchored to, so verification does not depend on the
+// current directory. When the file has been renamed since that commit the new path
+// is found from git history, and when the cited block has moved within the file it
+// is located by searching for the snippet rather than comparing a fixed line range.
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/CanopyHQ/phloem/internal/git"
+)
+
+// maxCitedFileSize is the largest file VerifyCitation will read (10MB)
+const maxCitedFileSize = 10 * 1024 * 1024
+
+// Limits for the fuzzy search in closestSnippet, which compares every window of the file
+const (
+	maxFuzzySnippetLines = 200
+	maxFuzzyFileLines    = 20000
+)
+
+var errFileTooLarge = errors.New("file too large")
+
+// anchorCitation resolves filePath to a repo root and repo-relative path when it names
+// an existing file inside a git work tree, defaulting commitSHA to HEAD. Other paths
+// (missing files, repo-relative paths from grafts, files outside git) are kept as given.
+func anchorCitation(filePath, commitSHA string) (repoRoot, relPath, sha string) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", filePath, commitSHA
+	}
+	if info, err := os.Stat(abs); err != nil || info.IsDir() {
+		return "", filePath, commitSHA
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
+	}
+	root, err := git.WorkTreeRoot(abs)
+	if err != nil {
+		return "", filePath, commitSHA
+	}
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		root = real
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", filePath, commitSHA
+	}
+	if commitSHA == "" {
+		commitSHA, _ = git.HeadCommit(root) // Empty in a repo with no commits yet
+	}
+	return root, rel, commitSHA
+}
+
+// resolvedPath is where the cited file is expected on disk
+func (c *Citation) resolvedPath() string {
+	if c.RepoRoot != "" {
+		return filepath.Join(c.RepoRoot, c.FilePath)
+	}
+	return c.FilePath
+}
+
+// readCitedFile reads a cited file, refusing files over maxCitedFileSize
+func readCitedFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxCitedFileSize {
+		return nil, fmt.Errorf("%w (%d bytes, limit %d)", errFileTooLarge, info.Size(), maxCitedFileSize)
+	}
+	return os.ReadFile(path)
+}
+
+// followCitationRename points c at the file's current path if it was renamed since
+// c.CommitSHA, re-anchoring it to HEAD. Reports whether the path changed.
+func followCitationRename(c *Citation) bool {
+	if c.RepoRoot == "" || c.CommitSHA == "" {
+		return false
+	}
+	moved, err := git.FollowRenames(c.RepoRoot, c.CommitSHA, c.FilePath)
+	if err != nil || moved == "" || moved == c.FilePath {
+		return false
+	}
+	c.FilePath = moved
+	if head, err := git.HeadCommit(c.RepoRoot); err == nil {
+		c.CommitSHA = head
+	}
+	return true
+}
+
+// citedLines returns lines startLine..endLine (1-indexed, inclusive), clamped to the file
+func citedLines(lines []string, startLine, endLine int) string {
+	startIdx := startLine - 1
+	endIdx := endLine
+	if startIdx < 0 {
+		startIdx = 0
+	}
+	if endIdx > len(lines) {
+		endIdx = len(lines)
+	}
+	if startIdx >= len(lines) || startIdx >= endIdx {
+		return ""
+	}
+	return strings.Join(lines[startIdx:endIdx], "\n")
+}
+
+// scrubLines scrubs each line of a cited file the way insertCitation scrubbed the
+// stored snippet, so the two compare equal when the cited code holds a secret. Lines
+// are scrubbed one at a time to keep line numbers; a secret spanning lines (a private
+// key block) then no longer matches exactly and is verified by similarity.
+func (s *Store) scrubLines(lines []string) []string {
+	scrubbed := make([]string, len(lines))
+	for i, line := range lines {
+		scrubbed[i], _ = s.scrub(line)
+	}
+	return scrubbed
+}
+
+// snippetLines splits a cited snippet into trimmed lines without leading or trailing blank lines
+func snippetLines(snippet string) []string {
+	lines := strings.Split(strings.TrimSpace(snippet), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return lines
+}
+
+// locateSnippet finds the snippet in the file, ignoring indentation, and returns its
+// 1-indexed line range. When it occurs more than once the occurrence nearest to
+// nearLine wins.
+func locateSnippet(lines []string, snippet string, nearLine int) (start, end int, ok bool) {
+	want := snippetLines(snippet)
+	if len(want) == 0 || want[0] == "" {
+		return 0, 0, false
+	}
+	bestDist := -1
+	for i := 0; i+len(want) <= len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != want[0] {
+			continue
+		}
+		match := true
+		for j := 1; j < len(want); j++ {
+			if strings.TrimSpace(lines[i+j]) != want[j] {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		dist := i + 1 - nearLine
+		if dist < 0 {
+			dist = -dist
+		}
+		if bestDist < 0 || dist < bestDist {
+			bestDist, start, end = dist, i+1, i+len(want)
+		}
+	}
+	return start, end, bestDist >= 0
+}
+
+// minRelocateSimilarity is the similarity a fuzzy match needs before a changed
+// citation is moved to it
+const minRelocateSimilarity = 0.8
+
+// staleCitationConfidence caps the confidence of a citation whose code changed and
+// moved somewhere that could not be pinned down, below DefaultStaleThreshold so its
+// memory is reported stale
+const staleCitationConfidence = DefaultStaleThreshold / 2
+
+// closestSnippet returns the window of the file most similar to the snippet and its
+// similarity. unique is false when another window not overlapping it also reaches
+// minRelocateSimilarity. It is skipped for very large files or snippets.
+func closestSnippet(lines []string, snippet string) (start, end int, similarity float64, unique bool) {
+	n := len(snippetLines(snippet))
+	if n == 0 || n > maxFuzzySnippetLines || len(lines) > maxFuzzyFileLines {
+		return 0, 0, 0, false
+	}
+	sims := make([]float64, len(lines)-n+1)
+	for i := range sims {
+		sims[i] = stringSimilarity(snippet, strings.Join(lines[i:i+n], "\n"))
+		if sims[i] > similarity {
+			start, end, similarity = i+1, i+n, sims[i]
+		}
+	}
+	for i, sim := range sims {
+		if sim >= minRelocateSimilarity && (i+n < start || i >= end) {
+			return start, end, similarity, false
+		}
+	}
+	return start, end, similarity, true
+}
+
+// markCitationVerified records a successful verification at c's current location,
+// moving its anchor to HEAD when it lives in a git repo.
+func (s *Store) markCitationVerified(ctx context.Context, c *Citation, confidence float64) {
+	c.Confidence = confidence
+	c.VerifiedAt = time.Now()
+	if c.RepoRoot != "" {
+		if head, err := git.HeadCommit(c.RepoRoot); err == nil {
+			c.CommitSHA = head
+		}
+	}
+	s.db.ExecContext(ctx, `
+		UPDATE citations SET file_path = ?, start_line = ?, end_line = ?, commit_sha = ?, verified_at = ?, confidence = ?
+		WHERE id = ?
+	`, c.FilePath, c.StartLine, c.EndLine, c.CommitSHA, c.VerifiedAt, c.Confidence, c.ID)
+}
+
+// updateCitationLocation persists c's location and confidence without marking it verified
+func (s *Store) updateCitationLocation(ctx context.Context, c *Citation) {
+	s.db.ExecContext(ctx, `
+		UPDATE citations SET file_path = ?, start_line = ?, end_line = ?, commit_sha = ?, confidence = ?
+		WHERE id = ?
+	`, c.FilePath, c.StartLine, c.EndLine, c.CommitSHA, c.Confidence, c.ID)
+}
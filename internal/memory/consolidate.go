@@ -0,0 +1,293 @@
+// Package memory: near-duplicate consolidation.
+// Content-hash dedup in RememberWithScope only catches byte-identical text; this pass
+// clusters paraphrased copies by embedding similarity and folds each cluster into one
+// canonical memory. Merged IDs are kept as aliases so GetMemoryByID still resolves them.
+// Similar wording is not the same fact: "expires after 15 minutes" and "after 30
+// minutes" embed almost identically, so texts that differ in numbers or negation are
+// never merged, and the newest text is the one kept.
+
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultConsolidationThreshold is the cosine similarity above which two memories
+// in the same scope are treated as the same fact.
+const DefaultConsolidationThreshold = 0.92
+
+// consolidationCandidates is how many nearest neighbours of each memory the vector
+// index is asked for
+const consolidationCandidates = 32
+
+// ConsolidateOptions controls a consolidation pass.
+type ConsolidateOptions struct {
+	Threshold float64 // Minimum similarity to merge; 0 uses DefaultConsolidationThreshold
+	DryRun    bool    // Report clusters without changing anything
+}
+
+// ConsolidationGroup is one cluster of near-duplicates.
+type ConsolidationGroup struct {
+	Canonical  *Memory   `json:"canonical"`  // The memory that is kept (newest in the cluster)
+	Duplicates []*Memory `json:"duplicates"` // Merged into Canonical; Similarity is relative to Canonical
+}
+
+// ConsolidationResult summarizes a consolidation pass.
+type ConsolidationResult struct {
+	Groups []ConsolidationGroup `json:"groups"`
+	Merged int                  `json:"merged"` // Duplicates folded into a canonical memory (0 on dry run)
+	DryRun bool                 `json:"dry_run"`
+}
+
+// Consolidate clusters memories whose embeddings are at least opts.Threshold similar
+// and merges each cluster into its newest member: tags are unioned, citations and edges
+// move to the canonical memory, and the duplicate IDs become aliases of it.
+// Memories are only compared within the same scope and embedding model, superseded
+// memories are skipped, and memories linked by a supersedes/contradicts edge, or whose
+// texts differ in numbers or negation, are never merged.
+func (s *Store) Consolidate(ctx context.Context, opts ConsolidateOptions) (*ConsolidationResult, error) {
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		threshold = DefaultConsolidationThreshold
+	}
+	result := &ConsolidationResult{DryRun: opts.DryRun}
+
+	memories, err := s.List(ctx, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	superseded, contradicted, err := s.staleMemories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision edges: %w", err)
+	}
+	revised := revisionPairs(superseded, contradicted)
+	models, err := s.embeddingModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first so the latest wording of a fact is the one that survives
+	sort.SliceStable(memories, func(i, j int) bool {
+		return memories[i].CreatedAt.After(memories[j].CreatedAt)
+	})
+
+	// Group by scope, and by embedding model since vectors of different models are not comparable
+	byScope := make(map[string][]*Memory)
+	var scopes []string
+	for _, m := range memories {
+		if superseded[m.ID] != "" || models[m.ID] == "" {
+			continue
+		}
+		key := m.Scope + "\x00" + models[m.ID]
+		if _, ok := byScope[key]; !ok {
+			scopes = append(scopes, key)
+		}
+		byScope[key] = append(byScope[key], m)
+	}
+
+	// Leader clustering: each unassigned memory absorbs the older ones close to it.
+	// Comparing against the leader only (not transitively) keeps clusters from drifting.
+	for _, scope := range scopes {
+		group := byScope[scope]
+		position := make(map[string]int, len(group))
+		for i, m := range group {
+			position[m.ID] = i
+		}
+		assigned := make(map[string]bool)
+		for i, leader := range group {
+			if assigned[leader.ID] {
+				continue
+			}
+			var dups []*Memory
+			for _, m := range s.consolidationNeighbours(leader, models[leader.ID], group, position) {
+				if position[m.ID] <= i || assigned[m.ID] || revised[leader.ID+"|"+m.ID] {
+					continue
+				}
+				sim := cosineSimilarity(leader.Embedding, m.Embedding)
+				if sim < threshold || factsDiffer(leader.Content, m.Content) {
+					continue
+				}
+				m.Similarity = sim
+				assigned[m.ID] = true
+				dups = append(dups, m)
+			}
+			sort.SliceStable(dups, func(a, b int) bool { return position[dups[a].ID] < position[dups[b].ID] })
+			if len(dups) > 0 {
+				result.Groups = append(result.Groups, ConsolidationGroup{Canonical: leader, Duplicates: dups})
+			}
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	for _, g := range result.Groups {
+		for _, dup := range g.Duplicates {
+			if err := s.mergeInto(ctx, g.Canonical, dup); err != nil {
+				return result, fmt.Errorf("failed to merge %s into %s: %w", dup.ID, g.Canonical.ID, err)
+			}
+			result.Merged++
+		}
+	}
+	return result, nil
+}
+
+// consolidationNeighbours returns the members of group that may be near duplicates of
+// m: its nearest neighbours in the vector index when the index holds m's model, every
+// member otherwise
+func (s *Store) consolidationNeighbours(m *Memory, model string, group []*Memory, position map[string]int) []*Memory {
+	if s.vecIdx == nil || !s.vecIdx.available || model != s.vecIdx.model {
+		return group
+	}
+	found, err := s.vecIdx.Search(m.Embedding, consolidationCandidates)
+	if err != nil {
+		return group
+	}
+	neighbours := make([]*Memory, 0, len(found))
+	for _, r := range found {
+		if i, ok := position[r.MemoryID]; ok {
+			neighbours = append(neighbours, group[i])
+		}
+	}
+	return neighbours
+}
+
+var (
+	numberPattern   = regexp.MustCompile(`\d+(?:[.,:]\d+)*`)
+	negationPattern = regexp.MustCompile(`(?i)\b(?:not|no|never|none|nothing|cannot|without)\b|n't\b`)
+)
+
+// factsDiffer reports whether two similar texts state different facts: they contain
+// different numbers ("15 minutes", "30 minutes") or one is negated and the other not
+func factsDiffer(a, b string) bool {
+	numbers := func(text string) string {
+		found := numberPattern.FindAllString(text, -1)
+		sort.Strings(found)
+		return strings.Join(found, " ")
+	}
+	if numbers(a) != numbers(b) {
+		return true
+	}
+	return len(negationPattern.FindAllString(a, -1))%2 != len(negationPattern.FindAllString(b, -1))%2
+}
+
+// revisionPairs returns both orderings of every superseded/contradicted pair.
+func revisionPairs(maps ...map[string]string) map[string]bool {
+	pairs := make(map[string]bool)
+	for _, m := range maps {
+		for oldID, newID := range m {
+			pairs[oldID+"|"+newID] = true
+			pairs[newID+"|"+oldID] = true
+		}
+	}
+	return pairs
+}
+
+// mergeInto folds dup into canonical and deletes dup, leaving its ID as an alias.
+// canonical is updated in place with the merged tags and context.
+func (s *Store) mergeInto(ctx context.Context, canonical, dup *Memory) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.mergeIntoTx(ctx, tx, canonical, dup); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if s.vecIdx != nil {
+		s.vecIdx.Delete(dup.ID)
+	}
+	return nil
+}
+
+// mergeIntoTx is mergeInto within tx; the caller removes dup from the vector index
+// once tx is committed
+func (s *Store) mergeIntoTx(ctx context.Context, tx *sql.Tx, canonical, dup *Memory) error {
+	tags := dedupeTags(append(append([]string{}, canonical.Tags...), dup.Tags...))
+	memContext := canonical.Context
+	if memContext == "" {
+		memContext = dup.Context
+	}
+	utility := canonical.UtilityScore
+	if dup.UtilityScore > utility {
+		utility = dup.UtilityScore
+	}
+
+	now := time.Now()
+	tagsJSON, _ := json.Marshal(tags)
+	stmts := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE memories SET tags = ?, context = ?, utility_score = ?, updated_at = ? WHERE id = ?`,
+			[]interface{}{string(tagsJSON), s.seal(memContext), utility, now, canonical.ID}},
+		{`DELETE FROM memory_tags WHERE memory_id IN (?, ?)`, []interface{}{canonical.ID, dup.ID}},
+		{`UPDATE citations SET memory_id = ? WHERE memory_id = ?`, []interface{}{canonical.ID, dup.ID}},
+		{`UPDATE memory_redactions SET memory_id = ? WHERE memory_id = ?`, []interface{}{canonical.ID, dup.ID}},
+		{`UPDATE memory_edges SET source_id = ? WHERE source_id = ?`, []interface{}{canonical.ID, dup.ID}},
+		{`UPDATE memory_edges SET target_id = ? WHERE target_id = ?`, []interface{}{canonical.ID, dup.ID}},
+		// Edges between the two memories became self-loops; edges both had are now duplicates
+		{`DELETE FROM memory_edges WHERE source_id = target_id`, nil},
+		{`DELETE FROM memory_edges WHERE (source_id = ? OR target_id = ?) AND id NOT IN (
+			SELECT MIN(id) FROM memory_edges GROUP BY source_id, target_id, edge_type)`,
+			[]interface{}{canonical.ID, canonical.ID}},
+		{`UPDATE memory_aliases SET memory_id = ? WHERE memory_id = ?`, []interface{}{canonical.ID, dup.ID}},
+		{`INSERT OR REPLACE INTO memory_aliases (alias_id, memory_id, created_at) VALUES (?, ?, ?)`,
+			[]interface{}{dup.ID, canonical.ID, now}},
+		{`DELETE FROM memory_revisions WHERE memory_id = ?`, []interface{}{dup.ID}},
+		{`DELETE FROM memories WHERE id = ?`, []interface{}{dup.ID}},
+	}
+	for _, st := range stmts {
+		if _, err := tx.ExecContext(ctx, st.query, st.args...); err != nil {
+			return err
+		}
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO memory_tags (memory_id, tag) VALUES (?, ?)`, canonical.ID, tag); err != nil {
+			return err
+		}
+	}
+	canonical.Tags = tags
+	canonical.Context = memContext
+	canonical.UtilityScore = utility
+	canonical.UpdatedAt = now
+	return nil
+}
+
+// resolveAlias returns the memory a merged ID was folded into, or "" if id is not an alias.
+func resolveAlias(ctx context.Context, q rowQuerier, id string) (string, error) {
+	var target string
+	err := q.QueryRowContext(ctx, `SELECT memory_id FROM memory_aliases WHERE alias_id = ?`, id).Scan(&target)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return target, err
+}
+
+// Aliases returns the IDs that were merged into a memory.
+func (s *Store) Aliases(ctx context.Context, memoryID string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT alias_id FROM memory_aliases WHERE memory_id = ? ORDER BY created_at`, memoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolidate_MergesNearDuplicates(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	dup, err := store.Remember(ctx, "The API uses JWT tokens that expire after 15 minutes", []string{"auth"}, "from chat import")
+	require.NoError(t, err)
+	canonical, err := store.Remember(ctx, "The API uses JWT tokens which expire after 15 minutes.", []string{"security"}, "")
+	require.NoError(t, err)
+	other, err := store.Remember(ctx, "The cache is write-through", nil, "")
+	require.NoError(t, err)
+
+	_, err = store.AddCitation(ctx, dup.ID, "auth/jwt.go", 10, 20, "", "expiry := 15 * time.Minute")
+	require.NoError(t, err)
+	require.NoError(t, store.AddEdge(ctx, dup.ID, other.ID, "causal", ""))
+	require.NoError(t, store.AddEdge(ctx, canonical.ID, dup.ID, "semantic", ""))
+
+	result, err := store.Consolidate(ctx, ConsolidateOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Groups, 1)
+	assert.Equal(t, canonical.ID, result.Groups[0].Canonical.ID, "the newest wording is kept")
+	assert.Equal(t, 1, result.Merged)
+
+	merged, err := store.GetMemoryByID(ctx, canonical.ID)
+	require.NoError(t, err)
+	assert.Equal(t, canonical.Content, merged.Content)
+	assert.Equal(t, []string{"auth", "security"}, merged.Tags)
+	assert.Equal(t, "from chat import", merged.Context)
+
+	citations, err := store.GetCitations(ctx, canonical.ID)
+	require.NoError(t, err)
+	assert.Len(t, citations, 1)
+
+	causalEdges, err := store.GetEdgesFrom(ctx, canonical.ID, "causal")
+	require.NoError(t, err)
+	require.Len(t, causalEdges, 1)
+	assert.Equal(t, other.ID, causalEdges[0].TargetID)
+	selfEdges, err := store.GetEdgesFrom(ctx, canonical.ID, "semantic")
+	require.NoError(t, err)
+	assert.Empty(t, selfEdges, "edges between merged memories are dropped")
+
+	// The old ID resolves to the canonical memory
+	resolved, err := store.GetMemoryByID(ctx, dup.ID)
+	require.NoError(t, err)
+	require.NotNil(t, resolved)
+	assert.Equal(t, canonical.ID, resolved.ID)
+
+	aliases, err := store.Aliases(ctx, canonical.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{dup.ID}, aliases)
+
+	count, err := store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestConsolidate_KeepsCorrectedFacts(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Similar enough to cluster, but each pair states different facts
+	pairs := [][2]string{
+		{"The API uses JWT tokens that expire after 15 minutes", "The API uses JWT tokens that expire after 30 minutes"},
+		{"The public API rate limit is 100 requests per minute", "The public API rate limit is 1000 requests per minute"},
+		{"Deploys run on Fridays after the standup", "Deploys do not run on Fridays after the standup"},
+	}
+	for _, pair := range pairs {
+		_, err := store.Remember(ctx, pair[0], nil, "")
+		require.NoError(t, err)
+		_, err = store.Remember(ctx, pair[1], nil, "")
+		require.NoError(t, err)
+	}
+
+	result, err := store.Consolidate(ctx, ConsolidateOptions{Threshold: 0.5})
+	require.NoError(t, err)
+	assert.Empty(t, result.Groups)
+
+	curation, err := store.RunNightlyCuration(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, curation.Consolidated)
+	count, err := store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, count)
+}
+
+func TestConsolidate_NightlyCurationMerges(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	dup, err := store.Remember(ctx, "The API uses JWT tokens that expire after 15 minutes", nil, "")
+	require.NoError(t, err)
+	canonical, err := store.Remember(ctx, "The API uses JWT tokens which expire after 15 minutes.", nil, "")
+	require.NoError(t, err)
+
+	curation, err := store.RunNightlyCuration(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, curation.Consolidated)
+	count, err := store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	mem, err := store.GetMemoryByID(ctx, dup.ID)
+	require.NoError(t, err)
+	require.NotNil(t, mem)
+	assert.Equal(t, canonical.ID, mem.ID)
+}
+
+func TestFactsDiffer(t *testing.T) {
+	assert.False(t, factsDiffer("Expires after 15 minutes", "It expires after 15 minutes."))
+	assert.True(t, factsDiffer("Expires after 15 minutes", "Expires after 30 minutes"))
+	assert.True(t, factsDiffer("Limit is 100", "Limit is 1000"))
+	assert.True(t, factsDiffer("Version 1.2.3 is supported", "Version 1.2.4 is supported"))
+	assert.True(t, factsDiffer("Deploys run on Fridays", "Deploys don't run on Fridays"))
+	assert.False(t, factsDiffer("Never deploy on Fridays", "Do not ever deploy on Fridays"))
+}
+
+func TestConsolidate_DryRun(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := store.Remember(ctx, "The API uses JWT tokens that expire after 15 minutes", nil, "")
+	require.NoError(t, err)
+	_, err = store.Remember(ctx, "The API uses JWT tokens which expire after 15 minutes.", nil, "")
+	require.NoError(t, err)
+
+	result, err := store.Consolidate(ctx, ConsolidateOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	require.Len(t, result.Groups, 1)
+	assert.Greater(t, result.Groups[0].Duplicates[0].Similarity, DefaultConsolidationThreshold)
+	assert.Zero(t, result.Merged)
+
+	count, err := store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestConsolidate_RespectsScopeAndRevisions(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := store.RememberWithScope(ctx, "Run make test before pushing", nil, "", "github.com/acme/api")
+	require.NoError(t, err)
+	_, err = store.RememberWithScope(ctx, "Run make test before pushing.", nil, "", "github.com/acme/web")
+	require.NoError(t, err)
+
+	// Near-identical, but one is an intentional revision of the other
+	oldMem, err := store.Remember(ctx, "The retry limit is 3 attempts", nil, "")
+	require.NoError(t, err)
+	newMem, err := store.Remember(ctx, "The retry limit is 3 attempts!", nil, "")
+	require.NoError(t, err)
+	require.NoError(t, store.Contradict(ctx, newMem.ID, oldMem.ID))
+
+	result, err := store.Consolidate(ctx, ConsolidateOptions{Threshold: 0.9})
+	require.NoError(t, err)
+	assert.Empty(t, result.Groups)
+}
+
+func TestForget_RemovesAliases(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	dup, err := store.Remember(ctx, "The API uses JWT tokens that expire after 15 minutes", nil, "")
+	require.NoError(t, err)
+	canonical, err := store.Remember(ctx, "The API uses JWT tokens which expire after 15 minutes.", nil, "")
+	require.NoError(t, err)
+	_, err = store.Consolidate(ctx, ConsolidateOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Forget(ctx, canonical.ID))
+	resolved, err := store.GetMemoryByID(ctx, dup.ID)
+	require.NoError(t, err)
+	assert.Nil(t, resolved)
+}
@@ -0,0 +1,284 @@
+// Package memory: binary embedding storage.
+// Vectors in memories.embedding and embedding_cache.embedding are stored as a format
+// byte followed by little-endian float32 values, or, with PHLOEM_EMBED_STORAGE=int8,
+// by a float32 scale and one signed byte per value (a quarter of the size, at a small
+// cost in precision). Older databases stored json.Marshal([]float32), several times
+// larger and slow to decode on every scan: decodeEmbedding still reads it, and
+// migrateEmbeddings rewrites such rows in the current format when the store opens.
+
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// embeddingFormat is the first byte of a stored vector. JSON starts with '[' or 'n',
+// so legacy rows are told apart without a schema change.
+type embeddingFormat byte
+
+const (
+	embeddingFloat32 embeddingFormat = 0x01
+	embeddingInt8    embeddingFormat = 0x02
+)
+
+func (f embeddingFormat) String() string {
+	switch f {
+	case embeddingFloat32:
+		return "float32"
+	case embeddingInt8:
+		return "int8"
+	default:
+		return "json"
+	}
+}
+
+// embeddingStorageFormat reads PHLOEM_EMBED_STORAGE (float32 or int8)
+func embeddingStorageFormat() embeddingFormat {
+	switch v := os.Getenv("PHLOEM_EMBED_STORAGE"); v {
+	case "", "float32":
+		return embeddingFloat32
+	case "int8":
+		return embeddingInt8
+	default:
+		fmt.Fprintf(os.Stderr, "⚠️  Invalid PHLOEM_EMBED_STORAGE %q, using float32\n", v)
+		return embeddingFloat32
+	}
+}
+
+// encode returns the stored form of v; an empty vector is stored as an empty blob
+func (f embeddingFormat) encode(v []float32) []byte {
+	if len(v) == 0 {
+		return []byte{}
+	}
+	if f == embeddingInt8 {
+		var maxAbs float32
+		for _, x := range v {
+			maxAbs = max(maxAbs, float32(math.Abs(float64(x))))
+		}
+		scale := maxAbs / 127
+		blob := make([]byte, 5+len(v))
+		blob[0] = byte(embeddingInt8)
+		binary.LittleEndian.PutUint32(blob[1:], math.Float32bits(scale))
+		if scale > 0 {
+			for i, x := range v {
+				blob[5+i] = byte(int8(math.Round(float64(x / scale))))
+			}
+		}
+		return blob
+	}
+	blob := make([]byte, 1+4*len(v))
+	blob[0] = byte(embeddingFloat32)
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(blob[1+4*i:], math.Float32bits(x))
+	}
+	return blob
+}
+
+// decodeEmbedding reads a stored vector in any format, including legacy JSON
+func decodeEmbedding(blob []byte) ([]float32, error) {
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	switch embeddingFormat(blob[0]) {
+	case embeddingFloat32:
+		if (len(blob)-1)%4 != 0 {
+			return nil, fmt.Errorf("invalid float32 embedding of %d bytes", len(blob))
+		}
+		v := make([]float32, (len(blob)-1)/4)
+		for i := range v {
+			v[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[1+4*i:]))
+		}
+		return v, nil
+	case embeddingInt8:
+		if len(blob) < 5 {
+			return nil, fmt.Errorf("invalid int8 embedding of %d bytes", len(blob))
+		}
+		scale := math.Float32frombits(binary.LittleEndian.Uint32(blob[1:]))
+		v := make([]float32, len(blob)-5)
+		for i := range v {
+			v[i] = float32(int8(blob[5+i])) * scale
+		}
+		return v, nil
+	}
+	var v []float32
+	if err := json.Unmarshal(blob, &v); err != nil {
+		return nil, fmt.Errorf("invalid embedding: %w", err)
+	}
+	return v, nil
+}
+
+// encodeEmbedding returns the stored form of v in the store's format
+func (s *Store) encodeEmbedding(v []float32) []byte {
+	return s.embedFormat.encode(v)
+}
+
+// embeddingTables are the tables holding stored vectors, both keyed by rowid
+var embeddingTables = []string{"memories", "embedding_cache"}
+
+// embeddingStorageSettingKey records the format every vector was last migrated to, so
+// opening the store does not scan all vectors again
+const embeddingStorageSettingKey = "embedding_storage"
+
+// migrateEmbeddings rewrites stored vectors that are not in the store's format (JSON
+// from older versions, or the other binary format after PHLOEM_EMBED_STORAGE changed)
+// and returns how many were rewritten and how many bytes that saved. Rows that cannot
+// be decoded are left as they are.
+func (s *Store) migrateEmbeddings(ctx context.Context) (int, int64, error) {
+	var done string
+	s.db.QueryRowContext(ctx, `SELECT value FROM settings WHERE key = ?`, embeddingStorageSettingKey).Scan(&done)
+	if done == s.embedFormat.String() {
+		return 0, 0, nil
+	}
+
+	converted, saved := 0, int64(0)
+	for _, table := range embeddingTables {
+		lastRowID := int64(0)
+		for {
+			rows, err := s.db.QueryContext(ctx, `SELECT rowid, embedding FROM `+table+`
+				WHERE rowid > ? AND length(embedding) > 0 AND hex(substr(embedding, 1, 1)) != ?
+				ORDER BY rowid LIMIT ?`, lastRowID, fmt.Sprintf("%02X", byte(s.embedFormat)), DefaultReembedBatchSize*10)
+			if err != nil {
+				return converted, saved, fmt.Errorf("failed to read %s embeddings: %w", table, err)
+			}
+			type row struct {
+				rowid int64
+				blob  []byte
+			}
+			var batch []row
+			for rows.Next() {
+				var r row
+				if err := rows.Scan(&r.rowid, &r.blob); err != nil {
+					rows.Close()
+					return converted, saved, fmt.Errorf("failed to read %s embedding: %w", table, err)
+				}
+				batch = append(batch, r)
+			}
+			rows.Close()
+			if len(batch) == 0 {
+				break
+			}
+			lastRowID = batch[len(batch)-1].rowid
+
+			tx, err := s.db.BeginTx(ctx, nil)
+			if err != nil {
+				return converted, saved, fmt.Errorf("failed to begin embedding migration: %w", err)
+			}
+			n, freed := 0, int64(0)
+			for _, r := range batch {
+				v, err := decodeEmbedding(r.blob)
+				if err != nil {
+					continue
+				}
+				blob := s.encodeEmbedding(v)
+				if _, err := tx.ExecContext(ctx, `UPDATE `+table+` SET embedding = ? WHERE rowid = ?`, blob, r.rowid); err != nil {
+					tx.Rollback()
+					return converted, saved, fmt.Errorf("failed to rewrite %s embedding: %w", table, err)
+				}
+				n++
+				freed += int64(len(r.blob) - len(blob))
+			}
+			if err := tx.Commit(); err != nil {
+				return converted, saved, fmt.Errorf("failed to commit embedding migration: %w", err)
+			}
+			converted += n
+			saved += freed
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)`,
+		embeddingStorageSettingKey, s.embedFormat.String()); err != nil {
+		return converted, saved, fmt.Errorf("failed to record embedding storage: %w", err)
+	}
+	return converted, saved, nil
+}
+
+// EmbeddingStorageStats describes how stored vectors are encoded, for 'phloem audit'
+// and memory_stats. Memories and the embedding cache are counted together.
+type EmbeddingStorageStats struct {
+	Vectors    int            `json:"vectors"`
+	ByFormat   map[string]int `json:"by_format"` // float32, int8, or json for rows not migrated yet
+	Bytes      int64          `json:"bytes"`
+	JSONBytes  int64          `json:"json_bytes"`  // Size of the same vectors stored as JSON (estimated from a sample)
+	SavedBytes int64          `json:"saved_bytes"` // JSONBytes - Bytes
+}
+
+// embeddingSampleSize is the number of binary vectors encoded as JSON to estimate
+// their JSON size; the estimate is exact when there are no more vectors than this
+const embeddingSampleSize = 200
+
+// ReadEmbeddingStorage measures the stored vectors. It needs no key and does not
+// write, so it works on a read-only connection.
+func ReadEmbeddingStorage(ctx context.Context, db *sql.DB) (*EmbeddingStorageStats, error) {
+	stats := &EmbeddingStorageStats{ByFormat: make(map[string]int)}
+	var binaryValues int64    // Values stored in binary vectors
+	var sampled int           // Binary vectors sampled
+	var sampleValues int64    // Values in the sampled vectors
+	var sampleJSONBytes int64 // Their size as JSON
+	for _, table := range embeddingTables {
+		rows, err := db.QueryContext(ctx, `SELECT hex(substr(embedding, 1, 1)), COUNT(*), SUM(length(embedding))
+			FROM `+table+` WHERE length(embedding) > 0 GROUP BY 1`)
+		if err != nil {
+			continue // The table may not exist yet
+		}
+		for rows.Next() {
+			var prefix string
+			var n int
+			var bytes int64
+			if rows.Scan(&prefix, &n, &bytes) != nil {
+				continue
+			}
+			b, _ := strconv.ParseUint(prefix, 16, 8)
+			format := embeddingFormat(b)
+			stats.Vectors += n
+			stats.ByFormat[format.String()] += n
+			stats.Bytes += bytes
+			switch format {
+			case embeddingFloat32:
+				binaryValues += (bytes - int64(n)) / 4
+			case embeddingInt8:
+				binaryValues += bytes - 5*int64(n)
+			default:
+				stats.JSONBytes += bytes
+			}
+		}
+		rows.Close()
+
+		if sampled >= embeddingSampleSize {
+			continue
+		}
+		rows, err = db.QueryContext(ctx, `SELECT embedding FROM `+table+`
+			WHERE length(embedding) > 0 AND hex(substr(embedding, 1, 1)) IN ('01', '02') LIMIT ?`, embeddingSampleSize-sampled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sample %s embeddings: %w", table, err)
+		}
+		for rows.Next() {
+			var blob []byte
+			if rows.Scan(&blob) != nil {
+				continue
+			}
+			if v, err := decodeEmbedding(blob); err == nil {
+				data, _ := json.Marshal(v)
+				sampled++
+				sampleValues += int64(len(v))
+				sampleJSONBytes += int64(len(data))
+			}
+		}
+		rows.Close()
+	}
+	if sampleValues > 0 {
+		stats.JSONBytes += int64(math.Round(float64(binaryValues) * float64(sampleJSONBytes) / float64(sampleValues)))
+	}
+	stats.SavedBytes = stats.JSONBytes - stats.Bytes
+	return stats, nil
+}
+
+// EmbeddingStorage measures the stored vectors (see ReadEmbeddingStorage)
+func (s *Store) EmbeddingStorage(ctx context.Context) (*EmbeddingStorageStats, error) {
+	return ReadEmbeddingStorage(ctx, s.db)
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddingFormat_RoundTrip(t *testing.T) {
+	v := randomVectors(1, 384, 7)[0]
+
+	blob := embeddingFloat32.encode(v)
+	assert.Len(t, blob, 1+4*384)
+	decoded, err := decodeEmbedding(blob)
+	require.NoError(t, err)
+	assert.Equal(t, v, decoded)
+
+	blob = embeddingInt8.encode(v)
+	assert.Len(t, blob, 5+384)
+	decoded, err = decodeEmbedding(blob)
+	require.NoError(t, err)
+	require.Len(t, decoded, 384)
+	assert.Greater(t, cosineSimilarity(v, decoded), 0.999, "quantization should barely move the vector")
+
+	// Zero vectors (failed embeddings) stay zero
+	decoded, err = decodeEmbedding(embeddingInt8.encode(make([]float32, 8)))
+	require.NoError(t, err)
+	assert.Len(t, decoded, 8)
+	assert.True(t, isZeroVector(decoded))
+
+	// Legacy JSON, empty and corrupt blobs
+	legacy, _ := json.Marshal(v)
+	assert.Greater(t, len(legacy), 2*len(embeddingFloat32.encode(v)), "JSON should take more than twice the space")
+	decoded, err = decodeEmbedding(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, v, decoded)
+	for _, empty := range [][]byte{nil, embeddingFloat32.encode(nil), []byte("null")} {
+		decoded, err = decodeEmbedding(empty)
+		require.NoError(t, err)
+		assert.Empty(t, decoded)
+	}
+	_, err = decodeEmbedding([]byte{byte(embeddingFloat32), 1, 2})
+	assert.Error(t, err)
+	_, err = decodeEmbedding([]byte("[0.1,"))
+	assert.Error(t, err)
+}
+
+func TestStore_MigratesJSONEmbeddings(t *testing.T) {
+	t.Setenv("PHLOEM_AIR_GAPPED", "1")
+	t.Setenv("PHLOEM_VECTOR_INDEX", "none")
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// A database written by an older version
+	store := openStoreAt(t, dir)
+	deploys, err := store.Remember(ctx, "Deploys run on Fridays after the standup", nil, "")
+	require.NoError(t, err)
+	_, err = store.Remember(ctx, "The staging database is rebuilt nightly", nil, "")
+	require.NoError(t, err)
+	rows, err := store.db.Query(`SELECT id, embedding FROM memories`)
+	require.NoError(t, err)
+	legacy := make(map[string][]byte)
+	for rows.Next() {
+		var id string
+		var blob []byte
+		require.NoError(t, rows.Scan(&id, &blob))
+		assert.Equal(t, byte(embeddingFloat32), blob[0])
+		v, err := decodeEmbedding(blob)
+		require.NoError(t, err)
+		legacy[id], _ = json.Marshal(v)
+	}
+	rows.Close()
+	for id, blob := range legacy {
+		_, err := store.db.Exec(`UPDATE memories SET embedding = ? WHERE id = ?`, blob, id)
+		require.NoError(t, err)
+	}
+	_, err = store.db.Exec(`DELETE FROM settings WHERE key = ?`, embeddingStorageSettingKey)
+	require.NoError(t, err)
+
+	before, err := store.EmbeddingStorage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"json": 2}, before.ByFormat)
+	assert.Zero(t, before.SavedBytes)
+	got, err := store.GetMemoryByID(ctx, deploys.ID)
+	require.NoError(t, err)
+	assert.Equal(t, deploys.Embedding, got.Embedding, "legacy rows should be readable before they are migrated")
+	require.NoError(t, store.Close())
+
+	// Reopened: rewritten in binary, same vectors, smaller
+	store = openStoreAt(t, dir)
+	after, err := store.EmbeddingStorage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"float32": 2}, after.ByFormat)
+	assert.Equal(t, before.Bytes, after.JSONBytes, "the JSON size of two vectors is measured exactly")
+	assert.Equal(t, after.JSONBytes-after.Bytes, after.SavedBytes)
+	got, err = store.GetMemoryByID(ctx, deploys.ID)
+	require.NoError(t, err)
+	assert.Equal(t, deploys.Embedding, got.Embedding)
+	results, err := store.Recall(ctx, "Deploys run on Fridays after the standup", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, deploys.ID, results[0].ID)
+	require.NoError(t, store.Close())
+
+	// Quantized storage: existing rows are converted too
+	t.Setenv("PHLOEM_EMBED_STORAGE", "int8")
+	store = openStoreAt(t, dir)
+	defer store.Close()
+	quantized, err := store.EmbeddingStorage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"int8": 2}, quantized.ByFormat)
+	assert.Less(t, quantized.Bytes*3, after.Bytes)
+	_, err = store.Remember(ctx, "Logs go to stderr", nil, "")
+	require.NoError(t, err)
+	results, err = store.Recall(ctx, "Deploys run on Fridays after the standup", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, deploys.ID, results[0].ID)
+	assert.Greater(t, cosineSimilarity(deploys.Embedding, results[0].Embedding), 0.999)
+}
+
+// Decoding one stored vector, as every linear scan and scanMemory does
+func BenchmarkDecodeEmbedding(b *testing.B) {
+	v := randomVectors(1, 384, 8)[0]
+	legacy, _ := json.Marshal(v)
+	for _, tc := range []struct {
+		name string
+		blob []byte
+	}{
+		{"json", legacy},
+		{"float32", embeddingFloat32.encode(v)},
+		{"int8", embeddingInt8.encode(v)},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportMetric(float64(len(tc.blob)), "bytes")
+			for i := 0; i < b.N; i++ {
+				if _, err := decodeEmbedding(tc.blob); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
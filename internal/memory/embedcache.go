@@ -0,0 +1,261 @@
+// Package memory: persistent embedding cache.
+// Vectors from API and self-hosted embedders are kept in the embedding_cache table,
+// keyed by content hash and model, so text that was embedded before (repeated recall
+// queries and session hints, dreams passes, content a re-embedding migration has
+// already seen) is answered from disk instead of another request. cachingEmbedder
+// wraps the store's embedder to consult the cache on every path. The table holds at
+// most PHLOEM_EMBED_CACHE_SIZE entries, evicting the least recently used; 0 disables
+// it. Local embeddings are cheaper to compute than to look up and are not cached.
+
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultEmbeddingCacheSize is the default maximum number of cached vectors
+const DefaultEmbeddingCacheSize = 20000
+
+// EmbeddingCacheStats describes the embedding cache
+type EmbeddingCacheStats struct {
+	Entries    int     `json:"entries"`
+	MaxEntries int     `json:"max_entries"`
+	Hits       int64   `json:"hits"` // Since the store was opened
+	Misses     int64   `json:"misses"`
+	HitRate    float64 `json:"hit_rate"` // Hits / (hits + misses); 0 before any lookup
+}
+
+// embeddingCache is the embedding_cache table with its size limit and counters
+type embeddingCache struct {
+	db         *sql.DB
+	maxEntries int
+	format     embeddingFormat
+	entries    atomic.Int64
+	hits       atomic.Int64
+	misses     atomic.Int64
+}
+
+// embeddingCacheSize reads PHLOEM_EMBED_CACHE_SIZE
+func embeddingCacheSize() int {
+	if v := os.Getenv("PHLOEM_EMBED_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+		fmt.Fprintf(os.Stderr, "⚠️  Invalid PHLOEM_EMBED_CACHE_SIZE %q, using %d\n", v, DefaultEmbeddingCacheSize)
+	}
+	return DefaultEmbeddingCacheSize
+}
+
+// newEmbeddingCache opens the cache, storing vectors in format, or returns nil when
+// maxEntries disables it
+func newEmbeddingCache(db *sql.DB, maxEntries int, format embeddingFormat) *embeddingCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+	c := &embeddingCache{db: db, maxEntries: maxEntries, format: format}
+	var n int64
+	db.QueryRow(`SELECT COUNT(*) FROM embedding_cache`).Scan(&n)
+	c.entries.Store(n)
+	if n > int64(maxEntries) {
+		c.evict() // The limit was lowered
+	}
+	return c
+}
+
+// get returns the cached vectors for hashes under model, refreshing their last use
+func (c *embeddingCache) get(model string, hashes []string) map[string][]float32 {
+	found := make(map[string][]float32, len(hashes))
+	if len(hashes) == 0 {
+		return found
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(hashes)), ",")
+	args := make([]interface{}, 0, len(hashes)+1)
+	args = append(args, model)
+	for _, h := range hashes {
+		args = append(args, h)
+	}
+
+	rows, err := c.db.Query(`SELECT content_hash, embedding FROM embedding_cache WHERE model = ? AND content_hash IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return found
+	}
+	for rows.Next() {
+		var hash string
+		var embeddingBlob []byte
+		if rows.Scan(&hash, &embeddingBlob) != nil {
+			continue
+		}
+		if vec, err := decodeEmbedding(embeddingBlob); err == nil && len(vec) > 0 {
+			found[hash] = vec
+		}
+	}
+	rows.Close()
+
+	if len(found) > 0 {
+		args = append([]interface{}{time.Now()}, args...)
+		c.db.Exec(`UPDATE embedding_cache SET last_used_at = ? WHERE model = ? AND content_hash IN (`+placeholders+`)`, args...)
+	}
+	return found
+}
+
+// put stores vectors by content hash under model, evicting old entries over the limit
+func (c *embeddingCache) put(model string, vectors map[string][]float32) {
+	if len(vectors) == 0 || model == "" {
+		return
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+	now := time.Now()
+	added := int64(0)
+	for hash, vec := range vectors {
+		res, err := tx.Exec(`
+			INSERT INTO embedding_cache (content_hash, model, embedding, created_at, last_used_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(content_hash, model) DO UPDATE SET embedding = excluded.embedding, last_used_at = excluded.last_used_at
+		`, hash, model, c.format.encode(vec), now, now)
+		if err != nil {
+			return
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added++ // Upper bound: updates count too, corrected by the next recount
+		}
+	}
+	if tx.Commit() != nil {
+		return
+	}
+	if c.entries.Add(added) > int64(c.maxEntries) {
+		c.evict()
+	}
+}
+
+// evict removes the least recently used entries down to 90% of the limit, so eviction
+// runs once per batch of inserts rather than on every one
+func (c *embeddingCache) evict() {
+	var n int64
+	if c.db.QueryRow(`SELECT COUNT(*) FROM embedding_cache`).Scan(&n) != nil {
+		return
+	}
+	target := int64(c.maxEntries) * 9 / 10
+	if n > int64(c.maxEntries) {
+		c.db.Exec(`
+			DELETE FROM embedding_cache WHERE rowid IN (
+				SELECT rowid FROM embedding_cache ORDER BY last_used_at ASC LIMIT ?
+			)
+		`, n-target)
+		c.db.QueryRow(`SELECT COUNT(*) FROM embedding_cache`).Scan(&n)
+	}
+	c.entries.Store(n)
+}
+
+func (c *embeddingCache) stats() *EmbeddingCacheStats {
+	stats := &EmbeddingCacheStats{
+		Entries:    int(c.entries.Load()),
+		MaxEntries: c.maxEntries,
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+// cachingEmbedder answers from the embedding cache and sends only misses to inner
+type cachingEmbedder struct {
+	inner Embedder
+	cache *embeddingCache
+	hash  func(string) string // Store's content hash, keyed when the store is encrypted
+}
+
+// withEmbeddingCache wraps embedder with cache; local embedders and a nil cache are
+// returned unchanged
+func withEmbeddingCache(embedder Embedder, cache *embeddingCache, hash func(string) string) Embedder {
+	if _, isLocal := embedder.(*LocalEmbedder); isLocal || cache == nil {
+		return embedder
+	}
+	return &cachingEmbedder{inner: embedder, cache: cache, hash: hash}
+}
+
+// Embed returns the cached vector for text or embeds it with the inner embedder
+func (e *cachingEmbedder) Embed(text string) ([]float32, error) {
+	embeddings, err := e.EmbedBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch embeds the texts that are not cached in one inner batch
+func (e *cachingEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	model := e.inner.Model()
+	hashes := make([]string, len(texts))
+	for i, text := range texts {
+		hashes[i] = e.hash(text)
+	}
+	cached := e.cache.get(model, hashes)
+
+	embeddings := make([][]float32, len(texts))
+	var missing []int
+	var missingTexts []string
+	queued := make(map[string]bool)
+	for i, hash := range hashes {
+		if vec, ok := cached[hash]; ok {
+			embeddings[i] = vec
+			continue
+		}
+		missing = append(missing, i)
+		if !queued[hash] {
+			queued[hash] = true
+			missingTexts = append(missingTexts, texts[i])
+		}
+	}
+	e.cache.hits.Add(int64(len(texts) - len(missing)))
+	e.cache.misses.Add(int64(len(missing)))
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	fresh, err := e.inner.EmbedBatch(missingTexts)
+	if err != nil {
+		return nil, err
+	}
+	if len(fresh) != len(missingTexts) {
+		return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(fresh), len(missingTexts))
+	}
+
+	byHash := make(map[string][]float32, len(fresh))
+	for i, text := range missingTexts {
+		byHash[e.hash(text)] = fresh[i]
+	}
+	for _, i := range missing {
+		embeddings[i] = byHash[hashes[i]]
+	}
+	e.cache.put(model, byHash)
+	return embeddings, nil
+}
+
+// Dimensions returns the inner embedder's dimensions
+func (e *cachingEmbedder) Dimensions() int {
+	return e.inner.Dimensions()
+}
+
+// Model returns the inner embedder's model
+func (e *cachingEmbedder) Model() string {
+	return e.inner.Model()
+}
+
+// EmbeddingCacheStats reports the embedding cache, or nil when it is disabled
+func (s *Store) EmbeddingCacheStats() *EmbeddingCacheStats {
+	if s.embedCache == nil {
+		return nil
+	}
+	return s.embedCache.stats()
+}
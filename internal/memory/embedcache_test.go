@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useHTTPEmbedder points the store's embedder at server
+func useHTTPEmbedder(t *testing.T, server *embeddingServer) {
+	t.Setenv("PHLOEM_AIR_GAPPED", "")
+	t.Setenv("PHLOEM_EMBEDDINGS", "http")
+	t.Setenv("PHLOEM_EMBED_URL", server.URL)
+	t.Setenv("PHLOEM_EMBED_MODEL", "bge-small")
+	t.Setenv("PHLOEM_EMBED_DIMENSIONS", "8")
+	t.Setenv("PHLOEM_EMBED_API", "openai")
+}
+
+func TestEmbeddingCache_RepeatedTextIsNotEmbeddedAgain(t *testing.T) {
+	server := newEmbeddingServer(t, 8, true, false)
+	useHTTPEmbedder(t, server)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	store := openStoreAt(t, dir)
+	_, err := store.Remember(ctx, "Deploys run on Fridays", nil, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, server.requests())
+
+	// Asking the same thing again costs no further request
+	for i := 0; i < 3; i++ {
+		_, err = store.Recall(ctx, "when do deploys run", 5, nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, server.requests())
+
+	stats := store.EmbeddingCacheStats()
+	require.NotNil(t, stats)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.InDelta(t, 0.5, stats.HitRate, 0.001)
+	store.Close()
+
+	// The cache survives a restart
+	store = openStoreAt(t, dir)
+	defer store.Close()
+	_, err = store.Recall(ctx, "when do deploys run", 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.requests())
+	assert.Equal(t, int64(1), store.EmbeddingCacheStats().Hits)
+}
+
+func TestEmbeddingCache_BatchSendsOnlyMisses(t *testing.T) {
+	server := newEmbeddingServer(t, 8, true, false)
+	useHTTPEmbedder(t, server)
+	store := openStoreAt(t, t.TempDir())
+	defer store.Close()
+
+	vecs, err := store.embedder.EmbedBatch([]string{"a", "bb"})
+	require.NoError(t, err)
+	require.Len(t, vecs, 2)
+
+	vecs, err = store.embedder.EmbedBatch([]string{"bb", "ccc", "a", "ccc"})
+	require.NoError(t, err)
+	for i, text := range []string{"bb", "ccc", "a", "ccc"} {
+		assert.Equal(t, float32(len(text)), vecs[i][0], "embedding %d out of order", i)
+	}
+	assert.Equal(t, []int{2, 1}, server.batches, "only the new text should be sent, once")
+}
+
+func TestEmbeddingCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	server := newEmbeddingServer(t, 8, true, false)
+	useHTTPEmbedder(t, server)
+	t.Setenv("PHLOEM_EMBED_CACHE_SIZE", "10")
+	store := openStoreAt(t, t.TempDir())
+	defer store.Close()
+
+	_, err := store.embedder.Embed("kept")
+	require.NoError(t, err)
+	for i := 0; i < 12; i++ {
+		_, err := store.embedder.Embed(fmt.Sprintf("text %d", i))
+		require.NoError(t, err)
+		_, err = store.embedder.Embed("kept") // Recently used again
+		require.NoError(t, err)
+	}
+
+	stats := store.EmbeddingCacheStats()
+	assert.LessOrEqual(t, stats.Entries, 10)
+	var n int
+	require.NoError(t, store.db.QueryRow(`SELECT COUNT(*) FROM embedding_cache`).Scan(&n))
+	assert.Equal(t, stats.Entries, n)
+
+	requests := server.requests()
+	_, err = store.embedder.Embed("kept")
+	require.NoError(t, err)
+	assert.Equal(t, requests, server.requests(), "a recently used entry should survive eviction")
+	_, err = store.embedder.Embed("text 0")
+	require.NoError(t, err)
+	assert.Equal(t, requests+1, server.requests(), "the oldest entry should have been evicted")
+}
+
+func TestEmbeddingCache_ReembedKeepsReplacedVectors(t *testing.T) {
+	server := newEmbeddingServer(t, 8, true, false)
+	useHTTPEmbedder(t, server)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store := openStoreAt(t, dir)
+	_, err := store.Remember(ctx, "Logs go to stderr", nil, "")
+	require.NoError(t, err)
+	store.Close()
+
+	// To another model and back: the second switch is answered from the cache
+	t.Setenv("PHLOEM_EMBED_MODEL", "bge-large")
+	store = openStoreAt(t, dir)
+	_, err = store.Reembed(ctx, ReembedOptions{})
+	require.NoError(t, err)
+	store.Close()
+
+	t.Setenv("PHLOEM_EMBED_MODEL", "bge-small")
+	store = openStoreAt(t, dir)
+	defer store.Close()
+	requests := server.requests()
+	result, err := store.Reembed(ctx, ReembedOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Reembedded)
+	assert.Equal(t, requests, server.requests())
+}
+
+func TestEmbeddingCache_Disabled(t *testing.T) {
+	server := newEmbeddingServer(t, 8, true, false)
+	useHTTPEmbedder(t, server)
+	t.Setenv("PHLOEM_EMBED_CACHE_SIZE", "0")
+	store := openStoreAt(t, t.TempDir())
+	defer store.Close()
+	assert.Nil(t, store.EmbeddingCacheStats())
+	assert.NotContains(t, fmt.Sprintf("%T", store.embedder), "cachingEmbedder")
+
+	// Local embeddings are not cached either
+	t.Setenv("PHLOEM_EMBED_CACHE_SIZE", "")
+	t.Setenv("PHLOEM_EMBEDDINGS", "local")
+	local := openStoreAt(t, t.TempDir())
+	defer local.Close()
+	assert.IsType(t, &LocalEmbedder{}, local.embedder)
+}
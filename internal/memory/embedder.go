@@ -4,12 +4,14 @@ package memory
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,55 +20,67 @@ type Embedder interface {
 	Embed(text string) ([]float32, error)
 	EmbedBatch(texts []string) ([][]float32, error)
 	Dimensions() int
+	// Model identifies the embedding space (e.g. "openai:text-embedding-3-small").
+	// Vectors are only comparable when they come from the same model.
+	Model() string
 }
 
-// FallbackEmbedder wraps a primary embedder and falls back to local on errors (e.g. expired API keys)
+// ErrEmbedderUnavailable is returned by FallbackEmbedder while its primary embedder is failing
+var ErrEmbedderUnavailable = errors.New("embedder unavailable")
+
+// fallbackRetryInterval is how long FallbackEmbedder leaves a failed primary alone
+const fallbackRetryInterval = 30 * time.Second
+
+// FallbackEmbedder wraps an API embedder that can fail at runtime (e.g. expired API keys,
+// network failures). It does not substitute vectors from another model, which could not
+// be compared with the primary's: while the primary is failing it returns
+// ErrEmbedderUnavailable, so the store queues new memories in pending_embeddings and
+// answers queries lexically. The primary is retried every fallbackRetryInterval.
 type FallbackEmbedder struct {
-	primary  Embedder
-	fallback Embedder
-	failed   bool // sticky: once primary fails, stay on fallback for the session
+	primary Embedder
+	retryAt atomic.Int64 // UnixNano before which the primary is not called; 0 while healthy
 }
 
 func NewFallbackEmbedder(primary Embedder) *FallbackEmbedder {
-	return &FallbackEmbedder{
-		primary:  primary,
-		fallback: NewLocalEmbedder(),
-	}
+	return &FallbackEmbedder{primary: primary}
 }
 
 func (f *FallbackEmbedder) Embed(text string) ([]float32, error) {
-	if f.failed {
-		return f.fallback.Embed(text)
-	}
-	result, err := f.primary.Embed(text)
+	embeddings, err := f.EmbedBatch([]string{text})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "⚠️  Primary embedder failed (%v), falling back to local\n", err)
-		f.failed = true
-		return f.fallback.Embed(text)
+		return nil, err
 	}
-	return result, nil
+	return embeddings[0], nil
 }
 
 func (f *FallbackEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
-	if f.failed {
-		return f.fallback.EmbedBatch(texts)
+	retryAt := f.retryAt.Load()
+	if retryAt != 0 && time.Now().UnixNano() < retryAt {
+		return nil, fmt.Errorf("%w: %s is failing, retrying in %s", ErrEmbedderUnavailable, f.primary.Model(),
+			time.Until(time.Unix(0, retryAt)).Round(time.Second))
 	}
 	result, err := f.primary.EmbedBatch(texts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "⚠️  Primary embedder failed (%v), falling back to local\n", err)
-		f.failed = true
-		return f.fallback.EmbedBatch(texts)
+		if f.retryAt.Swap(time.Now().Add(fallbackRetryInterval).UnixNano()) == 0 {
+			fmt.Fprintf(os.Stderr, "⚠️  Primary embedder failed (%v), queuing embeddings until it recovers\n", err)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrEmbedderUnavailable, err)
+	}
+	if f.retryAt.Swap(0) != 0 {
+		fmt.Fprintln(os.Stderr, "✅ Primary embedder recovered")
 	}
 	return result, nil
 }
 
 func (f *FallbackEmbedder) Dimensions() int {
-	if f.failed {
-		return f.fallback.Dimensions()
-	}
 	return f.primary.Dimensions()
 }
 
+// Model returns the primary's model: the only one FallbackEmbedder produces vectors with
+func (f *FallbackEmbedder) Model() string {
+	return f.primary.Model()
+}
+
 // OpenAIEmbedder uses OpenAI's embedding API directly
 type OpenAIEmbedder struct {
 	apiKey     string
@@ -192,6 +206,11 @@ func (e *GeminiEmbedder) Dimensions() int {
 	return e.dimensions
 }
 
+// Model returns the embedding model identifier
+func (e *GeminiEmbedder) Model() string {
+	return "gemini:" + e.model
+}
+
 // Embed generates an embedding for a single text via OpenAI directly
 func (e *OpenAIEmbedder) Embed(text string) ([]float32, error) {
 	embeddings, err := e.EmbedBatch([]string{text})
@@ -214,6 +233,11 @@ func (e *OpenAIEmbedder) Dimensions() int {
 	return e.dimensions
 }
 
+// Model returns the embedding model identifier
+func (e *OpenAIEmbedder) Model() string {
+	return "openai:" + e.model
+}
+
 // callEmbeddingAPI is shared logic for calling OpenAI-compatible embedding APIs
 func callEmbeddingAPI(client *http.Client, url, apiKey, model string, texts []string) ([][]float32, error) {
 	reqBody := map[string]interface{}{
@@ -346,6 +370,12 @@ func (e *LocalEmbedder) Dimensions() int {
 	return e.dimensions
 }
 
+// Model returns the embedding model identifier. Bump the version when the features
+// change, so stored vectors are re-embedded instead of compared with new ones.
+func (e *LocalEmbedder) Model() string {
+	return fmt.Sprintf("local:enhanced-v1-%d", e.dimensions)
+}
+
 // generateEnhancedEmbedding creates a multi-feature embedding
 func (e *LocalEmbedder) generateEnhancedEmbedding(text string) []float32 {
 	embedding := make([]float32, e.dimensions)
@@ -598,11 +628,12 @@ func normalize(v []float32) {
 // - Admin-phloem: API embeddings (low latency, high resilience) - set PHLOEM_ADMIN_MODE=true
 // - Free tier / default: Local embeddings by default (privacy + cost; no PHLOEM_EMBEDDINGS needed)
 //
-// Explicit override: Set PHLOEM_EMBEDDINGS=openai|gemini|local
+// Explicit override: Set PHLOEM_EMBEDDINGS=openai|gemini|http|local
+// (http uses a self-hosted server at PHLOEM_EMBED_URL; see httpEmbedderConfigFromEnv)
 func GetEmbedder() Embedder {
 	embedder := getEmbedderInner()
-	// Wrap any API-based embedder with fallback to local on runtime errors
-	// (e.g. expired API keys, network failures)
+	// Wrap any API-based embedder so runtime errors (e.g. expired API keys, network
+	// failures) queue embeddings instead of failing every call
 	if _, isLocal := embedder.(*LocalEmbedder); !isLocal {
 		return NewFallbackEmbedder(embedder)
 	}
@@ -641,6 +672,25 @@ func getEmbedderInner() Embedder {
 			} else {
 				fmt.Fprintln(os.Stderr, "⚠️  PHLOEM_EMBEDDINGS=gemini but GEMINI_API_KEY not set")
 			}
+		case "http":
+			// With PHLOEM_EMBED_DIMENSIONS set the server is not probed, so a server that
+			// is down keeps its model: GetEmbedder wraps it to queue embeddings meanwhile
+			cfg := httpEmbedderConfigFromEnv()
+			embedder, err := NewHTTPEmbedder(cfg)
+			if err == nil {
+				api := embedder.api
+				if api == "" {
+					api = "self-hosted"
+				}
+				fmt.Fprintf(os.Stderr, "🧠 Using %s embeddings from %s (%d dimensions, explicit override)\n",
+					api, embedder.endpoint, embedder.dimensions)
+				return embedder
+			}
+			if cfg.Dimensions == 0 {
+				fmt.Fprintf(os.Stderr, "⚠️  HTTP embedder failed: %v, falling back (set PHLOEM_EMBED_DIMENSIONS to keep the model while the server is down)\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "⚠️  HTTP embedder failed: %v, falling back\n", err)
+			}
 		case "local":
 			fmt.Fprintln(os.Stderr, "🧠 Using local embeddings (explicit override)")
 			return NewLocalEmbedder()
@@ -0,0 +1,505 @@
+// Package memory: encryption at rest.
+// When enabled, memory content and context, revision content and context, and citation
+// snippets are sealed with AES-256-GCM before they reach SQLite, and content hashes become
+// keyed HMACs so duplicates can still be detected without revealing content. Embeddings
+// stay in plaintext because recall needs them, and the full-text index is dropped because
+// it would otherwise hold a plaintext copy of every memory.
+//
+// The key comes from a key file (default ~/.phloem/encryption.key, override with
+// PHLOEM_KEY_FILE) or is derived from PHLOEM_PASSPHRASE with PBKDF2. The settings table
+// records which, along with a check value that detects a wrong key before anything is read.
+
+package memory
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Encryption methods recorded in EncryptionConfig
+const (
+	EncryptionKeyFile    = "keyfile"
+	EncryptionPassphrase = "passphrase"
+)
+
+const (
+	// sealedPrefix marks an encrypted column value: "enc1:" + base64(nonce || ciphertext)
+	sealedPrefix = "enc1:"
+
+	// DefaultKeyFileName is the key file created in the data directory by 'phloem encrypt'
+	DefaultKeyFileName = "encryption.key"
+
+	// PassphraseIterations is the PBKDF2-SHA256 work factor for passphrase-derived keys
+	PassphraseIterations = 600000
+
+	encryptionSettingKey = "encryption"
+	encryptionCheckValue = "phloem-encryption-check"
+)
+
+// ErrWrongKey is returned when the supplied key does not decrypt the database.
+var ErrWrongKey = errors.New("encryption key does not match this database")
+
+// EncryptionConfig records how an encrypted database's key is obtained.
+// It is stored in the settings table and contains no secret material.
+type EncryptionConfig struct {
+	Version    int    `json:"version"`
+	Method     string `json:"method"`               // EncryptionKeyFile or EncryptionPassphrase
+	KeyFile    string `json:"key_file,omitempty"`   // Key file used at encryption time
+	Salt       string `json:"salt,omitempty"`       // Base64 PBKDF2 salt (passphrase only)
+	Iterations int    `json:"iterations,omitempty"` // PBKDF2 rounds (passphrase only)
+	Check      string `json:"check"`                // Sealed known value, used to detect a wrong key
+}
+
+// EncryptionResult counts the values sealed or opened by EnableEncryption/DisableEncryption.
+type EncryptionResult struct {
+	Memories  int `json:"memories"`
+	Revisions int `json:"revisions"`
+	Citations int `json:"citations"`
+}
+
+// fieldCipher seals column values and computes keyed content hashes.
+type fieldCipher struct {
+	aead    cipher.AEAD
+	hashKey []byte
+}
+
+func newFieldCipher(key []byte) (*fieldCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	encKey, err := hkdf.Key(sha256.New, key, nil, "phloem column encryption", 32)
+	if err != nil {
+		return nil, err
+	}
+	hashKey, err := hkdf.Key(sha256.New, key, nil, "phloem content hash", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &fieldCipher{aead: aead, hashKey: hashKey}, nil
+}
+
+func (c *fieldCipher) seal(plain string) string {
+	nonce := make([]byte, c.aead.NonceSize())
+	rand.Read(nonce)
+	sealed := c.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+func (c *fieldCipher) open(stored string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	n := c.aead.NonceSize()
+	if len(data) < n {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	plain, err := c.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return "", ErrWrongKey
+	}
+	return string(plain), nil
+}
+
+func (c *fieldCipher) hash(content string) string {
+	mac := hmac.New(sha256.New, c.hashKey)
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isSealed reports whether a stored column value is encrypted
+func isSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// seal encrypts a value for storage. Empty values and stores without encryption pass through.
+func (s *Store) seal(plain string) string {
+	if s.cipher == nil || plain == "" {
+		return plain
+	}
+	return s.cipher.seal(plain)
+}
+
+// open decrypts a stored value. Plaintext values (from before encryption) pass through.
+func (s *Store) open(stored string) string {
+	if !isSealed(stored) || s.cipher == nil {
+		return stored
+	}
+	plain, err := s.cipher.open(stored)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to decrypt value: %v\n", err)
+		return stored
+	}
+	return plain
+}
+
+// hashContent returns the deduplication hash for content: SHA-256, or a keyed HMAC when encrypted
+func (s *Store) hashContent(content string) string {
+	if s.cipher != nil {
+		return s.cipher.hash(content)
+	}
+	return contentHash(content)
+}
+
+// Encrypted reports whether the store encrypts content at rest
+func (s *Store) Encrypted() bool {
+	return s.cipher != nil
+}
+
+// GenerateKeyFile writes a new random 32-byte key to path (base64, mode 0600) and returns it.
+func GenerateKeyFile(path string) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := os.WriteFile(path, []byte(encoded), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	return key, nil
+}
+
+// LoadKeyFile reads a key written by GenerateKeyFile
+func LoadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid key file %s: expected a base64-encoded 32-byte key", path)
+	}
+	return key, nil
+}
+
+// NewPassphraseConfig derives a key from passphrase with a fresh salt and returns it
+// along with the config needed to derive it again.
+func NewPassphraseConfig(passphrase string) ([]byte, EncryptionConfig, error) {
+	if passphrase == "" {
+		return nil, EncryptionConfig{}, fmt.Errorf("passphrase cannot be empty")
+	}
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	cfg := EncryptionConfig{
+		Method:     EncryptionPassphrase,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Iterations: PassphraseIterations,
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, cfg.Iterations, 32)
+	if err != nil {
+		return nil, EncryptionConfig{}, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, cfg, nil
+}
+
+// resolveEncryptionKey finds the key for an encrypted database and checks it against cfg.
+func resolveEncryptionKey(dataDir string, cfg *EncryptionConfig) (*fieldCipher, error) {
+	var key []byte
+	switch cfg.Method {
+	case EncryptionPassphrase:
+		passphrase := os.Getenv("PHLOEM_PASSPHRASE")
+		if passphrase == "" {
+			return nil, fmt.Errorf("memories.db is encrypted with a passphrase; set PHLOEM_PASSPHRASE")
+		}
+		salt, err := base64.StdEncoding.DecodeString(cfg.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption salt: %w", err)
+		}
+		key, err = pbkdf2.Key(sha256.New, passphrase, salt, cfg.Iterations, 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+	case EncryptionKeyFile:
+		path := os.Getenv("PHLOEM_KEY_FILE")
+		if path == "" {
+			path = cfg.KeyFile
+		}
+		if path == "" {
+			path = filepath.Join(dataDir, DefaultKeyFileName)
+		}
+		var err error
+		if key, err = LoadKeyFile(path); err != nil {
+			return nil, fmt.Errorf("memories.db is encrypted; %w (set PHLOEM_KEY_FILE to its location)", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown encryption method %q", cfg.Method)
+	}
+
+	c, err := newFieldCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if check, err := c.open(cfg.Check); err != nil || check != encryptionCheckValue {
+		return nil, ErrWrongKey
+	}
+	return c, nil
+}
+
+// loadEncryptionConfig returns the database's encryption config, or nil if it is not encrypted
+func loadEncryptionConfig(ctx context.Context, db *sql.DB) (*EncryptionConfig, error) {
+	var raw string
+	err := db.QueryRowContext(ctx, `SELECT value FROM settings WHERE key = ?`, encryptionSettingKey).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption settings: %w", err)
+	}
+	var cfg EncryptionConfig
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse encryption settings: %w", err)
+	}
+	return &cfg, nil
+}
+
+// EnableEncryption encrypts every stored memory, revision and citation with key and
+// records cfg so later opens can find the key. The full-text index is dropped and the
+// database vacuumed so no plaintext copy is left behind.
+func (s *Store) EnableEncryption(ctx context.Context, key []byte, cfg EncryptionConfig) (*EncryptionResult, error) {
+	if s.cipher != nil {
+		return nil, fmt.Errorf("database is already encrypted")
+	}
+	c, err := newFieldCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Version = 1
+	cfg.Check = c.seal(encryptionCheckValue)
+	cfgJSON, _ := json.Marshal(cfg)
+
+	result, err := s.rewriteColumns(ctx, func(value string) string {
+		if value == "" || isSealed(value) {
+			return value
+		}
+		return c.seal(value)
+	}, c.hash, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)`, encryptionSettingKey, string(cfgJSON))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cipher = c
+	s.ftsIdx = &ftsIndex{db: s.db}
+	s.compact()
+	return result, nil
+}
+
+// DisableEncryption decrypts everything sealed by EnableEncryption, removes the
+// encryption settings and rebuilds the full-text index.
+func (s *Store) DisableEncryption(ctx context.Context) (*EncryptionResult, error) {
+	if s.cipher == nil {
+		return nil, fmt.Errorf("database is not encrypted")
+	}
+	c := s.cipher
+	var openErr error
+	result, err := s.rewriteColumns(ctx, func(value string) string {
+		if !isSealed(value) {
+			return value
+		}
+		plain, err := c.open(value)
+		if err != nil && openErr == nil {
+			openErr = err
+		}
+		return plain
+	}, contentHash, func(tx *sql.Tx) error {
+		if openErr != nil {
+			return fmt.Errorf("failed to decrypt: %w", openErr)
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM settings WHERE key = ?`, encryptionSettingKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cipher = nil
+	s.ftsIdx = newFTSIndex(s.db)
+	if n, err := s.ftsIdx.Backfill(); err == nil && n > 0 {
+		fmt.Fprintf(os.Stderr, "🔍 Rebuilt full-text index with %d memories\n", n)
+	}
+	s.compact()
+	return result, nil
+}
+
+// rewriteColumns applies transform to every encryptable column and rehashes content,
+// in one transaction. Plaintext content is read back through transform so the hash
+// is always computed over the decrypted value. finish runs last inside the transaction.
+func (s *Store) rewriteColumns(ctx context.Context, transform func(string) string, hash func(string) string, finish func(*sql.Tx) error) (*EncryptionResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The full-text triggers would copy content into memories_fts on every update
+	for _, stmt := range []string{
+		`DROP TRIGGER IF EXISTS memories_fts_ai`,
+		`DROP TRIGGER IF EXISTS memories_fts_ad`,
+		`DROP TRIGGER IF EXISTS memories_fts_au`,
+		`DROP TABLE IF EXISTS memories_fts`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("failed to drop full-text index: %w", err)
+		}
+	}
+
+	plain := func(stored string) string {
+		if isSealed(stored) && s.cipher != nil {
+			if p, err := s.cipher.open(stored); err == nil {
+				return p
+			}
+		}
+		return stored
+	}
+
+	result := &EncryptionResult{}
+	type row struct{ id, content, context string }
+	load := func(query string) ([]row, error) {
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var out []row
+		for rows.Next() {
+			var r row
+			var content, memContext sql.NullString
+			if err := rows.Scan(&r.id, &content, &memContext); err != nil {
+				return nil, err
+			}
+			r.content, r.context = content.String, memContext.String
+			out = append(out, r)
+		}
+		return out, rows.Err()
+	}
+
+	memories, err := load(`SELECT id, content, context FROM memories`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memories: %w", err)
+	}
+	for _, r := range memories {
+		if _, err := tx.ExecContext(ctx, `UPDATE memories SET content = ?, context = ?, content_hash = ? WHERE id = ?`,
+			transform(r.content), transform(r.context), hash(plain(r.content)), r.id); err != nil {
+			return nil, fmt.Errorf("failed to rewrite memory %s: %w", r.id, err)
+		}
+		result.Memories++
+	}
+
+	revisions, err := load(`SELECT id, content, context FROM memory_revisions`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revisions: %w", err)
+	}
+	for _, r := range revisions {
+		if _, err := tx.ExecContext(ctx, `UPDATE memory_revisions SET content = ?, context = ? WHERE id = ?`,
+			transform(r.content), transform(r.context), r.id); err != nil {
+			return nil, fmt.Errorf("failed to rewrite revision %s: %w", r.id, err)
+		}
+		result.Revisions++
+	}
+
+	citations, err := load(`SELECT id, content, '' FROM citations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read citations: %w", err)
+	}
+	for _, r := range citations {
+		if _, err := tx.ExecContext(ctx, `UPDATE citations SET content = ? WHERE id = ?`, transform(r.content), r.id); err != nil {
+			return nil, fmt.Errorf("failed to rewrite citation %s: %w", r.id, err)
+		}
+		result.Citations++
+	}
+
+	// Cache keys are content hashes, which change with the key
+	if _, err := tx.ExecContext(ctx, `DELETE FROM embedding_cache`); err != nil {
+		return nil, fmt.Errorf("failed to clear embedding cache: %w", err)
+	}
+
+	if err := finish(tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	if s.embedCache != nil {
+		s.embedCache.entries.Store(0)
+	}
+	return result, nil
+}
+
+// compact checkpoints the WAL and vacuums so overwritten plaintext is not left in free pages
+func (s *Store) compact() {
+	s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  VACUUM failed, old values may remain in free pages: %v\n", err)
+	}
+	s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+}
+
+// EncryptionStatus summarizes encryption for 'phloem audit'. It needs no key.
+type EncryptionStatus struct {
+	Config    *EncryptionConfig
+	Sealed    int // Encrypted values across memories, revisions and citations
+	Plaintext int // Non-empty values still stored in plaintext
+}
+
+// ReadEncryptionStatus inspects a database handle (which may be read-only) without decrypting anything
+func ReadEncryptionStatus(ctx context.Context, db *sql.DB) (*EncryptionStatus, error) {
+	status := &EncryptionStatus{}
+	cfg, err := loadEncryptionConfig(ctx, db)
+	if err != nil && !strings.Contains(err.Error(), "no such table") {
+		return nil, err
+	}
+	status.Config = cfg
+
+	queries := []string{
+		`SELECT content FROM memories`, `SELECT context FROM memories`,
+		`SELECT content FROM memory_revisions`, `SELECT context FROM memory_revisions`,
+		`SELECT content FROM citations`,
+	}
+	for _, q := range queries {
+		rows, err := db.QueryContext(ctx, q)
+		if err != nil {
+			continue // table may not exist yet
+		}
+		for rows.Next() {
+			var v sql.NullString
+			if rows.Scan(&v) != nil || v.String == "" {
+				continue
+			}
+			if isSealed(v.String) {
+				status.Sealed++
+			} else {
+				status.Plaintext++
+			}
+		}
+		rows.Close()
+	}
+	return status, nil
+}
@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rawColumnValues returns every stored content, context and citation snippet, undecrypted
+func rawColumnValues(t *testing.T, store *Store) []string {
+	t.Helper()
+	var values []string
+	for _, q := range []string{
+		`SELECT COALESCE(content, '') || '|' || COALESCE(context, '') FROM memories`,
+		`SELECT COALESCE(content, '') || '|' || COALESCE(context, '') FROM memory_revisions`,
+		`SELECT COALESCE(content, '') FROM citations`,
+	} {
+		rows, err := store.db.Query(q)
+		require.NoError(t, err)
+		for rows.Next() {
+			var v string
+			require.NoError(t, rows.Scan(&v))
+			values = append(values, v)
+		}
+		rows.Close()
+	}
+	return values
+}
+
+func TestEncryption_KeyFileRoundTrip(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mem, err := store.Remember(ctx, "The staging database password rotates monthly", []string{"ops"}, "from the runbook")
+	require.NoError(t, err)
+	newContent := "The staging database password rotates weekly"
+	_, err = store.Update(ctx, mem.ID, MemoryUpdate{Content: &newContent})
+	require.NoError(t, err)
+	_, err = store.AddCitation(ctx, mem.ID, "ops/rotate.sh", 1, 3, "", "rotate_password staging")
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(store.DataDir(), DefaultKeyFileName)
+	key, err := GenerateKeyFile(keyFile)
+	require.NoError(t, err)
+	result, err := store.EnableEncryption(ctx, key, EncryptionConfig{Method: EncryptionKeyFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	assert.Equal(t, &EncryptionResult{Memories: 1, Revisions: 1, Citations: 1}, result)
+	assert.True(t, store.Encrypted())
+
+	for _, v := range rawColumnValues(t, store) {
+		assert.NotContains(t, v, "staging", "plaintext left in the database: %q", v)
+	}
+	var ftsTables int
+	store.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'memories_fts'`).Scan(&ftsTables)
+	assert.Zero(t, ftsTables, "full-text index should be dropped")
+
+	// Reads decrypt, writes encrypt, and duplicates are still detected
+	got, err := store.GetMemoryByID(ctx, mem.ID)
+	require.NoError(t, err)
+	assert.Equal(t, newContent, got.Content)
+	assert.Equal(t, "from the runbook", got.Context)
+	again, err := store.Remember(ctx, newContent, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, mem.ID, again.ID)
+	revisions, err := store.GetRevisions(ctx, mem.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "The staging database password rotates monthly", revisions[0].Content)
+	citations, err := store.GetCitations(ctx, mem.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "rotate_password staging", citations[0].Content)
+
+	// Reopening finds the recorded key file
+	store.Close()
+	reopened, err := NewStore()
+	require.NoError(t, err)
+	assert.True(t, reopened.Encrypted())
+	got, err = reopened.GetMemoryByID(ctx, mem.ID)
+	require.NoError(t, err)
+	assert.Equal(t, newContent, got.Content)
+
+	// And decrypting restores plaintext and keyword search
+	_, err = reopened.DisableEncryption(ctx)
+	require.NoError(t, err)
+	for _, v := range rawColumnValues(t, reopened) {
+		assert.False(t, strings.HasPrefix(v, sealedPrefix), "value still sealed: %q", v)
+	}
+	results, err := reopened.RecallWithRecencyBoost(ctx, "weekly", 5, RecallOptions{Mode: RecallModeLexical})
+	require.NoError(t, err)
+	assert.NotEmpty(t, results)
+	reopened.Close()
+}
+
+func TestEncryption_WrongOrMissingKey(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := store.Remember(ctx, "secret memory", nil, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(store.DataDir(), DefaultKeyFileName)
+	key, err := GenerateKeyFile(keyFile)
+	require.NoError(t, err)
+	_, err = store.EnableEncryption(ctx, key, EncryptionConfig{Method: EncryptionKeyFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	store.Close()
+
+	otherKey := filepath.Join(t.TempDir(), "other.key")
+	_, err = GenerateKeyFile(otherKey)
+	require.NoError(t, err)
+	t.Setenv("PHLOEM_KEY_FILE", otherKey)
+	_, err = NewStore()
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	t.Setenv("PHLOEM_KEY_FILE", filepath.Join(t.TempDir(), "missing.key"))
+	_, err = NewStore()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "encrypted")
+
+	t.Setenv("PHLOEM_KEY_FILE", "")
+	reopened, err := NewStore()
+	require.NoError(t, err)
+	reopened.Close()
+}
+
+func TestEncryption_Passphrase(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := store.Remember(ctx, "passphrase protected memory", nil, "")
+	require.NoError(t, err)
+	key, cfg, err := NewPassphraseConfig("correct horse battery staple")
+	require.NoError(t, err)
+	_, err = store.EnableEncryption(ctx, key, cfg)
+	require.NoError(t, err)
+	store.Close()
+
+	t.Setenv("PHLOEM_PASSPHRASE", "")
+	_, err = NewStore()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PHLOEM_PASSPHRASE")
+
+	t.Setenv("PHLOEM_PASSPHRASE", "wrong passphrase")
+	_, err = NewStore()
+	assert.ErrorIs(t, err, ErrWrongKey)
+
+	t.Setenv("PHLOEM_PASSPHRASE", "correct horse battery staple")
+	reopened, err := NewStore()
+	require.NoError(t, err)
+	defer reopened.Close()
+	memories, err := reopened.List(ctx, 10, nil)
+	require.NoError(t, err)
+	require.Len(t, memories, 1)
+	assert.Equal(t, "passphrase protected memory", memories[0].Content)
+
+	status, err := ReadEncryptionStatus(ctx, reopened.db)
+	require.NoError(t, err)
+	assert.Equal(t, EncryptionPassphrase, status.Config.Method)
+	assert.Equal(t, 1, status.Sealed)
+	assert.Zero(t, status.Plaintext)
+}
@@ -0,0 +1,346 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ftsIndex manages the full-text index used for lexical (BM25) recall.
+// FTS5 is preferred; if the SQLite build lacks it (mattn/go-sqlite3 only
+// enables FTS5 with the sqlite_fts5 build tag) the index falls back to FTS4
+// and computes BM25 from matchinfo(). If neither is available all operations
+// are no-ops and lexical recall degrades to vector-only.
+type ftsIndex struct {
+	db        *sql.DB
+	fts5      bool
+	available bool
+}
+
+type ftsResult struct {
+	MemoryID string
+	Score    float64 // BM25 relevance, higher is better
+}
+
+// BM25 parameters (same defaults as SQLite's FTS5 bm25())
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+func newFTSIndex(db *sql.DB) *ftsIndex {
+	fi := &ftsIndex{db: db}
+	if err := fi.ensureSchema(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Full-text search not available, lexical recall disabled: %v\n", err)
+		fi.available = false
+	} else {
+		fi.available = true
+	}
+	return fi
+}
+
+// ftsTriggers keep the index in sync with the memories table.
+var ftsTriggers = map[string]string{
+	"memories_fts_ai": `CREATE TRIGGER IF NOT EXISTS memories_fts_ai AFTER INSERT ON memories BEGIN
+			INSERT INTO memories_fts (content, memory_id) VALUES (new.content, new.id);
+		END`,
+	"memories_fts_ad": `CREATE TRIGGER IF NOT EXISTS memories_fts_ad AFTER DELETE ON memories BEGIN
+			DELETE FROM memories_fts WHERE memory_id = old.id;
+		END`,
+	"memories_fts_au": `CREATE TRIGGER IF NOT EXISTS memories_fts_au AFTER UPDATE OF content ON memories BEGIN
+			DELETE FROM memories_fts WHERE memory_id = old.id;
+			INSERT INTO memories_fts (content, memory_id) VALUES (new.content, new.id);
+		END`,
+}
+
+func (fi *ftsIndex) ensureSchema() error {
+	// Prefer FTS5, then FTS4
+	module := "fts5"
+	if _, err := fi.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS temp.memories_fts_probe USING fts5(content)`); err != nil {
+		module = "fts4"
+		if _, err4 := fi.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS temp.memories_fts_probe USING fts4(content)`); err4 != nil {
+			// Triggers left by a build that had FTS would make every write fail
+			_ = fi.dropTable()
+			return fmt.Errorf("fts5: %v; fts4: %w", err, err4)
+		}
+	}
+	_, _ = fi.db.Exec(`DROP TABLE IF EXISTS temp.memories_fts_probe`)
+	fi.fts5 = module == "fts5"
+
+	// A database written by a build with a different FTS module (e.g. one built with
+	// the sqlite_fts5 tag) is rebuilt with ours; Backfill then repopulates it.
+	var existing string
+	err := fi.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'memories_fts'`).Scan(&existing)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read fts schema: %w", err)
+	}
+	if existing != "" && ftsModule(existing) != module {
+		fmt.Fprintf(os.Stderr, "🔍 Rebuilding full-text index with %s (was %s)\n", module, ftsModule(existing))
+		if err := fi.dropTable(); err != nil {
+			return fmt.Errorf("failed to drop %s table: %w", ftsModule(existing), err)
+		}
+	}
+
+	// memory_id is stored but not tokenized
+	create := `CREATE VIRTUAL TABLE IF NOT EXISTS memories_fts USING fts5(content, memory_id UNINDEXED)`
+	if !fi.fts5 {
+		create = `CREATE VIRTUAL TABLE IF NOT EXISTS memories_fts USING fts4(content, memory_id, notindexed=memory_id)`
+	}
+	if _, err := fi.db.Exec(create); err != nil {
+		return fmt.Errorf("failed to create fts table: %w", err)
+	}
+	for _, t := range ftsTriggers {
+		if _, err := fi.db.Exec(t); err != nil {
+			return fmt.Errorf("failed to create fts trigger: %w", err)
+		}
+	}
+	return nil
+}
+
+// ftsModule returns the module named in a CREATE VIRTUAL TABLE statement ("fts5", "fts4").
+func ftsModule(createSQL string) string {
+	lower := strings.ToLower(createSQL)
+	i := strings.Index(lower, " using ")
+	if i < 0 {
+		return ""
+	}
+	module, _, _ := strings.Cut(strings.TrimSpace(lower[i+len(" using "):]), "(")
+	return strings.TrimSpace(module)
+}
+
+// dropTable removes the index table and its triggers. SQLite can't drop a virtual
+// table whose module isn't compiled in, so in that case its schema entry is deleted
+// directly and the shadow tables it left behind are dropped as plain tables.
+func (fi *ftsIndex) dropTable() error {
+	for name := range ftsTriggers {
+		if _, err := fi.db.Exec(`DROP TRIGGER IF EXISTS ` + name); err != nil {
+			return err
+		}
+	}
+	if _, err := fi.db.Exec(`DROP TABLE IF EXISTS memories_fts`); err == nil {
+		return nil
+	}
+
+	// writable_schema is per connection, so pin one
+	ctx := context.Background()
+	conn, err := fi.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var version int
+	if err := conn.QueryRowContext(ctx, `PRAGMA schema_version`).Scan(&version); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `PRAGMA writable_schema = ON`); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, `DELETE FROM sqlite_master WHERE type = 'table' AND name = 'memories_fts'`)
+	_, _ = conn.ExecContext(ctx, `PRAGMA writable_schema = OFF`)
+	if err != nil {
+		return err
+	}
+	// Bumping the schema version makes every connection reload the schema
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`PRAGMA schema_version = %d`, version+1)); err != nil {
+		return err
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name LIKE 'memories\_fts\_%' ESCAPE '\'`)
+	if err != nil {
+		return err
+	}
+	var shadows []string
+	for rows.Next() {
+		var name string
+		if rows.Scan(&name) == nil {
+			shadows = append(shadows, name)
+		}
+	}
+	rows.Close()
+	for _, name := range shadows {
+		if _, err := conn.ExecContext(ctx, `DROP TABLE IF EXISTS "`+name+`"`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backfill indexes memories that predate the FTS table.
+// Returns the number of memories backfilled.
+func (fi *ftsIndex) Backfill() (int, error) {
+	if !fi.available {
+		return 0, nil
+	}
+
+	var ftsCount, memCount int
+	fi.db.QueryRow(`SELECT COUNT(*) FROM memories_fts`).Scan(&ftsCount)
+	fi.db.QueryRow(`SELECT COUNT(*) FROM memories`).Scan(&memCount)
+	if ftsCount >= memCount {
+		return 0, nil
+	}
+
+	result, err := fi.db.Exec(`
+		INSERT INTO memories_fts (content, memory_id)
+		SELECT m.content, m.id FROM memories m
+		WHERE m.id NOT IN (SELECT memory_id FROM memories_fts)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// Search runs a BM25-ranked full-text query and returns memory IDs, best first.
+func (fi *ftsIndex) Search(query string, limit int) ([]ftsResult, error) {
+	if !fi.available {
+		return nil, fmt.Errorf("fts index not available")
+	}
+	match := buildFTSQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	if fi.fts5 {
+		return fi.searchFTS5(match, limit)
+	}
+	return fi.searchFTS4(match, limit)
+}
+
+func (fi *ftsIndex) searchFTS5(match string, limit int) ([]ftsResult, error) {
+	// bm25() returns lower-is-better scores; negate so higher is better
+	rows, err := fi.db.Query(`
+		SELECT memory_id, -bm25(memories_fts)
+		FROM memories_fts
+		WHERE memories_fts MATCH ?
+		ORDER BY bm25(memories_fts)
+		LIMIT ?
+	`, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ftsResult
+	for rows.Next() {
+		var r ftsResult
+		if err := rows.Scan(&r.MemoryID, &r.Score); err != nil {
+			continue
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func (fi *ftsIndex) searchFTS4(match string, limit int) ([]ftsResult, error) {
+	rows, err := fi.db.Query(`
+		SELECT memory_id, matchinfo(memories_fts, 'pcnalx')
+		FROM memories_fts
+		WHERE memories_fts MATCH ?
+	`, match)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ftsResult
+	for rows.Next() {
+		var r ftsResult
+		var info []byte
+		if err := rows.Scan(&r.MemoryID, &info); err != nil {
+			continue
+		}
+		r.Score = bm25FromMatchinfo(info)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortFTSResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// bm25FromMatchinfo computes a BM25 score for the first (content) column
+// from an FTS4 matchinfo 'pcnalx' blob.
+func bm25FromMatchinfo(info []byte) float64 {
+	if len(info)%4 != 0 || len(info) < 12 {
+		return 0
+	}
+	vals := make([]uint32, len(info)/4)
+	for i := range vals {
+		vals[i] = binary.NativeEndian.Uint32(info[i*4:])
+	}
+
+	phrases := int(vals[0])
+	cols := int(vals[1])
+	docs := float64(vals[2])
+	// Layout: p, c, n, a[c], l[c], x[3*c*p]
+	avgIdx := 3
+	lenIdx := avgIdx + cols
+	hitIdx := lenIdx + cols
+	if len(vals) < hitIdx+3*cols*phrases {
+		return 0
+	}
+
+	avgLen := float64(vals[avgIdx])
+	docLen := float64(vals[lenIdx])
+	if avgLen <= 0 {
+		avgLen = 1
+	}
+
+	score := 0.0
+	for p := 0; p < phrases; p++ {
+		x := hitIdx + 3*(p*cols) // column 0 only
+		tf := float64(vals[x])
+		df := float64(vals[x+2])
+		if tf == 0 {
+			continue
+		}
+		idf := math.Log((docs - df + 0.5) / (df + 0.5))
+		if idf < 1e-6 {
+			idf = 1e-6 // same floor FTS5 uses so common terms still count
+		}
+		score += idf * (tf * (bm25K1 + 1)) / (tf + bm25K1*(1-bm25B+bm25B*docLen/avgLen))
+	}
+	return score
+}
+
+func sortFTSResults(results []ftsResult) {
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+}
+
+// buildFTSQuery turns free text into a MATCH expression. Each whitespace-separated
+// term is quoted as a phrase so identifiers like ERR_TOKEN_EXPIRED or
+// RateLimiter.Allow match their token sequence, and terms are OR-ed so BM25
+// ranks documents by how many terms they contain.
+func buildFTSQuery(query string) string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Fields(query) {
+		field = strings.ReplaceAll(field, `"`, "")
+		field = strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if field == "" {
+			continue
+		}
+		key := strings.ToLower(field)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, `"`+field+`"`)
+	}
+	return strings.Join(terms, " OR ")
+}
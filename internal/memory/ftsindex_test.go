@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedIdentifierMemories(t *testing.T, store *Store) *Memory {
+	t.Helper()
+	ctx := context.Background()
+	for _, content := range []string{
+		"Tokens expire after a while and the client has to log in again",
+		"Authentication errors should be surfaced to the user with a friendly message",
+		"The session token is refreshed in the background before it expires",
+		"We log every expired session for auditing purposes",
+	} {
+		_, err := store.Remember(ctx, content, nil, "")
+		require.NoError(t, err)
+	}
+	target, err := store.Remember(ctx, "Return ERR_TOKEN_EXPIRED from RateLimiter.Allow when the JWT is stale", []string{"api"}, "")
+	require.NoError(t, err)
+	return target
+}
+
+func TestFTSIndex_Available(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	require.NotNil(t, store.ftsIdx)
+	assert.True(t, store.ftsIdx.available)
+}
+
+func TestRecall_LexicalModeFindsIdentifier(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	target := seedIdentifierMemories(t, store)
+
+	results, err := store.RecallWithRecencyBoost(context.Background(), "ERR_TOKEN_EXPIRED", 3, RecallOptions{Mode: RecallModeLexical})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, target.ID, results[0].ID)
+}
+
+func TestRecall_HybridModeFindsIdentifier(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	target := seedIdentifierMemories(t, store)
+
+	results, err := store.RecallWithRecencyBoost(context.Background(), "RateLimiter.Allow", 3, RecallOptions{Mode: RecallModeHybrid})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, target.ID, results[0].ID)
+	assert.Greater(t, results[0].Similarity, 0.0)
+}
+
+func TestFTSIndex_ForgetRemovesFromIndex(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	target := seedIdentifierMemories(t, store)
+
+	require.NoError(t, store.Forget(context.Background(), target.ID))
+
+	results, err := store.ftsIdx.Search("ERR_TOKEN_EXPIRED", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestFTSIndex_Backfill(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	target := seedIdentifierMemories(t, store)
+
+	// Simulate a database that predates the full-text index
+	_, err := store.db.Exec(`DELETE FROM memories_fts`)
+	require.NoError(t, err)
+
+	n, err := store.ftsIdx.Backfill()
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	results, err := store.ftsIdx.Search("ERR_TOKEN_EXPIRED", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, target.ID, results[0].MemoryID)
+}
+
+func TestFTSIndex_RebuildsForAvailableModule(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	store := openStoreAt(t, dir)
+	target := seedIdentifierMemories(t, store)
+	module := "fts4"
+	if store.ftsIdx.fts5 {
+		module = "fts5"
+	}
+
+	// Leave the table behind as a build with the other FTS module would
+	require.NoError(t, store.ftsIdx.dropTable())
+	if store.ftsIdx.fts5 {
+		_, err := store.db.Exec(`CREATE VIRTUAL TABLE memories_fts USING fts4(content, memory_id, notindexed=memory_id)`)
+		require.NoError(t, err)
+	} else {
+		// FTS5 isn't compiled in, so plant its schema entry directly
+		conn, err := store.db.Conn(ctx)
+		require.NoError(t, err)
+		for _, stmt := range []string{
+			`PRAGMA writable_schema = ON`,
+			`INSERT INTO sqlite_master (type, name, tbl_name, rootpage, sql) VALUES ('table', 'memories_fts', 'memories_fts', 0, 'CREATE VIRTUAL TABLE memories_fts USING fts5(content, memory_id UNINDEXED)')`,
+			`PRAGMA writable_schema = OFF`,
+		} {
+			_, err := conn.ExecContext(ctx, stmt)
+			require.NoError(t, err)
+		}
+		require.NoError(t, conn.Close())
+	}
+	for _, trigger := range ftsTriggers {
+		_, err := store.db.Exec(trigger)
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.Close())
+
+	store = openStoreAt(t, dir)
+	defer store.Close()
+	assert.True(t, store.ftsIdx.available)
+	var createSQL string
+	require.NoError(t, store.db.QueryRow(`SELECT sql FROM sqlite_master WHERE name = 'memories_fts'`).Scan(&createSQL))
+	assert.Equal(t, module, ftsModule(createSQL))
+
+	added, err := store.Remember(ctx, "Retry ERR_TOKEN_EXPIRED once after refreshing the session", nil, "")
+	require.NoError(t, err, "writes must not hit triggers for a missing module")
+	results, err := store.ftsIdx.Search("ERR_TOKEN_EXPIRED", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.ElementsMatch(t, []string{target.ID, added.ID}, []string{results[0].MemoryID, results[1].MemoryID})
+}
+
+func TestBuildFTSQuery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"ERR_TOKEN_EXPIRED", `"ERR_TOKEN_EXPIRED"`},
+		{"RateLimiter.Allow()", `"RateLimiter.Allow"`},
+		{`jwt "expiry" jwt`, `"jwt" OR "expiry"`},
+		{"  ?? ", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, buildFTSQuery(tt.input), "input %q", tt.input)
+	}
+}
+
+func TestParseRecallMode(t *testing.T) {
+	mode, err := ParseRecallMode("")
+	require.NoError(t, err)
+	assert.Equal(t, RecallModeVector, mode)
+
+	mode, err = ParseRecallMode("Hybrid")
+	require.NoError(t, err)
+	assert.Equal(t, RecallModeHybrid, mode)
+
+	_, err = ParseRecallMode("fuzzy")
+	assert.Error(t, err)
+}
@@ -0,0 +1,200 @@
+// Package gosym resolves Go symbols in source files for symbol-level citations.
+// A symbol is found with go/parser and fingerprinted with a hash of its syntax tree
+// that ignores positions, whitespace and comments, so reformatting a declaration
+// keeps its hash while changing what it does does not.
+package gosym
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// Kinds of symbols reported in Location.Kind
+const (
+	KindPackage = "package"
+	KindType    = "type"
+	KindFunc    = "func"
+	KindMethod  = "method"
+	KindValue   = "value" // const or var
+)
+
+// Location is where a symbol is declared in a file
+type Location struct {
+	Symbol    string // Normalized name, e.g. "RateLimiter.Allow"
+	Kind      string
+	StartLine int // 1-indexed, inclusive; doc comments are not included
+	EndLine   int
+	Source    string // Text of lines StartLine..EndLine
+	Hash      string // Normalized AST hash (hex SHA-256)
+}
+
+// IsGoFile reports whether path names a Go source file
+func IsGoFile(path string) bool {
+	return strings.HasSuffix(path, ".go")
+}
+
+// Normalize turns the accepted spellings of a method ("(*T).M", "*T.M", "T.M")
+// into "T.M" and trims whitespace.
+func Normalize(symbol string) string {
+	symbol = strings.TrimSpace(symbol)
+	symbol = strings.NewReplacer("(", "", ")", "", "*", "").Replace(symbol)
+	return symbol
+}
+
+// Resolve finds symbol in the Go source src. symbol is one of:
+//   - the file's package name, for the whole file
+//   - a type, func, const or var name ("RateLimiter", "NewLimiter")
+//   - a method as "Type.Method" or "(*Type).Method"
+//
+// A leading package qualifier matching the file's package ("ratelimit.NewLimiter") is accepted.
+func Resolve(filename string, src []byte, symbol string) (*Location, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	name := Normalize(symbol)
+	if name == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	lines := strings.Split(string(src), "\n")
+	locate := func(kind string, node ast.Node, from, to token.Pos) *Location {
+		start, end := fset.Position(from).Line, fset.Position(to).Line
+		return &Location{
+			Symbol:    name,
+			Kind:      kind,
+			StartLine: start,
+			EndLine:   end,
+			Source:    strings.Join(lines[start-1:end], "\n"),
+			Hash:      Hash(node),
+		}
+	}
+
+	if name == file.Name.Name {
+		return locate(KindPackage, file, file.Package, file.End()), nil
+	}
+
+	recv, member, isMember := strings.Cut(name, ".")
+	if isMember && recv == file.Name.Name {
+		name, isMember = member, false
+		if m, rest, ok := strings.Cut(member, "."); ok {
+			recv, member, isMember = m, rest, true
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			switch {
+			case isMember && d.Recv != nil && receiverName(d.Recv) == recv && d.Name.Name == member:
+				return locate(KindMethod, d, d.Pos(), d.End()), nil
+			case !isMember && d.Recv == nil && d.Name.Name == name:
+				return locate(KindFunc, d, d.Pos(), d.End()), nil
+			}
+		case *ast.GenDecl:
+			if isMember {
+				continue
+			}
+			for _, spec := range d.Specs {
+				from, to := spec.Pos(), spec.End()
+				if !d.Lparen.IsValid() {
+					from, to = d.Pos(), d.End() // Include the keyword of an ungrouped declaration
+				}
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					if sp.Name.Name == name {
+						return locate(KindType, sp, from, to), nil
+					}
+				case *ast.ValueSpec:
+					for _, n := range sp.Names {
+						if n.Name == name {
+							return locate(KindValue, sp, from, to), nil
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("symbol %q not found in %s", symbol, filename)
+}
+
+// receiverName returns the type name of a method receiver, without pointer or type parameters
+func receiverName(recv *ast.FieldList) string {
+	if recv == nil || len(recv.List) == 0 {
+		return ""
+	}
+	expr := recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// Hash returns a hex SHA-256 of node's syntax tree: node types, identifiers, literals
+// and operators in order. Positions and comments are ignored, so gofmt-style changes
+// and comment edits leave the hash unchanged.
+func Hash(node ast.Node) string {
+	var b strings.Builder
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			b.WriteByte(')')
+			return true
+		}
+		switch x := n.(type) {
+		case *ast.CommentGroup, *ast.Comment:
+			return false
+		case *ast.Ident:
+			fmt.Fprintf(&b, "(Ident %s", x.Name)
+			return true
+		case *ast.BasicLit:
+			fmt.Fprintf(&b, "(Lit %s %s", x.Kind, x.Value)
+			return true
+		case *ast.BinaryExpr:
+			fmt.Fprintf(&b, "(Binary %s", x.Op)
+			return true
+		case *ast.UnaryExpr:
+			fmt.Fprintf(&b, "(Unary %s", x.Op)
+			return true
+		case *ast.AssignStmt:
+			fmt.Fprintf(&b, "(Assign %s", x.Tok)
+			return true
+		case *ast.IncDecStmt:
+			fmt.Fprintf(&b, "(IncDec %s", x.Tok)
+			return true
+		case *ast.BranchStmt:
+			fmt.Fprintf(&b, "(Branch %s", x.Tok)
+			return true
+		case *ast.RangeStmt:
+			fmt.Fprintf(&b, "(Range %s", x.Tok)
+			return true
+		case *ast.GenDecl:
+			fmt.Fprintf(&b, "(GenDecl %s", x.Tok)
+			return true
+		case *ast.ChanType:
+			fmt.Fprintf(&b, "(Chan %d", x.Dir)
+			return true
+		}
+		fmt.Fprintf(&b, "(%T", n)
+		return true
+	})
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,90 @@
+package gosym
+
+import (
+	"strings"
+	"testing"
+)
+
+const limiterSrc = `package ratelimit
+
+// RateLimiter allows n requests per window.
+type RateLimiter struct {
+	n int
+}
+
+const (
+	DefaultRate = 100
+	MaxRate     = 1000
+)
+
+// NewLimiter returns a limiter for n requests.
+func NewLimiter(n int) *RateLimiter {
+	return &RateLimiter{n: n}
+}
+
+// Allow reports whether another request fits.
+func (r *RateLimiter) Allow(used int) bool {
+	return used < r.n
+}
+`
+
+func TestResolve_Symbols(t *testing.T) {
+	tests := []struct {
+		symbol     string
+		kind       string
+		start, end int
+	}{
+		{"RateLimiter", KindType, 4, 6},
+		{"NewLimiter", KindFunc, 14, 16},
+		{"ratelimit.NewLimiter", KindFunc, 14, 16},
+		{"RateLimiter.Allow", KindMethod, 19, 21},
+		{"(*RateLimiter).Allow", KindMethod, 19, 21},
+		{"MaxRate", KindValue, 10, 10},
+		{"ratelimit", KindPackage, 1, 21},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			loc, err := Resolve("limiter.go", []byte(limiterSrc), tt.symbol)
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if loc.Kind != tt.kind || loc.StartLine != tt.start || loc.EndLine != tt.end {
+				t.Errorf("got %s %d-%d, want %s %d-%d", loc.Kind, loc.StartLine, loc.EndLine, tt.kind, tt.start, tt.end)
+			}
+			if loc.Hash == "" {
+				t.Error("expected a hash")
+			}
+		})
+	}
+}
+
+func TestResolve_NotFound(t *testing.T) {
+	for _, symbol := range []string{"Missing", "RateLimiter.Deny", "NewLimiter.Allow", ""} {
+		if _, err := Resolve("limiter.go", []byte(limiterSrc), symbol); err == nil {
+			t.Errorf("expected error for %q", symbol)
+		}
+	}
+	if _, err := Resolve("broken.go", []byte("package x\nfunc {"), "x"); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestResolve_HashIgnoresFormattingAndComments(t *testing.T) {
+	original, _ := Resolve("limiter.go", []byte(limiterSrc), "RateLimiter.Allow")
+
+	reformatted := strings.Replace(limiterSrc, "func (r *RateLimiter) Allow(used int) bool {\n\treturn used < r.n\n}",
+		"func (r *RateLimiter) Allow(used int) bool {\n\t// Strictly below the limit\n\treturn used<r.n\n\n}", 1)
+	loc, err := Resolve("limiter.go", []byte(reformatted), "RateLimiter.Allow")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if loc.Hash != original.Hash {
+		t.Error("formatting and comment changes should not change the hash")
+	}
+
+	changed := strings.Replace(limiterSrc, "return used < r.n", "return used <= r.n", 1)
+	loc, _ = Resolve("limiter.go", []byte(changed), "RateLimiter.Allow")
+	if loc.Hash == original.Hash {
+		t.Error("changing an operator should change the hash")
+	}
+}
@@ -0,0 +1,580 @@
+// Package memory: in-process HNSW vector index.
+// When the sqlite-vec extension cannot be loaded, vecIndex keeps its vectors in an
+// hnswIndex instead: a pure-Go Hierarchical Navigable Small World graph (Malkov and
+// Yashunin, 2016) answering approximate KNN queries in logarithmic time, where the
+// linear scan decodes and compares every stored embedding. The graph is updated by
+// Insert and Delete as memories change and saved to vectors.hnsw in the data
+// directory on Close. At startup it is reconciled with the memories table, so a
+// process that exited without saving only costs re-inserting what changed.
+
+package memory
+
+import (
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	hnswFileName       = "vectors.hnsw"
+	hnswFileVersion    = 1
+	hnswM              = 16  // Neighbors per node and layer; layer 0 keeps 2*hnswM
+	hnswEfConstruction = 100 // Candidate list size while inserting
+	hnswEfSearch       = 64  // Minimum candidate list size while searching
+)
+
+// hnswNode is a vector in the graph. Deleted nodes stay in the graph as waypoints
+// until the next compaction but are never returned.
+type hnswNode struct {
+	ID        string
+	Vector    []float32 // Unit length, so cosine distance is 1 - dot product
+	Neighbors [][]int32 // Per layer, from 0 up to the node's level
+	Deleted   bool
+}
+
+// hnswIndex is an HNSW graph over the vectors of one embedding model
+type hnswIndex struct {
+	mu         sync.RWMutex
+	path       string // "" keeps the index in memory only
+	dimensions int
+	model      string
+	nodes      []*hnswNode
+	ids        map[string]int32 // Live nodes by memory ID
+	entry      int32            // Entry point on the top layer; -1 when empty
+	maxLevel   int
+	deleted    int
+	dirty      bool
+	rng        *rand.Rand
+}
+
+// hnswFile is the on-disk form of an hnswIndex
+type hnswFile struct {
+	Version    int
+	Model      string
+	Dimensions int
+	Entry      int32
+	MaxLevel   int
+	Nodes      []*hnswNode
+}
+
+func newHNSWIndex(path string, dimensions int, model string) *hnswIndex {
+	return &hnswIndex{
+		path:       path,
+		dimensions: dimensions,
+		model:      model,
+		ids:        make(map[string]int32),
+		entry:      -1,
+		rng:        rand.New(rand.NewSource(1)),
+	}
+}
+
+// loadHNSWIndex opens the index saved in dataDir, or starts an empty one when there is
+// none or it was built for another embedding model
+func loadHNSWIndex(dataDir string, dimensions int, model string) *hnswIndex {
+	if dataDir == "" {
+		return newHNSWIndex("", dimensions, model)
+	}
+	h := newHNSWIndex(filepath.Join(dataDir, hnswFileName), dimensions, model)
+	f, err := os.Open(h.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "⚠️  Failed to open HNSW index, rebuilding: %v\n", err)
+		}
+		return h
+	}
+	defer f.Close()
+
+	var saved hnswFile
+	if err := gob.NewDecoder(f).Decode(&saved); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to read HNSW index, rebuilding: %v\n", err)
+		return h
+	}
+	switch {
+	case saved.Version != hnswFileVersion:
+		return h
+	case saved.Dimensions != dimensions || saved.Model != model:
+		fmt.Fprintf(os.Stderr, "⚠️  Embedding model changed (%s -> %s), rebuilding HNSW index\n", saved.Model, model)
+		h.dirty = true // Replace the stale file even if no memory uses the new model yet
+		return h
+	}
+	h.nodes, h.entry, h.maxLevel = saved.Nodes, saved.Entry, saved.MaxLevel
+	for i, node := range h.nodes {
+		if node.Deleted {
+			h.deleted++
+		} else {
+			h.ids[node.ID] = int32(i)
+		}
+	}
+	return h
+}
+
+// Insert adds or replaces a memory's vector
+func (h *hnswIndex) Insert(memoryID string, embedding []float32) {
+	vec := unitVector(embedding)
+	if vec == nil {
+		h.Delete(memoryID)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if old, ok := h.ids[memoryID]; ok {
+		h.markDeleted(old)
+	}
+	h.insert(memoryID, vec)
+	h.maybeCompact()
+}
+
+// Delete removes a memory's vector
+func (h *hnswIndex) Delete(memoryID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if old, ok := h.ids[memoryID]; ok {
+		h.markDeleted(old)
+		h.maybeCompact()
+	}
+}
+
+// Len returns the number of live vectors
+func (h *hnswIndex) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.ids)
+}
+
+// Search returns up to limit memories nearest to query, by cosine distance
+func (h *hnswIndex) Search(query []float32, limit int) []vecResult {
+	q := unitVector(query)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if q == nil || len(q) != h.dimensions || h.entry < 0 || limit <= 0 {
+		return nil
+	}
+
+	ep := h.entry
+	for layer := h.maxLevel; layer > 0; layer-- {
+		ep = h.greedyClosest(q, ep, layer)
+	}
+	found := h.searchLayer(q, []int32{ep}, max(hnswEfSearch, limit), 0, true)
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	results := make([]vecResult, len(found))
+	for i, c := range found {
+		results[i] = vecResult{MemoryID: h.nodes[c.node].ID, Distance: float64(c.dist)}
+	}
+	return results
+}
+
+// Save writes the index to its file if it changed since it was loaded or last saved
+func (h *hnswIndex) Save() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.path == "" || !h.dirty {
+		return nil
+	}
+	tmp := h.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to save HNSW index: %w", err)
+	}
+	saved := hnswFile{
+		Version:    hnswFileVersion,
+		Model:      h.model,
+		Dimensions: h.dimensions,
+		Entry:      h.entry,
+		MaxLevel:   h.maxLevel,
+		Nodes:      h.nodes,
+	}
+	if err := gob.NewEncoder(f).Encode(&saved); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save HNSW index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save HNSW index: %w", err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return fmt.Errorf("failed to save HNSW index: %w", err)
+	}
+	h.dirty = false
+	return nil
+}
+
+// Backfill reconciles the index with the memories embedded with its model: vectors of
+// deleted or re-embedded memories are dropped and missing ones inserted. Returns the
+// number of memories inserted.
+func (h *hnswIndex) Backfill(db *sql.DB) (int, error) {
+	rows, err := db.Query(`SELECT id FROM memories WHERE embedding_model = ?`, h.model)
+	if err != nil {
+		return 0, err
+	}
+	live := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			live[id] = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	h.mu.Lock()
+	for id, node := range h.ids {
+		if !live[id] {
+			h.markDeleted(node)
+		}
+	}
+	var missing []string
+	for id := range live {
+		if _, ok := h.ids[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	h.maybeCompact()
+	h.mu.Unlock()
+	sort.Strings(missing) // Reproducible graphs
+	if len(missing) >= 1000 {
+		fmt.Fprintf(os.Stderr, "🔍 Adding %d memories to the HNSW index...\n", len(missing))
+	}
+
+	count := 0
+	const chunk = 500
+	for start := 0; start < len(missing); start += chunk {
+		ids := missing[start:min(start+chunk, len(missing))]
+		args := make([]interface{}, len(ids))
+		for i, id := range ids {
+			args[i] = id
+		}
+		rows, err := db.Query(`SELECT id, embedding FROM memories WHERE id IN (`+
+			strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")+`)`, args...)
+		if err != nil {
+			return count, err
+		}
+		for rows.Next() {
+			var id string
+			var embeddingBlob []byte
+			if rows.Scan(&id, &embeddingBlob) != nil {
+				continue
+			}
+			embedding, err := decodeEmbedding(embeddingBlob)
+			if err != nil || len(embedding) != h.dimensions {
+				continue
+			}
+			h.Insert(id, embedding)
+			count++
+		}
+		rows.Close()
+	}
+
+	if h.dirty {
+		if err := h.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		}
+	}
+	return count, nil
+}
+
+// insert adds a unit vector as a new node; the caller holds the lock
+func (h *hnswIndex) insert(memoryID string, vec []float32) {
+	level := h.randomLevel()
+	idx := int32(len(h.nodes))
+	node := &hnswNode{ID: memoryID, Vector: vec, Neighbors: make([][]int32, level+1)}
+	h.nodes = append(h.nodes, node)
+	h.ids[memoryID] = idx
+	h.dirty = true
+	if h.entry < 0 {
+		h.entry, h.maxLevel = idx, level
+		return
+	}
+
+	ep := h.entry
+	for layer := h.maxLevel; layer > level; layer-- {
+		ep = h.greedyClosest(vec, ep, layer)
+	}
+	eps := []int32{ep}
+	for layer := min(level, h.maxLevel); layer >= 0; layer-- {
+		candidates := h.searchLayer(vec, eps, hnswEfConstruction, layer, false)
+		node.Neighbors[layer] = h.selectNeighbors(candidates, hnswMaxNeighbors(layer))
+		for _, nb := range node.Neighbors[layer] {
+			h.link(nb, idx, layer)
+		}
+		eps = eps[:0]
+		for _, c := range candidates {
+			eps = append(eps, c.node)
+		}
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = idx, level
+	}
+}
+
+// link adds to as a neighbor of from on layer. Neighbor lists may grow half again
+// past the limit before they are pruned back to it with the heuristic, so the pruning
+// cost is shared by many inserts.
+func (h *hnswIndex) link(from, to int32, layer int) {
+	node := h.nodes[from]
+	node.Neighbors[layer] = append(node.Neighbors[layer], to)
+	limit := hnswMaxNeighbors(layer)
+	if len(node.Neighbors[layer]) <= limit+limit/2 {
+		return
+	}
+	candidates := make([]hnswCandidate, len(node.Neighbors[layer]))
+	for i, nb := range node.Neighbors[layer] {
+		candidates[i] = hnswCandidate{node: nb, dist: cosineDistance(node.Vector, h.nodes[nb].Vector)}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+	node.Neighbors[layer] = h.selectNeighbors(candidates, limit)
+}
+
+// selectNeighbors picks up to m of the candidates (sorted by distance) with the HNSW
+// heuristic: a candidate closer to an already selected neighbor than to the base is
+// skipped, which keeps links spread across directions. Skipped candidates fill any
+// remaining slots.
+func (h *hnswIndex) selectNeighbors(candidates []hnswCandidate, m int) []int32 {
+	selected := make([]int32, 0, m)
+	var skipped []int32
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		keep := true
+		for _, s := range selected {
+			if cosineDistance(h.nodes[c.node].Vector, h.nodes[s].Vector) < c.dist {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c.node)
+		} else {
+			skipped = append(skipped, c.node)
+		}
+	}
+	for _, s := range skipped {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, s)
+	}
+	return selected
+}
+
+// greedyClosest walks layer from ep towards q and returns the closest node it reaches
+func (h *hnswIndex) greedyClosest(q []float32, ep int32, layer int) int32 {
+	best := cosineDistance(q, h.nodes[ep].Vector)
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range h.nodes[ep].Neighbors[layer] {
+			if d := cosineDistance(q, h.nodes[nb].Vector); d < best {
+				best, ep, changed = d, nb, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns up to ef nodes of layer nearest to q, closest first. With
+// liveOnly, deleted nodes are traversed but not returned.
+func (h *hnswIndex) searchLayer(q []float32, eps []int32, ef int, layer int, liveOnly bool) []hnswCandidate {
+	visited := hnswVisitedPool.Get().(*hnswVisited)
+	defer hnswVisitedPool.Put(visited)
+	visited.reset(len(h.nodes))
+
+	candidates := hnswHeap{}            // Closest first
+	results := hnswHeap{farthest: true} // Farthest first, so the worst is dropped
+	for _, ep := range eps {
+		visited.visit(ep)
+		c := hnswCandidate{node: ep, dist: cosineDistance(q, h.nodes[ep].Vector)}
+		candidates.push(c)
+		if !liveOnly || !h.nodes[ep].Deleted {
+			results.push(c)
+		}
+	}
+
+	for len(candidates.items) > 0 {
+		c := candidates.pop()
+		if len(results.items) >= ef && c.dist > results.items[0].dist {
+			break
+		}
+		for _, nb := range h.nodes[c.node].Neighbors[layer] {
+			if !visited.visit(nb) {
+				continue
+			}
+			d := cosineDistance(q, h.nodes[nb].Vector)
+			if len(results.items) < ef || d < results.items[0].dist {
+				candidates.push(hnswCandidate{node: nb, dist: d})
+				if liveOnly && h.nodes[nb].Deleted {
+					continue
+				}
+				results.push(hnswCandidate{node: nb, dist: d})
+				if len(results.items) > ef {
+					results.pop()
+				}
+			}
+		}
+	}
+
+	found := make([]hnswCandidate, len(results.items))
+	for i := len(found) - 1; i >= 0; i-- {
+		found[i] = results.pop()
+	}
+	return found
+}
+
+// markDeleted takes a node out of the results; the caller holds the lock
+func (h *hnswIndex) markDeleted(idx int32) {
+	node := h.nodes[idx]
+	node.Deleted = true
+	delete(h.ids, node.ID)
+	h.deleted++
+	h.dirty = true
+}
+
+// maybeCompact rebuilds the graph from the live nodes once deleted nodes make up a
+// quarter of it, since they slow searches down; the caller holds the lock
+func (h *hnswIndex) maybeCompact() {
+	if h.deleted == 0 || h.deleted*4 < len(h.nodes) {
+		return
+	}
+	old := h.nodes
+	h.nodes, h.ids, h.entry, h.maxLevel, h.deleted = nil, make(map[string]int32, len(old)-h.deleted), -1, 0, 0
+	for _, node := range old {
+		if !node.Deleted {
+			h.insert(node.ID, node.Vector)
+		}
+	}
+	h.dirty = true
+}
+
+// randomLevel draws a node's top layer from the exponentially decaying distribution
+func (h *hnswIndex) randomLevel() int {
+	return int(math.Floor(-math.Log(1-h.rng.Float64()) / math.Log(hnswM)))
+}
+
+func hnswMaxNeighbors(layer int) int {
+	if layer == 0 {
+		return 2 * hnswM
+	}
+	return hnswM
+}
+
+// unitVector returns a normalized copy of v, or nil for an empty or zero vector
+func unitVector(v []float32) []float32 {
+	if len(v) == 0 || isZeroVector(v) {
+		return nil
+	}
+	u := make([]float32, len(v))
+	copy(u, v)
+	normalize(u)
+	return u
+}
+
+// cosineDistance is 1 - cosine similarity of two unit vectors, as sqlite-vec reports it
+func cosineDistance(a, b []float32) float32 {
+	b = b[:len(a)]
+	var d0, d1, d2, d3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		d0 += a[i] * b[i]
+		d1 += a[i+1] * b[i+1]
+		d2 += a[i+2] * b[i+2]
+		d3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		d0 += a[i] * b[i]
+	}
+	return 1 - (d0 + d1 + d2 + d3)
+}
+
+type hnswCandidate struct {
+	node int32
+	dist float32
+}
+
+// hnswHeap is a binary heap of candidates, closest or farthest on top
+type hnswHeap struct {
+	items    []hnswCandidate
+	farthest bool
+}
+
+func (h *hnswHeap) less(i, j int) bool {
+	if h.farthest {
+		return h.items[i].dist > h.items[j].dist
+	}
+	return h.items[i].dist < h.items[j].dist
+}
+
+func (h *hnswHeap) push(c hnswCandidate) {
+	h.items = append(h.items, c)
+	for i := len(h.items) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if !h.less(i, parent) {
+			break
+		}
+		h.items[i], h.items[parent] = h.items[parent], h.items[i]
+		i = parent
+	}
+}
+
+func (h *hnswHeap) pop() hnswCandidate {
+	top := h.items[0]
+	last := len(h.items) - 1
+	h.items[0] = h.items[last]
+	h.items = h.items[:last]
+	for i := 0; ; {
+		child := 2*i + 1
+		if child >= last {
+			break
+		}
+		if child+1 < last && h.less(child+1, child) {
+			child++
+		}
+		if !h.less(child, i) {
+			break
+		}
+		h.items[i], h.items[child] = h.items[child], h.items[i]
+		i = child
+	}
+	return top
+}
+
+// hnswVisited marks the nodes a search has seen. Marks are stamped with an epoch, so
+// the set is cleared by bumping it; sets are pooled since searches run concurrently.
+type hnswVisited struct {
+	marks []uint32
+	epoch uint32
+}
+
+var hnswVisitedPool = sync.Pool{New: func() interface{} { return &hnswVisited{} }}
+
+func (v *hnswVisited) reset(n int) {
+	if len(v.marks) < n {
+		v.marks = make([]uint32, n+n/4)
+		v.epoch = 0
+	}
+	v.epoch++
+	if v.epoch == 0 { // Wrapped around
+		clear(v.marks)
+		v.epoch = 1
+	}
+}
+
+// visit marks node i and reports whether it was unvisited
+func (v *hnswVisited) visit(i int32) bool {
+	if v.marks[i] == v.epoch {
+		return false
+	}
+	v.marks[i] = v.epoch
+	return true
+}
@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomVectors returns n vectors around a few dozen centers, like embeddings of
+// memories about a handful of topics
+func randomVectors(n, dims int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	centers := make([][]float32, 32)
+	for i := range centers {
+		centers[i] = make([]float32, dims)
+		for j := range centers[i] {
+			centers[i][j] = float32(rng.NormFloat64())
+		}
+	}
+	vecs := make([][]float32, n)
+	for i := range vecs {
+		center := centers[rng.Intn(len(centers))]
+		vecs[i] = make([]float32, dims)
+		for j := range vecs[i] {
+			vecs[i][j] = center[j] + float32(rng.NormFloat64())*0.6
+		}
+	}
+	return vecs
+}
+
+// bruteForce returns the IDs of the limit vectors nearest to q
+func bruteForce(vecs [][]float32, q []float32, limit int) []string {
+	type scored struct {
+		id  string
+		sim float64
+	}
+	all := make([]scored, len(vecs))
+	for i, v := range vecs {
+		all[i] = scored{fmt.Sprintf("m%d", i), cosineSimilarity(q, v)}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].sim > all[j].sim })
+	ids := make([]string, limit)
+	for i := range ids {
+		ids[i] = all[i].id
+	}
+	return ids
+}
+
+func buildHNSW(vecs [][]float32) *hnswIndex {
+	h := newHNSWIndex("", len(vecs[0]), "test:model")
+	for i, v := range vecs {
+		h.Insert(fmt.Sprintf("m%d", i), v)
+	}
+	return h
+}
+
+func TestHNSWIndex_RecallMatchesBruteForce(t *testing.T) {
+	vecs := randomVectors(3000, 64, 1)
+	h := buildHNSW(vecs)
+	require.Equal(t, 3000, h.Len())
+
+	queries := randomVectors(50, 64, 2)
+	hits := 0
+	for _, q := range queries {
+		found := h.Search(q, 10)
+		require.Len(t, found, 10)
+		for i := 1; i < len(found); i++ {
+			assert.LessOrEqual(t, found[i-1].Distance, found[i].Distance, "results should be closest first")
+		}
+		want := make(map[string]bool)
+		for _, id := range bruteForce(vecs, q, 10) {
+			want[id] = true
+		}
+		for _, r := range found {
+			if want[r.MemoryID] {
+				hits++
+			}
+		}
+	}
+	recall := float64(hits) / float64(len(queries)*10)
+	t.Logf("recall@10: %.3f", recall)
+	assert.GreaterOrEqual(t, recall, 0.9, "recall@10 against brute force")
+}
+
+func TestHNSWIndex_UpdatesAndPersistence(t *testing.T) {
+	dir := t.TempDir()
+	vecs := randomVectors(200, 16, 3)
+	h := loadHNSWIndex(dir, 16, "test:model")
+	for i, v := range vecs {
+		h.Insert(fmt.Sprintf("m%d", i), v)
+	}
+
+	// A replaced vector is found under its new value only
+	h.Insert("m0", vecs[1])
+	found := h.Search(vecs[1], 2)
+	require.Len(t, found, 2)
+	assert.ElementsMatch(t, []string{"m0", "m1"}, []string{found[0].MemoryID, found[1].MemoryID})
+	assert.InDelta(t, 0, found[0].Distance, 1e-5)
+
+	h.Delete("m1")
+	h.Delete("unknown")
+	for _, r := range h.Search(vecs[1], 10) {
+		assert.NotEqual(t, "m1", r.MemoryID)
+	}
+	assert.Equal(t, 199, h.Len())
+
+	// Saved and reopened
+	require.NoError(t, h.Save())
+	reopened := loadHNSWIndex(dir, 16, "test:model")
+	assert.Equal(t, 199, reopened.Len())
+	assert.Equal(t, h.Search(vecs[5], 5), reopened.Search(vecs[5], 5))
+
+	// Another model starts over
+	assert.Zero(t, loadHNSWIndex(dir, 16, "test:other").Len())
+	assert.Zero(t, loadHNSWIndex(dir, 8, "test:model").Len())
+
+	// Deleting most vectors compacts the graph
+	for i := 2; i < 150; i++ {
+		reopened.Delete(fmt.Sprintf("m%d", i))
+	}
+	assert.Equal(t, 51, reopened.Len())
+	assert.Less(t, len(reopened.nodes), 100)
+	found = reopened.Search(vecs[160], 1)
+	require.Len(t, found, 1)
+	assert.Equal(t, "m160", found[0].MemoryID)
+}
+
+func TestHNSWIndex_Concurrent(t *testing.T) {
+	vecs := randomVectors(400, 16, 4)
+	h := buildHNSW(vecs[:200])
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 200 + w; i < len(vecs); i += 4 {
+				h.Insert(fmt.Sprintf("m%d", i), vecs[i])
+				h.Search(vecs[i-200], 5)
+				h.Delete(fmt.Sprintf("m%d", i-200))
+			}
+		}(w)
+	}
+	wg.Wait()
+	assert.Equal(t, 200, h.Len())
+}
+
+func TestStore_HNSWIndex(t *testing.T) {
+	t.Setenv("PHLOEM_VECTOR_INDEX", "hnsw")
+	t.Setenv("PHLOEM_AIR_GAPPED", "1")
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	store := openStoreAt(t, dir)
+	require.NotNil(t, store.vecIdx.hnsw)
+	deploys, err := store.Remember(ctx, "Deploys run on Fridays after the standup", nil, "")
+	require.NoError(t, err)
+	_, err = store.Remember(ctx, "The staging database is rebuilt nightly", nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.vecIdx.hnsw.Len())
+
+	results, err := store.Recall(ctx, "Deploys run on Fridays after the standup", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, deploys.ID, results[0].ID)
+	require.NoError(t, store.Close())
+	_, err = os.Stat(filepath.Join(dir, hnswFileName))
+	require.NoError(t, err, "the index should be saved on close")
+
+	// Changes from a process that exited without saving are reconciled on open
+	other := openStoreAt(t, dir)
+	other.vecIdx.hnsw.path = ""
+	require.NoError(t, other.Forget(ctx, deploys.ID))
+	added, err := other.Remember(ctx, "Logs go to stderr", nil, "")
+	require.NoError(t, err)
+	require.NoError(t, other.Close())
+
+	store = openStoreAt(t, dir)
+	defer store.Close()
+	assert.Equal(t, 2, store.vecIdx.hnsw.Len())
+	found, err := store.vecIdx.Search(added.Embedding, 5)
+	require.NoError(t, err)
+	ids := make([]string, len(found))
+	for i, r := range found {
+		ids[i] = r.MemoryID
+	}
+	assert.Contains(t, ids, added.ID)
+	assert.NotContains(t, ids, deploys.ID)
+}
+
+// Vector search over n memories: the linear scan decodes every stored embedding and
+// compares it with the query, as recallLinearScan does (reading the rows from SQLite,
+// which it also does, is left out); HNSW searches the in-memory graph. Building the
+// graph is reported as ns/insert.
+func BenchmarkVectorSearch(b *testing.B) {
+	const dims = 256
+	for _, n := range []int{10_000, 100_000} {
+		vecs := randomVectors(n, dims, 5)
+		queries := randomVectors(64, dims, 6)
+
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) {
+			blobs := make([][]byte, n)
+			for i, v := range vecs {
+				blobs[i] = embeddingFloat32.encode(v)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				q := queries[i%len(queries)]
+				sims := make([]float64, len(blobs))
+				for j, blob := range blobs {
+					v, err := decodeEmbedding(blob)
+					if err != nil {
+						b.Fatal(err)
+					}
+					sims[j] = cosineSimilarity(q, v)
+				}
+				sort.Sort(sort.Reverse(sort.Float64Slice(sims)))
+			}
+		})
+
+		var h *hnswIndex
+		var perInsert float64
+		b.Run(fmt.Sprintf("hnsw/%d", n), func(b *testing.B) {
+			if h == nil { // Built once, not for every b.N
+				start := time.Now()
+				h = buildHNSW(vecs)
+				perInsert = float64(time.Since(start).Nanoseconds()) / float64(n)
+				b.ResetTimer()
+			}
+			for i := 0; i < b.N; i++ {
+				h.Search(queries[i%len(queries)], 10)
+			}
+			b.ReportMetric(perInsert, "ns/insert")
+		})
+	}
+}
@@ -0,0 +1,321 @@
+// Package memory: embeddings from a self-hosted model server.
+// HTTPEmbedder talks to an embedding server on the user's own machine or network
+// (Ollama, llama.cpp's server, LM Studio, vLLM, text-embeddings-inference, ...) using
+// either the OpenAI-compatible /v1/embeddings protocol, which takes a batch per
+// request, or Ollama's /api/embeddings, which takes one prompt per request.
+// The protocol is detected from the URL or by probing the server, and the vector
+// size is discovered from a probe embedding unless configured. With the size
+// configured nothing is sent until the first embedding, so Phloem starts (queuing
+// embeddings) while the server is down.
+
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Embedding server protocols
+const (
+	EmbedAPIOpenAI = "openai" // POST /v1/embeddings {"model", "input": [...]}
+	EmbedAPIOllama = "ollama" // POST /api/embeddings {"model", "prompt"}
+)
+
+const (
+	openAIEmbeddingsPath = "/v1/embeddings"
+	ollamaEmbeddingsPath = "/api/embeddings"
+
+	defaultEmbedBatchSize = 32
+	embedProbeText        = "phloem embedding dimension probe"
+)
+
+// HTTPEmbedderConfig configures an HTTPEmbedder
+type HTTPEmbedderConfig struct {
+	URL        string // Server base URL, or the full embeddings endpoint
+	API        string // EmbedAPIOpenAI or EmbedAPIOllama; "" detects it
+	Model      string // Model name sent with each request
+	APIKey     string // Optional bearer token
+	Dimensions int    // Vector size; 0 discovers it with a probe request
+	BatchSize  int    // Texts per /v1/embeddings request (default 32)
+	Timeout    time.Duration
+}
+
+// HTTPEmbedder generates embeddings with a self-hosted embedding server
+type HTTPEmbedder struct {
+	endpoint   string
+	api        string
+	model      string
+	apiKey     string
+	dimensions int
+	batchSize  int
+	client     *http.Client
+
+	detectMu sync.Mutex
+	base     string // Server base URL while the protocol is still to be detected
+}
+
+// NewHTTPEmbedder creates an embedder for the server at cfg.URL. Unless the dimensions
+// are configured it sends a probe request, so an unreachable server or unknown model
+// is reported here rather than on the first memory. With the dimensions configured
+// but not the protocol, the protocol is detected on the first request.
+func NewHTTPEmbedder(cfg HTTPEmbedderConfig) (*HTTPEmbedder, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("embedding server URL not set")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid embedding server URL %q", cfg.URL)
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 60 * time.Second // Local models on a CPU can be slow
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultEmbedBatchSize
+	}
+
+	e := &HTTPEmbedder{
+		model:      cfg.Model,
+		apiKey:     cfg.APIKey,
+		dimensions: cfg.Dimensions,
+		batchSize:  cfg.BatchSize,
+		client:     &http.Client{Timeout: cfg.Timeout},
+	}
+
+	// A URL naming the endpoint fixes the protocol; a base URL gets the protocol's path
+	base := strings.TrimRight(cfg.URL, "/")
+	api := strings.ToLower(cfg.API)
+	switch {
+	case strings.HasSuffix(base, openAIEmbeddingsPath):
+		e.endpoint, api = base, EmbedAPIOpenAI
+	case strings.HasSuffix(base, ollamaEmbeddingsPath):
+		e.endpoint, api = base, EmbedAPIOllama
+	case api == EmbedAPIOpenAI:
+		e.endpoint = base + openAIEmbeddingsPath
+	case api == EmbedAPIOllama:
+		e.endpoint = base + ollamaEmbeddingsPath
+	case api != "":
+		return nil, fmt.Errorf("unknown embedding API %q (want %s or %s)", cfg.API, EmbedAPIOpenAI, EmbedAPIOllama)
+	}
+	e.api = api
+	if e.api == EmbedAPIOllama && e.model == "" {
+		return nil, fmt.Errorf("a model is required for the Ollama embeddings API")
+	}
+
+	if e.dimensions > 0 {
+		if e.api == "" {
+			// Model() must not change once detected: Ollama needs a model name, which
+			// Model() prefers, so the OpenAI endpoint stands in for the model until then
+			e.base, e.endpoint = base, base+openAIEmbeddingsPath
+		}
+		return e, nil
+	}
+	if e.api == "" {
+		if err := e.detectAPI(base); err != nil {
+			return nil, err
+		}
+	}
+	if e.dimensions == 0 {
+		vec, err := e.Embed(embedProbeText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover embedding dimensions: %w", err)
+		}
+		e.dimensions = len(vec)
+	}
+	return e, nil
+}
+
+// detectAPI probes the server with a single embedding, trying the OpenAI-compatible
+// endpoint first and Ollama's when that one does not exist. The probe also settles the
+// dimensions. On failure the protocol is left undetected.
+func (e *HTTPEmbedder) detectAPI(base string) (err error) {
+	defer func() {
+		if err != nil {
+			e.api, e.endpoint = "", base+openAIEmbeddingsPath
+		}
+	}()
+	e.api, e.endpoint = EmbedAPIOpenAI, base+openAIEmbeddingsPath
+	vecs, err := e.embedOpenAI([]string{embedProbeText})
+	if err == nil {
+		return e.probed(vecs[0])
+	}
+	if e.model == "" || !isNotFound(err) {
+		return fmt.Errorf("failed to probe embedding server at %s: %w", base, err)
+	}
+
+	e.api, e.endpoint = EmbedAPIOllama, base+ollamaEmbeddingsPath
+	vec, ollamaErr := e.embedOllama(embedProbeText)
+	if ollamaErr != nil {
+		return fmt.Errorf("embedding server at %s answers neither %s (%v) nor %s (%v)",
+			base, openAIEmbeddingsPath, err, ollamaEmbeddingsPath, ollamaErr)
+	}
+	return e.probed(vec)
+}
+
+// ensureAPI detects the protocol of a server that was not probed at startup
+func (e *HTTPEmbedder) ensureAPI() error {
+	e.detectMu.Lock()
+	defer e.detectMu.Unlock()
+	if e.base == "" {
+		return nil
+	}
+	if err := e.detectAPI(e.base); err != nil {
+		return err
+	}
+	e.base = ""
+	return nil
+}
+
+// probed records the dimensions of a probe embedding, checking configured ones
+func (e *HTTPEmbedder) probed(vec []float32) error {
+	if e.dimensions > 0 && len(vec) != e.dimensions {
+		return fmt.Errorf("embedding server returns %d dimensions, configured for %d", len(vec), e.dimensions)
+	}
+	e.dimensions = len(vec)
+	return nil
+}
+
+// Embed generates an embedding for a single text
+func (e *HTTPEmbedder) Embed(text string) ([]float32, error) {
+	embeddings, err := e.EmbedBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch generates embeddings for texts, in requests of at most the configured
+// batch size (one request per text for Ollama)
+func (e *HTTPEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	if err := e.ensureAPI(); err != nil {
+		return nil, err
+	}
+	embeddings := make([][]float32, 0, len(texts))
+	if e.api == EmbedAPIOllama {
+		for _, text := range texts {
+			vec, err := e.embedOllama(text)
+			if err != nil {
+				return nil, err
+			}
+			embeddings = append(embeddings, vec)
+		}
+	} else {
+		for start := 0; start < len(texts); start += e.batchSize {
+			end := min(start+e.batchSize, len(texts))
+			batch, err := e.embedOpenAI(texts[start:end])
+			if err != nil {
+				return nil, err
+			}
+			embeddings = append(embeddings, batch...)
+		}
+	}
+
+	for i, vec := range embeddings {
+		if e.dimensions > 0 && len(vec) != e.dimensions {
+			return nil, fmt.Errorf("embedding server returned %d dimensions for text %d, expected %d (was the model changed?)",
+				len(vec), i, e.dimensions)
+		}
+	}
+	return embeddings, nil
+}
+
+// Dimensions returns the embedding dimension size
+func (e *HTTPEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+// Model returns the embedding model identifier: the model name, or the endpoint when
+// the server decides which model to use
+func (e *HTTPEmbedder) Model() string {
+	if e.model != "" {
+		return "http:" + e.model
+	}
+	return "http:" + e.endpoint
+}
+
+func (e *HTTPEmbedder) embedOpenAI(texts []string) ([][]float32, error) {
+	embeddings, err := callEmbeddingAPI(e.client, e.endpoint, e.apiKey, e.model, texts)
+	if err != nil {
+		return nil, err
+	}
+	for i, vec := range embeddings {
+		if len(vec) == 0 {
+			return nil, fmt.Errorf("no embedding returned for text %d of %d", i, len(texts))
+		}
+	}
+	return embeddings, nil
+}
+
+func (e *HTTPEmbedder) embedOllama(text string) ([]float32, error) {
+	jsonBody, err := json.Marshal(map[string]string{"model": e.model, "prompt": text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(result.Embedding) == 0 {
+		return nil, fmt.Errorf("no embedding returned (is %q an embedding model?)", e.model)
+	}
+	return result.Embedding, nil
+}
+
+// isNotFound reports whether err is an API error for a missing endpoint
+func isNotFound(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "API error 404") || strings.HasPrefix(msg, "API error 405")
+}
+
+// httpEmbedderConfigFromEnv reads the HTTPEmbedder settings:
+//
+//	PHLOEM_EMBED_URL         server base URL or embeddings endpoint (required)
+//	PHLOEM_EMBED_MODEL       model name (required for Ollama)
+//	PHLOEM_EMBED_API         openai or ollama (detected if unset)
+//	PHLOEM_EMBED_API_KEY     bearer token, for servers that want one
+//	PHLOEM_EMBED_DIMENSIONS  vector size (discovered if unset)
+//	PHLOEM_EMBED_BATCH_SIZE  texts per request (default 32)
+func httpEmbedderConfigFromEnv() HTTPEmbedderConfig {
+	cfg := HTTPEmbedderConfig{
+		URL:    os.Getenv("PHLOEM_EMBED_URL"),
+		API:    os.Getenv("PHLOEM_EMBED_API"),
+		Model:  os.Getenv("PHLOEM_EMBED_MODEL"),
+		APIKey: os.Getenv("PHLOEM_EMBED_API_KEY"),
+	}
+	cfg.Dimensions, _ = strconv.Atoi(os.Getenv("PHLOEM_EMBED_DIMENSIONS"))
+	cfg.BatchSize, _ = strconv.Atoi(os.Getenv("PHLOEM_EMBED_BATCH_SIZE"))
+	return cfg
+}
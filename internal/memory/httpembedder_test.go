@@ -0,0 +1,260 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVector is a deterministic embedding whose first component encodes len(text)
+func fakeVector(text string, dims int) []float32 {
+	v := make([]float32, dims)
+	v[0] = float32(len(text))
+	v[1] = 1
+	return v
+}
+
+// embeddingServer stands in for an embedding server. It serves /v1/embeddings when
+// openAI is set and /api/embeddings when ollama is set, and records each request's
+// batch size.
+type embeddingServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	batches []int
+	models  []string
+	auth    []string
+}
+
+func newEmbeddingServer(t *testing.T, dims int, openAI, ollama bool) *embeddingServer {
+	t.Helper()
+	es := &embeddingServer{}
+	mux := http.NewServeMux()
+	if openAI {
+		mux.HandleFunc("POST /v1/embeddings", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Model string   `json:"model"`
+				Input []string `json:"input"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			es.record(len(req.Input), req.Model, r.Header.Get("Authorization"))
+
+			type datum struct {
+				Embedding []float32 `json:"embedding"`
+				Index     int       `json:"index"`
+			}
+			data := make([]datum, len(req.Input))
+			for i := range req.Input {
+				// Out of order, as the API allows
+				j := len(req.Input) - 1 - i
+				data[i] = datum{Embedding: fakeVector(req.Input[j], dims), Index: j}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+		})
+	}
+	if ollama {
+		mux.HandleFunc("POST /api/embeddings", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Model  string `json:"model"`
+				Prompt string `json:"prompt"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			es.record(1, req.Model, r.Header.Get("Authorization"))
+			if req.Model != "nomic-embed-text" {
+				http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"embedding": fakeVector(req.Prompt, dims)})
+		})
+	}
+	es.Server = httptest.NewServer(mux)
+	t.Cleanup(es.Close)
+	return es
+}
+
+func (es *embeddingServer) record(batch int, model, auth string) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.batches = append(es.batches, batch)
+	es.models = append(es.models, model)
+	es.auth = append(es.auth, auth)
+}
+
+func (es *embeddingServer) requests() int {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	return len(es.batches)
+}
+
+func TestHTTPEmbedder_OpenAICompatible(t *testing.T) {
+	server := newEmbeddingServer(t, 8, true, false)
+
+	emb, err := NewHTTPEmbedder(HTTPEmbedderConfig{URL: server.URL, Model: "bge-small", APIKey: "secret", BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, EmbedAPIOpenAI, emb.api)
+	assert.Equal(t, 8, emb.Dimensions(), "dimensions should be discovered from the probe")
+	assert.Equal(t, 1, server.requests(), "detection and discovery should share one probe")
+
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	vecs, err := emb.EmbedBatch(texts)
+	require.NoError(t, err)
+	require.Len(t, vecs, len(texts))
+	for i, text := range texts {
+		assert.Equal(t, float32(len(text)), vecs[i][0], "embedding %d out of order", i)
+	}
+	assert.Equal(t, []int{1, 2, 2, 1}, server.batches, "texts should be sent in batches of 2")
+	assert.Equal(t, "bge-small", server.models[1])
+	assert.Equal(t, "Bearer secret", server.auth[1])
+}
+
+func TestHTTPEmbedder_Ollama(t *testing.T) {
+	server := newEmbeddingServer(t, 6, false, true)
+
+	// Detected: /v1/embeddings is missing, so the probe moves on to /api/embeddings
+	emb, err := NewHTTPEmbedder(HTTPEmbedderConfig{URL: server.URL + "/", Model: "nomic-embed-text"})
+	require.NoError(t, err)
+	assert.Equal(t, EmbedAPIOllama, emb.api)
+	assert.Equal(t, server.URL+"/api/embeddings", emb.endpoint)
+	assert.Equal(t, 6, emb.Dimensions())
+
+	vecs, err := emb.EmbedBatch([]string{"one", "three"})
+	require.NoError(t, err)
+	assert.Equal(t, float32(3), vecs[0][0])
+	assert.Equal(t, float32(5), vecs[1][0])
+
+	// An unknown model is reported up front
+	_, err = NewHTTPEmbedder(HTTPEmbedderConfig{URL: server.URL, API: "ollama", Model: "llama3"})
+	assert.Error(t, err)
+
+	_, err = NewHTTPEmbedder(HTTPEmbedderConfig{URL: server.URL, API: "ollama"})
+	assert.Error(t, err, "Ollama needs a model")
+}
+
+func TestHTTPEmbedder_ConfiguredSkipsProbe(t *testing.T) {
+	server := newEmbeddingServer(t, 4, true, true)
+
+	emb, err := NewHTTPEmbedder(HTTPEmbedderConfig{URL: server.URL + "/v1/embeddings", Dimensions: 4})
+	require.NoError(t, err)
+	assert.Equal(t, EmbedAPIOpenAI, emb.api, "the endpoint path should select the protocol")
+	assert.Equal(t, 0, server.requests())
+
+	// A server whose vectors do not match the configured size is rejected
+	emb, err = NewHTTPEmbedder(HTTPEmbedderConfig{URL: server.URL, API: "openai", Dimensions: 16})
+	require.NoError(t, err)
+	_, err = emb.Embed("hello")
+	assert.ErrorContains(t, err, "expected 16")
+
+	// Configured dimensions but no protocol: detected by the first request, which
+	// fails when the server disagrees with the configured size
+	sent := server.requests()
+	emb, err = NewHTTPEmbedder(HTTPEmbedderConfig{URL: server.URL, Dimensions: 16})
+	require.NoError(t, err)
+	assert.Equal(t, sent, server.requests())
+	_, err = emb.Embed("hello")
+	assert.ErrorContains(t, err, "configured for 16")
+
+	emb, err = NewHTTPEmbedder(HTTPEmbedderConfig{URL: server.URL, Model: "nomic-embed-text", Dimensions: 4})
+	require.NoError(t, err)
+	model := emb.Model()
+	vec, err := emb.Embed("hello")
+	require.NoError(t, err)
+	assert.Equal(t, float32(5), vec[0])
+	assert.Equal(t, EmbedAPIOpenAI, emb.api)
+	assert.Equal(t, model, emb.Model(), "detection should not change the model")
+}
+
+func TestHTTPEmbedder_Errors(t *testing.T) {
+	_, err := NewHTTPEmbedder(HTTPEmbedderConfig{})
+	assert.Error(t, err)
+	_, err = NewHTTPEmbedder(HTTPEmbedderConfig{URL: "localhost:11434"})
+	assert.Error(t, err, "a URL without a scheme should be rejected")
+	_, err = NewHTTPEmbedder(HTTPEmbedderConfig{URL: "http://localhost:1", API: "grpc"})
+	assert.Error(t, err)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model is loading", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	_, err = NewHTTPEmbedder(HTTPEmbedderConfig{URL: failing.URL, Model: "nomic-embed-text"})
+	assert.ErrorContains(t, err, "503")
+}
+
+func TestGetEmbedder_HTTP(t *testing.T) {
+	server := newEmbeddingServer(t, 12, true, false)
+	t.Setenv("PHLOEM_AIR_GAPPED", "")
+	t.Setenv("PHLOEM_EMBEDDINGS", "http")
+	t.Setenv("PHLOEM_EMBED_URL", server.URL)
+	t.Setenv("PHLOEM_EMBED_MODEL", "bge-small")
+
+	emb := GetEmbedder()
+	fallback, ok := emb.(*FallbackEmbedder)
+	require.True(t, ok, "the HTTP embedder should fall back to local on errors")
+	assert.IsType(t, &HTTPEmbedder{}, fallback.primary)
+	assert.Equal(t, 12, emb.Dimensions())
+
+	// Unreachable server with dimensions to discover: local embeddings
+	server.Close()
+	assert.Equal(t, 512, GetEmbedder().Dimensions())
+}
+
+func TestGetEmbedder_HTTPDownAtStartup(t *testing.T) {
+	server := newEmbeddingServer(t, 12, true, false)
+	addr := server.Listener.Addr().String()
+	server.Close()
+	t.Setenv("PHLOEM_AIR_GAPPED", "")
+	t.Setenv("PHLOEM_VECTOR_INDEX", "none")
+	t.Setenv("PHLOEM_EMBEDDINGS", "http")
+	t.Setenv("PHLOEM_EMBED_URL", "http://"+addr)
+	t.Setenv("PHLOEM_EMBED_MODEL", "bge-small")
+	t.Setenv("PHLOEM_EMBED_DIMENSIONS", "12")
+	ctx := context.Background()
+
+	// The configured model is kept rather than replaced by local embeddings
+	emb := GetEmbedder()
+	fallback, ok := emb.(*FallbackEmbedder)
+	require.True(t, ok)
+	assert.IsType(t, &HTTPEmbedder{}, fallback.primary)
+	assert.Equal(t, 12, emb.Dimensions())
+	assert.Equal(t, "http:bge-small", emb.Model())
+	_, err := emb.Embed("hello")
+	assert.ErrorIs(t, err, ErrEmbedderUnavailable)
+
+	// Memories are queued and recall is lexical until the server is up
+	store := openStoreAt(t, t.TempDir())
+	defer store.Close()
+	queued, err := store.Remember(ctx, "The staging database is rebuilt nightly", nil, "")
+	require.NoError(t, err)
+	n, err := store.PendingEmbeddings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	results, err := store.Recall(ctx, "staging database", 5, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, queued.ID, results[0].ID)
+
+	// The server comes up: the queue drains with the configured model
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	restarted := &httptest.Server{Listener: ln, Config: &http.Server{Handler: server.Config.Handler}}
+	restarted.Start()
+	defer restarted.Close()
+	store.embedder.(*cachingEmbedder).inner.(*FallbackEmbedder).recoverNow()
+	_, err = store.Recall(ctx, "nightly rebuilds", 5, nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		n, err := store.PendingEmbeddings(ctx)
+		return err == nil && n == 0 && !store.draining.Load()
+	}, 5*time.Second, 10*time.Millisecond)
+	models, err := store.embeddingModels(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "http:bge-small", models[queued.ID])
+}
@@ -0,0 +1,111 @@
+// Package memory: pending embeddings.
+// When the embedder fails (an API key expired, the embedding server is down), memories
+// are stored with a zero vector and no model, and queued in pending_embeddings instead
+// of being embedded with another model whose vectors could not be compared with the
+// rest. Recall answers lexically meanwhile. The queue is drained in the background as
+// soon as an embedding succeeds again; `phloem reembed` clears it too.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// queueEmbedding records whether memory id still needs an embedding: model is the
+// model its vector was stored with, "" when embedding failed
+func (s *Store) queueEmbedding(ctx context.Context, id, model string) {
+	if model != "" {
+		if s.pendingEmbeds.Load() > 0 {
+			s.db.ExecContext(ctx, `DELETE FROM pending_embeddings WHERE memory_id = ?`, id)
+		}
+		return
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO pending_embeddings (memory_id, queued_at) VALUES (?, ?)
+		ON CONFLICT(memory_id) DO NOTHING
+	`, id, time.Now()); err == nil {
+		s.pendingEmbeds.Add(1)
+	}
+}
+
+// PendingEmbeddings counts memories queued for embedding
+func (s *Store) PendingEmbeddings(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM pending_embeddings WHERE memory_id IN (SELECT id FROM memories)
+	`).Scan(&n)
+	return n, err
+}
+
+// embedded is called after every successful embedding: the embedder works, so any
+// queued memories are drained in the background
+func (s *Store) embedded() {
+	if s.pendingEmbeds.Load() > 0 && s.draining.CompareAndSwap(false, true) {
+		go func() {
+			defer s.draining.Store(false)
+			if n, err := s.DrainPendingEmbeddings(context.Background()); n > 0 {
+				fmt.Fprintf(os.Stderr, "🧠 Embedded %d queued memories\n", n)
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Queued embeddings still pending: %v\n", err)
+			}
+		}()
+	}
+}
+
+// DrainPendingEmbeddings embeds the queued memories with the current embedder, oldest
+// first, committing each batch. It stops at the first failure, which is recorded on
+// the batch's queue entries, and returns the number embedded.
+func (s *Store) DrainPendingEmbeddings(ctx context.Context) (int, error) {
+	// Memories deleted while queued
+	s.db.ExecContext(ctx, `DELETE FROM pending_embeddings WHERE memory_id NOT IN (SELECT id FROM memories)`)
+
+	done := 0
+	defer func() {
+		if n, err := s.PendingEmbeddings(context.Background()); err == nil {
+			s.pendingEmbeds.Store(int64(n))
+		}
+	}()
+	for {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT m.id, m.content FROM pending_embeddings p JOIN memories m ON m.id = p.memory_id
+			ORDER BY p.queued_at, m.id LIMIT ?
+		`, DefaultReembedBatchSize)
+		if err != nil {
+			return done, fmt.Errorf("failed to list queued embeddings: %w", err)
+		}
+		var ids, texts []string
+		for rows.Next() {
+			var id, content string
+			if err := rows.Scan(&id, &content); err != nil {
+				rows.Close()
+				return done, fmt.Errorf("failed to read queued memory: %w", err)
+			}
+			ids = append(ids, id)
+			texts = append(texts, s.open(content))
+		}
+		rows.Close()
+		if len(ids) == 0 {
+			return done, rows.Err()
+		}
+
+		model := s.embedder.Model()
+		embeddings, err := s.embedder.EmbedBatch(texts)
+		if err != nil {
+			for _, id := range ids {
+				s.db.ExecContext(ctx, `UPDATE pending_embeddings SET attempts = attempts + 1, last_error = ? WHERE memory_id = ?`,
+					err.Error(), id)
+			}
+			return done, fmt.Errorf("failed to embed queued memories: %w", err)
+		}
+		if err := s.saveEmbeddings(ctx, ids, embeddings, model); err != nil {
+			return done, err
+		}
+		done += len(ids)
+	}
+}
@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyEmbedder stands in for an API that can go down and come back
+type flakyEmbedder struct {
+	down  atomic.Bool
+	calls atomic.Int32
+}
+
+func (e *flakyEmbedder) Embed(text string) ([]float32, error) {
+	embeddings, err := e.EmbedBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e *flakyEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	e.calls.Add(1)
+	if e.down.Load() {
+		return nil, fmt.Errorf("503 service unavailable")
+	}
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = fakeVector(text, 8)
+	}
+	return embeddings, nil
+}
+
+func (e *flakyEmbedder) Dimensions() int { return 8 }
+func (e *flakyEmbedder) Model() string   { return "test:flaky" }
+
+// recoverNow ends the fallback's wait before the primary is retried
+func (f *FallbackEmbedder) recoverNow() {
+	if f.retryAt.Load() != 0 {
+		f.retryAt.Store(1)
+	}
+}
+
+func TestFallbackEmbedder_NeverMixesModels(t *testing.T) {
+	primary := &flakyEmbedder{}
+	emb := NewFallbackEmbedder(primary)
+
+	primary.down.Store(true)
+	_, err := emb.Embed("hello")
+	assert.ErrorIs(t, err, ErrEmbedderUnavailable)
+	assert.Equal(t, "test:flaky", emb.Model(), "the model should not change while the primary is down")
+	assert.Equal(t, 8, emb.Dimensions())
+
+	// The primary is left alone until the retry interval has passed
+	primary.down.Store(false)
+	_, err = emb.EmbedBatch([]string{"hello", "world"})
+	assert.ErrorIs(t, err, ErrEmbedderUnavailable)
+	assert.Equal(t, int32(1), primary.calls.Load())
+
+	emb.recoverNow()
+	vecs, err := emb.EmbedBatch([]string{"hello", "world"})
+	require.NoError(t, err)
+	assert.Len(t, vecs, 2)
+	assert.Zero(t, emb.retryAt.Load())
+}
+
+func TestPendingEmbeddings_QueuedWhileDownAndDrainedOnRecovery(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	primary := &flakyEmbedder{}
+	emb := NewFallbackEmbedder(primary)
+	store.embedder = emb
+	store.vecIdx = newVecIndex(store.db, "", emb.Dimensions(), emb.Model())
+
+	_, err := store.Remember(ctx, "Deploys run on Fridays after the standup", nil, "")
+	require.NoError(t, err)
+
+	// The API goes down: memories are queued rather than embedded with another model
+	primary.down.Store(true)
+	queued, err := store.Remember(ctx, "The staging database is rebuilt nightly", nil, "")
+	require.NoError(t, err)
+	assert.True(t, isZeroVector(queued.Embedding))
+	n, err := store.PendingEmbeddings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	status, err := store.EmbeddingStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Queued)
+	assert.Equal(t, map[string]int{"test:flaky": 1, "": 1}, status.ByModel)
+
+	// Queries are answered by keywords meanwhile
+	results, err := store.Recall(ctx, "staging database", 5, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, queued.ID, results[0].ID)
+
+	results, err = store.RecallWithRecencyBoost(ctx, "deploys", 5, RecallOptions{SemanticWeight: 0.7, RecencyWeight: 0.3})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Contains(t, results[0].Content, "Deploys")
+
+	// The API comes back: the next successful embedding drains the queue
+	primary.down.Store(false)
+	emb.recoverNow()
+	_, err = store.Recall(ctx, "nightly rebuilds", 5, nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		n, err := store.PendingEmbeddings(ctx)
+		return err == nil && n == 0 && !store.draining.Load()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	models, err := store.embeddingModels(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "test:flaky", models[queued.ID])
+	results, err = store.Recall(ctx, "The staging database is rebuilt nightly", 1, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, queued.ID, results[0].ID)
+}
+
+func TestDrainPendingEmbeddings(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	primary := &flakyEmbedder{}
+	store.embedder = NewFallbackEmbedder(primary)
+	primary.down.Store(true)
+	kept, err := store.Remember(ctx, "Queued and kept", nil, "")
+	require.NoError(t, err)
+	forgotten, err := store.Remember(ctx, "Queued and forgotten", nil, "")
+	require.NoError(t, err)
+	require.NoError(t, store.Forget(ctx, forgotten.ID))
+
+	// Still down: the failure is recorded and the queue kept
+	n, err := store.DrainPendingEmbeddings(ctx)
+	assert.ErrorIs(t, err, ErrEmbedderUnavailable)
+	assert.Zero(t, n)
+	var attempts int
+	var lastError string
+	require.NoError(t, store.db.QueryRow(`SELECT attempts, last_error FROM pending_embeddings WHERE memory_id = ?`, kept.ID).
+		Scan(&attempts, &lastError))
+	assert.Equal(t, 1, attempts)
+	assert.Contains(t, lastError, "unavailable")
+
+	// Updating the memory while the API is up embeds it, and takes it off the queue
+	primary.down.Store(false)
+	store.embedder.(*FallbackEmbedder).recoverNow()
+	content := "Queued, then updated"
+	_, err = store.Update(ctx, kept.ID, MemoryUpdate{Content: &content})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return !store.draining.Load() }, 5*time.Second, 10*time.Millisecond)
+	n, err = store.DrainPendingEmbeddings(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	var rows int
+	require.NoError(t, store.db.QueryRow(`SELECT COUNT(*) FROM pending_embeddings`).Scan(&rows))
+	assert.Zero(t, rows)
+}
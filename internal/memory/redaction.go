@@ -0,0 +1,209 @@
+// Package memory: secret scrubbing.
+// Content, context and citation snippets pass through redact.Scrub before they are
+// stored, so API keys and tokens pasted by agents or chat imports never reach the
+// database. What was redacted is recorded in memory_redactions (kind and fingerprint
+// only, never the secret). ScanSecrets applies the same scrubbing to existing rows.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CanopyHQ/phloem/internal/redact"
+)
+
+// Fields a redaction can apply to
+const (
+	RedactedContent  = "content"
+	RedactedContext  = "context"
+	RedactedCitation = "citation"
+	RedactedRevision = "revision"
+)
+
+// RedactionRecord is a stored record of a secret removed from a memory
+type RedactionRecord struct {
+	MemoryID    string    `json:"memory_id"`
+	Field       string    `json:"field"`
+	Kind        string    `json:"kind"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// scrub removes secrets from text unless redaction is disabled with PHLOEM_REDACT=off
+func (s *Store) scrub(text string) (string, []redact.Finding) {
+	if text == "" || !redact.Enabled() {
+		return text, nil
+	}
+	return redact.Scrub(text)
+}
+
+// Scrub returns text as the store would keep it, with secrets redacted, for comparing
+// outside text with stored content
+func (s *Store) Scrub(text string) string {
+	scrubbed, _ := s.scrub(text)
+	return scrubbed
+}
+
+// recordRedactions stores what was redacted from a memory field
+func (s *Store) recordRedactions(ctx context.Context, memoryID, field string, findings []redact.Finding) {
+	for _, f := range findings {
+		s.db.ExecContext(ctx, `
+			INSERT INTO memory_redactions (id, memory_id, field, kind, fingerprint, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, generateID(), memoryID, field, f.Kind, f.Fingerprint, time.Now())
+	}
+}
+
+// Redactions returns the redactions recorded for a memory, oldest first
+func (s *Store) Redactions(ctx context.Context, memoryID string) ([]RedactionRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT memory_id, field, kind, fingerprint, created_at
+		FROM memory_redactions WHERE memory_id = ? ORDER BY created_at ASC
+	`, memoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list redactions: %w", err)
+	}
+	defer rows.Close()
+
+	var records []RedactionRecord
+	for rows.Next() {
+		var r RedactionRecord
+		if err := rows.Scan(&r.MemoryID, &r.Field, &r.Kind, &r.Fingerprint, &r.CreatedAt); err != nil {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// SecretScanHit is a memory found to contain secrets by ScanSecrets
+type SecretScanHit struct {
+	MemoryID string           `json:"memory_id"`
+	Preview  string           `json:"preview"` // Start of the scrubbed content
+	Findings []redact.Finding `json:"findings"`
+	Fields   []string         `json:"fields"`
+}
+
+// SecretScanResult summarizes a ScanSecrets run
+type SecretScanResult struct {
+	Scanned  int             `json:"scanned"`
+	Hits     []SecretScanHit `json:"hits"`
+	Redacted int             `json:"redacted"` // Secrets removed (0 on a dry run)
+	DryRun   bool            `json:"dry_run"`
+}
+
+// ScanSecrets looks for secrets in every stored memory, its revision history and its
+// citation snippets. Unless dryRun is set they are scrubbed in place: content is
+// re-embedded, and revisions are rewritten directly so no copy of the secret survives.
+func (s *Store) ScanSecrets(ctx context.Context, dryRun bool) (*SecretScanResult, error) {
+	memories, err := s.List(ctx, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SecretScanResult{Scanned: len(memories), DryRun: dryRun}
+	for _, m := range memories {
+		hit := SecretScanHit{MemoryID: m.ID}
+		note := func(field string, findings []redact.Finding) {
+			if len(findings) > 0 {
+				hit.Findings = append(hit.Findings, findings...)
+				hit.Fields = append(hit.Fields, field)
+			}
+		}
+
+		content, contentFindings := redact.Scrub(m.Content)
+		memContext, contextFindings := redact.Scrub(m.Context)
+		note(RedactedContent, contentFindings)
+		note(RedactedContext, contextFindings)
+
+		revisions, _ := s.GetRevisions(ctx, m.ID)
+		type revisionFix struct{ id, content, context string }
+		var revisionFixes []revisionFix
+		var revisionFindings []redact.Finding
+		for _, r := range revisions {
+			rc, f1 := redact.Scrub(r.Content)
+			rx, f2 := redact.Scrub(r.Context)
+			if len(f1)+len(f2) > 0 {
+				revisionFixes = append(revisionFixes, revisionFix{r.ID, rc, rx})
+				revisionFindings = append(append(revisionFindings, f1...), f2...)
+			}
+		}
+		note(RedactedRevision, revisionFindings)
+
+		citations, _ := s.GetCitations(ctx, m.ID)
+		type citationFix struct{ id, content string }
+		var citationFixes []citationFix
+		var citationFindings []redact.Finding
+		for _, c := range citations {
+			if cc, f := redact.Scrub(c.Content); len(f) > 0 {
+				citationFixes = append(citationFixes, citationFix{c.ID, cc})
+				citationFindings = append(citationFindings, f...)
+			}
+		}
+		note(RedactedCitation, citationFindings)
+
+		if len(hit.Findings) == 0 {
+			continue
+		}
+		hit.Preview = content
+		if len(hit.Preview) > 80 {
+			hit.Preview = hit.Preview[:80] + "..."
+		}
+		result.Hits = append(result.Hits, hit)
+		if dryRun {
+			continue
+		}
+
+		if len(contentFindings)+len(contextFindings) > 0 {
+			if err := s.rewriteScrubbed(ctx, m, content, memContext); err != nil {
+				return result, err
+			}
+		}
+		for _, r := range revisionFixes {
+			if _, err := s.db.ExecContext(ctx, `UPDATE memory_revisions SET content = ?, context = ? WHERE id = ?`,
+				s.seal(r.content), s.seal(r.context), r.id); err != nil {
+				return result, fmt.Errorf("failed to scrub revision %s: %w", r.id, err)
+			}
+		}
+		for _, c := range citationFixes {
+			if _, err := s.db.ExecContext(ctx, `UPDATE citations SET content = ? WHERE id = ?`, s.seal(c.content), c.id); err != nil {
+				return result, fmt.Errorf("failed to scrub citation %s: %w", c.id, err)
+			}
+		}
+		s.recordRedactions(ctx, m.ID, RedactedContent, contentFindings)
+		s.recordRedactions(ctx, m.ID, RedactedContext, contextFindings)
+		s.recordRedactions(ctx, m.ID, RedactedRevision, revisionFindings)
+		s.recordRedactions(ctx, m.ID, RedactedCitation, citationFindings)
+		result.Redacted += len(hit.Findings)
+	}
+
+	if result.Redacted > 0 {
+		s.compact() // Don't leave the old values in free pages or the WAL
+	}
+	return result, nil
+}
+
+// rewriteScrubbed replaces a memory's content and context without recording a revision,
+// since the revision would keep the secret.
+func (s *Store) rewriteScrubbed(ctx context.Context, m *Memory, content, memContext string) error {
+	if content == m.Content {
+		if _, err := s.db.ExecContext(ctx, `UPDATE memories SET context = ? WHERE id = ?`, s.seal(memContext), m.ID); err != nil {
+			return fmt.Errorf("failed to scrub memory %s: %w", m.ID, err)
+		}
+		return nil
+	}
+
+	embedding, embeddingModel := s.embed(content)
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE memories SET content = ?, content_hash = ?, context = ?, embedding = ?, embedding_model = ? WHERE id = ?
+	`, s.seal(content), s.hashContent(content), s.seal(memContext), s.encodeEmbedding(embedding), embeddingModel, m.ID); err != nil {
+		return fmt.Errorf("failed to scrub memory %s: %w", m.ID, err)
+	}
+	if s.vecIdx != nil {
+		s.vecIdx.Insert(m.ID, embedding, embeddingModel)
+	}
+	s.queueEmbedding(ctx, m.ID, embeddingModel)
+	return nil
+}
@@ -0,0 +1,301 @@
+// Package memory: embedding models and re-embedding.
+// Every memory records the model its vector came from (memories.embedding_model), and
+// vector recall, the vec index and consolidation only compare vectors from the current
+// embedder's model. After switching embedders, older memories stay findable lexically
+// but not by meaning until Reembed has re-embedded them. Reembed works in batches that
+// are committed one at a time and picks memories by their recorded model, so an
+// interrupted run resumes where it stopped. The vectors it replaces go to the
+// embedding cache, so switching back does not embed the same content again.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// DefaultReembedBatchSize is the number of memories sent to EmbedBatch at once
+const DefaultReembedBatchSize = 32
+
+// ReembedOptions controls a Reembed pass
+type ReembedOptions struct {
+	BatchSize int                   // Memories per EmbedBatch call; 0 uses DefaultReembedBatchSize
+	Limit     int                   // Stop after this many memories; 0 re-embeds all of them
+	Progress  func(done, total int) // Called after each committed batch
+}
+
+// ReembedResult summarizes a Reembed pass
+type ReembedResult struct {
+	Model      string `json:"model"`
+	Reembedded int    `json:"reembedded"`
+	Remaining  int    `json:"remaining"` // Memories still embedded with another model
+}
+
+// EmbeddingStatus describes which models the stored embeddings come from
+type EmbeddingStatus struct {
+	Model      string         `json:"model"` // Model of the current embedder
+	Dimensions int            `json:"dimensions"`
+	ByModel    map[string]int `json:"by_model"` // Memories per model; "" for unknown or failed embeddings
+	Pending    int            `json:"pending"`  // Memories not embedded with Model
+	Queued     int            `json:"queued"`   // Of those, memories whose embedding failed (see pending.go)
+}
+
+// embed embeds text with the store's embedder and returns the vector with the model
+// that produced it. On failure it returns a zero vector and no model; callers pass the
+// model to queueEmbedding once the memory is stored.
+func (s *Store) embed(text string) ([]float32, string) {
+	embedding, err := s.embedder.Embed(text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Embedding failed: %v\n", err)
+		return make([]float32, s.embedder.Dimensions()), ""
+	}
+	s.embedded()
+	return embedding, s.embedder.Model()
+}
+
+// embedQuery embeds a recall query
+func (s *Store) embedQuery(query string) ([]float32, error) {
+	embedding, err := s.embedder.Embed(query)
+	if err != nil {
+		return nil, err
+	}
+	s.embedded()
+	return embedding, nil
+}
+
+// EmbeddingStatus counts stored embeddings by model
+func (s *Store) EmbeddingStatus(ctx context.Context) (*EmbeddingStatus, error) {
+	status := &EmbeddingStatus{
+		Model:      s.embedder.Model(),
+		Dimensions: s.embedder.Dimensions(),
+		ByModel:    make(map[string]int),
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT COALESCE(embedding_model, ''), COUNT(*) FROM memories GROUP BY 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count embeddings by model: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var model string
+		var n int
+		if err := rows.Scan(&model, &n); err != nil {
+			continue
+		}
+		status.ByModel[model] = n
+		if model != status.Model {
+			status.Pending += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	status.Queued, err = s.PendingEmbeddings(ctx)
+	return status, err
+}
+
+// pendingReembed counts memories whose embedding is not from the current model
+func (s *Store) pendingReembed(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memories WHERE COALESCE(embedding_model, '') != ?`,
+		s.embedder.Model()).Scan(&n)
+	return n, err
+}
+
+// Reembed re-embeds memories whose vector comes from another model (or none) with the
+// current embedder, newest first, committing each batch. If the embedder fails it
+// stops and returns what was done; running it again continues with the remaining
+// memories.
+func (s *Store) Reembed(ctx context.Context, opts ReembedOptions) (*ReembedResult, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReembedBatchSize
+	}
+	model := s.embedder.Model()
+	result := &ReembedResult{Model: model}
+
+	total, err := s.pendingReembed(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count memories to re-embed: %w", err)
+	}
+	if opts.Limit > 0 && opts.Limit < total {
+		total = opts.Limit
+	}
+
+	for result.Reembedded < total {
+		if err := ctx.Err(); err != nil {
+			return s.reembedRemaining(ctx, result), err
+		}
+		n, err := s.reembedBatch(ctx, model, min(batchSize, total-result.Reembedded))
+		result.Reembedded += n
+		if err != nil {
+			return s.reembedRemaining(ctx, result), err
+		}
+		if n == 0 {
+			break // Others finished the job (e.g. a concurrent Update)
+		}
+		if opts.Progress != nil {
+			opts.Progress(result.Reembedded, total)
+		}
+	}
+	return s.reembedRemaining(ctx, result), nil
+}
+
+func (s *Store) reembedRemaining(ctx context.Context, result *ReembedResult) *ReembedResult {
+	result.Remaining, _ = s.pendingReembed(ctx)
+	return result
+}
+
+// reembedBatch re-embeds up to limit memories not yet embedded with model
+func (s *Store) reembedBatch(ctx context.Context, model string, limit int) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, content, COALESCE(content_hash, ''), embedding, COALESCE(embedding_model, '') FROM memories
+		WHERE COALESCE(embedding_model, '') != ?
+		ORDER BY created_at DESC, id LIMIT ?
+	`, model, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list memories to re-embed: %w", err)
+	}
+	var ids, texts []string
+	previous := make(map[string]map[string][]float32) // Old vectors by model and content hash
+	for rows.Next() {
+		var id, content, hash, oldModel string
+		var embeddingBlob []byte
+		if err := rows.Scan(&id, &content, &hash, &embeddingBlob, &oldModel); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read memory: %w", err)
+		}
+		ids = append(ids, id)
+		texts = append(texts, s.open(content))
+
+		if s.embedCache == nil || oldModel == "" || hash == "" {
+			continue
+		}
+		if old, err := decodeEmbedding(embeddingBlob); err == nil && len(old) > 0 {
+			if previous[oldModel] == nil {
+				previous[oldModel] = make(map[string][]float32)
+			}
+			previous[oldModel][hash] = old
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list memories to re-embed: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	embeddings, err := s.embedder.EmbedBatch(texts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to embed batch: %w", err)
+	}
+	if err := s.saveEmbeddings(ctx, ids, embeddings, model); err != nil {
+		return 0, err
+	}
+	for oldModel, vectors := range previous {
+		s.embedCache.put(oldModel, vectors)
+	}
+	return len(ids), nil
+}
+
+// saveEmbeddings stores the embeddings of memories ids, made with model, in one
+// transaction, takes the memories off the embedding queue and updates the vec index
+func (s *Store) saveEmbeddings(ctx context.Context, ids []string, embeddings [][]float32, model string) error {
+	if len(embeddings) != len(ids) {
+		return fmt.Errorf("embedder returned %d embeddings for %d memories", len(embeddings), len(ids))
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin storing embeddings: %w", err)
+	}
+	defer tx.Rollback()
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, `UPDATE memories SET embedding = ?, embedding_model = ? WHERE id = ?`,
+			s.encodeEmbedding(embeddings[i]), model, id); err != nil {
+			return fmt.Errorf("failed to store embedding for %s: %w", id, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM pending_embeddings WHERE memory_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to dequeue %s: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit embeddings: %w", err)
+	}
+
+	if s.vecIdx != nil {
+		for i, id := range ids {
+			s.vecIdx.Insert(id, embeddings[i], model)
+		}
+	}
+	return nil
+}
+
+// claimLegacyEmbeddings records the current model for memories stored before models
+// were recorded, when their vector has the current model's size: until now such
+// vectors were compared with the current embedder's anyway. Vectors of another size,
+// and the zero vectors left by failed embeddings, stay unclaimed for Reembed.
+func (s *Store) claimLegacyEmbeddings(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, embedding FROM memories WHERE COALESCE(embedding_model, '') = ''`)
+	if err != nil {
+		return 0, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		var embeddingBlob []byte
+		if err := rows.Scan(&id, &embeddingBlob); err != nil {
+			continue
+		}
+		embedding, err := decodeEmbedding(embeddingBlob)
+		if err != nil {
+			continue
+		}
+		if len(embedding) == s.embedder.Dimensions() && !isZeroVector(embedding) {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return 0, rows.Err()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	model := s.embedder.Model()
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, `UPDATE memories SET embedding_model = ? WHERE id = ?`, model, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), tx.Commit()
+}
+
+// embeddingModels returns the embedding model of every memory, by ID
+func (s *Store) embeddingModels(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, COALESCE(embedding_model, '') FROM memories`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding models: %w", err)
+	}
+	defer rows.Close()
+	models := make(map[string]string)
+	for rows.Next() {
+		var id, model string
+		if rows.Scan(&id, &model) == nil {
+			models[id] = model
+		}
+	}
+	return models, rows.Err()
+}
+
+func isZeroVector(v []float32) bool {
+	for _, x := range v {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingEmbedder fails every call, standing in for an unreachable API
+type failingEmbedder struct{}
+
+func (failingEmbedder) Embed(string) ([]float32, error) { return nil, fmt.Errorf("unreachable") }
+func (failingEmbedder) EmbedBatch([]string) ([][]float32, error) {
+	return nil, fmt.Errorf("unreachable")
+}
+func (failingEmbedder) Dimensions() int { return 12 }
+func (failingEmbedder) Model() string   { return "test:failing" }
+
+// openStoreAt opens the store in dir with the embedder selected by the environment
+func openStoreAt(t *testing.T, dir string) *Store {
+	t.Helper()
+	t.Setenv("PHLOEM_DATA_DIR", dir)
+	store, err := NewStore()
+	require.NoError(t, err)
+	return store
+}
+
+func TestReembed_SwitchingModels(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	t.Setenv("PHLOEM_AIR_GAPPED", "")
+	t.Setenv("PHLOEM_EMBEDDINGS", "local")
+
+	store := openStoreAt(t, dir)
+	for _, content := range []string{"Deploys run on Fridays", "The cache expires hourly", "Logs go to stderr"} {
+		_, err := store.Remember(ctx, content, nil, "")
+		require.NoError(t, err)
+	}
+	status, err := store.EmbeddingStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"local:enhanced-v1-512": 3}, status.ByModel)
+	assert.Zero(t, status.Pending)
+	store.Close()
+
+	// Switch to a self-hosted model with a different vector size
+	server := newEmbeddingServer(t, 8, true, false)
+	t.Setenv("PHLOEM_EMBEDDINGS", "http")
+	t.Setenv("PHLOEM_EMBED_URL", server.URL)
+	t.Setenv("PHLOEM_EMBED_MODEL", "bge-small")
+	store = openStoreAt(t, dir)
+	defer store.Close()
+
+	status, err = store.EmbeddingStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "http:bge-small", status.Model)
+	assert.Equal(t, 3, status.Pending)
+
+	results, err := store.Recall(ctx, "deploys", 10, nil)
+	require.NoError(t, err)
+	assert.Empty(t, results, "vectors from the old model must not be compared with the new one")
+
+	mem, err := store.Remember(ctx, "Deploys need a green build", nil, "")
+	require.NoError(t, err)
+	assert.Len(t, mem.Embedding, 8)
+
+	// Interrupted after one batch of two, then resumed
+	var progress []int
+	result, err := store.Reembed(ctx, ReembedOptions{BatchSize: 2, Limit: 2, Progress: func(done, total int) {
+		progress = append(progress, done)
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Reembedded)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, []int{2}, progress)
+
+	result, err = store.Reembed(ctx, ReembedOptions{BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Reembedded)
+	assert.Zero(t, result.Remaining)
+
+	results, err = store.Recall(ctx, "deploys", 10, nil)
+	require.NoError(t, err)
+	assert.Len(t, results, 4, "every memory should be comparable after re-embedding")
+}
+
+func TestReembed_StopsWhenEmbedderFails(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := store.Remember(ctx, "Stored with the local model", nil, "")
+	require.NoError(t, err)
+
+	store.embedder = NewFallbackEmbedder(failingEmbedder{})
+	result, err := store.Reembed(ctx, ReembedOptions{})
+	assert.ErrorIs(t, err, ErrEmbedderUnavailable)
+	assert.Zero(t, result.Reembedded)
+	assert.Equal(t, 1, result.Remaining)
+
+	status, err := store.EmbeddingStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.ByModel["local:enhanced-v1-512"], "nothing should be written")
+}
+
+func TestClaimLegacyEmbeddings(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	fits, err := store.Remember(ctx, "Legacy memory with a local vector", nil, "")
+	require.NoError(t, err)
+	other, err := store.Remember(ctx, "Legacy memory with an API vector", nil, "")
+	require.NoError(t, err)
+	failed, err := store.Remember(ctx, "Legacy memory whose embedding failed", nil, "")
+	require.NoError(t, err)
+
+	// As stored before models were recorded
+	store.db.Exec(`UPDATE memories SET embedding_model = ''`)
+	store.db.Exec(`UPDATE memories SET embedding = ? WHERE id = ?`, "[0.1,0.2,0.3]", other.ID)
+	store.db.Exec(`UPDATE memories SET embedding = ? WHERE id = ?`, fmt.Sprintf("[%s0]", strings.Repeat("0,", 511)), failed.ID)
+
+	n, err := store.claimLegacyEmbeddings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	models, err := store.embeddingModels(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, store.embedder.Model(), models[fits.ID])
+	assert.Empty(t, models[other.ID])
+	assert.Empty(t, models[failed.ID])
+}
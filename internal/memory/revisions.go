@@ -0,0 +1,219 @@
+// Package memory: in-place editing with revision history.
+// Update snapshots the current version into memory_revisions before changing a memory,
+// so every edit (including a revert) can itself be rolled back.
+
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/CanopyHQ/phloem/internal/redact"
+)
+
+// Revision is a prior version of a memory, recorded when the memory was updated.
+type Revision struct {
+	ID        string    `json:"id"`
+	MemoryID  string    `json:"memory_id"`
+	Revision  int       `json:"revision"` // 1 = original content, increasing with each edit
+	Content   string    `json:"content"`
+	Tags      []string  `json:"tags"`
+	Context   string    `json:"context"`
+	CreatedAt time.Time `json:"created_at"` // When this version was replaced
+}
+
+// MemoryUpdate describes the fields to change in Update. Nil fields are left unchanged.
+type MemoryUpdate struct {
+	Content *string
+	Tags    *[]string
+	Context *string
+}
+
+// Update edits a memory in place. The previous version is stored in memory_revisions;
+// when content changes the memory is re-embedded and its vec index entry refreshed.
+// Returns the updated memory, or an error if the memory does not exist.
+func (s *Store) Update(ctx context.Context, id string, upd MemoryUpdate) (*Memory, error) {
+	current, err := s.GetMemoryByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load memory: %w", err)
+	}
+	if current == nil {
+		return nil, fmt.Errorf("memory not found: %s", id)
+	}
+	id = current.ID // id may be an alias of a consolidated memory
+
+	updated := *current
+	var contentRedactions, contextRedactions []redact.Finding
+	if upd.Content != nil {
+		if *upd.Content == "" {
+			return nil, fmt.Errorf("content cannot be empty")
+		}
+		updated.Content, contentRedactions = s.scrub(*upd.Content)
+	}
+	if upd.Tags != nil {
+		updated.Tags = dedupeTags(*upd.Tags)
+	}
+	if upd.Context != nil {
+		updated.Context, contextRedactions = s.scrub(*upd.Context)
+	}
+
+	contentChanged := updated.Content != current.Content
+	if !contentChanged && updated.Context == current.Context && sameTags(updated.Tags, current.Tags) {
+		return current, nil // Nothing to do
+	}
+
+	var embeddingModel string
+	if contentChanged {
+		updated.Embedding, embeddingModel = s.embed(updated.Content)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin update: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Snapshot the current version
+	var lastRevision int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(revision), 0) FROM memory_revisions WHERE memory_id = ?`, id).Scan(&lastRevision); err != nil {
+		return nil, fmt.Errorf("failed to read revisions: %w", err)
+	}
+	now := time.Now()
+	oldTagsJSON, _ := json.Marshal(current.Tags)
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO memory_revisions (id, memory_id, revision, content, tags, context, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, generateID(), id, lastRevision+1, s.seal(current.Content), string(oldTagsJSON), s.seal(current.Context), now); err != nil {
+		return nil, fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	tagsJSON, _ := json.Marshal(updated.Tags)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE memories SET content = ?, content_hash = ?, tags = ?, context = ?, embedding = ?, updated_at = ?
+		WHERE id = ?
+	`, s.seal(updated.Content), s.hashContent(updated.Content), string(tagsJSON), s.seal(updated.Context), s.encodeEmbedding(updated.Embedding), now, id); err != nil {
+		return nil, fmt.Errorf("failed to update memory: %w", err)
+	}
+	if contentChanged {
+		if _, err := tx.ExecContext(ctx, `UPDATE memories SET embedding_model = ? WHERE id = ?`, embeddingModel, id); err != nil {
+			return nil, fmt.Errorf("failed to update memory: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM memory_tags WHERE memory_id = ?`, id); err != nil {
+		return nil, fmt.Errorf("failed to update tags: %w", err)
+	}
+	for _, tag := range updated.Tags {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO memory_tags (memory_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return nil, fmt.Errorf("failed to update tags: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit update: %w", err)
+	}
+	s.recordRedactions(ctx, id, RedactedContent, contentRedactions)
+	s.recordRedactions(ctx, id, RedactedContext, contextRedactions)
+	updated.Redactions = append(contentRedactions, contextRedactions...)
+
+	// Refresh vec index entry
+	if contentChanged {
+		if s.vecIdx != nil {
+			s.vecIdx.Insert(id, updated.Embedding, embeddingModel)
+		}
+		s.queueEmbedding(ctx, id, embeddingModel)
+	}
+
+	updated.UpdatedAt = now
+	return &updated, nil
+}
+
+// GetRevisions returns the prior versions of a memory, oldest first.
+func (s *Store) GetRevisions(ctx context.Context, memoryID string) ([]Revision, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, memory_id, revision, content, tags, context, created_at
+		FROM memory_revisions WHERE memory_id = ? ORDER BY revision ASC
+	`, memoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var r Revision
+		var tagsJSON, contextNull sql.NullString
+		if err := rows.Scan(&r.ID, &r.MemoryID, &r.Revision, &r.Content, &tagsJSON, &contextNull, &r.CreatedAt); err != nil {
+			continue
+		}
+		if tagsJSON.Valid {
+			_ = json.Unmarshal([]byte(tagsJSON.String), &r.Tags)
+		}
+		r.Content = s.open(r.Content)
+		if contextNull.Valid {
+			r.Context = s.open(contextNull.String)
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, nil
+}
+
+// RevertMemory restores a memory to the given revision. The version being replaced is
+// itself recorded as a new revision, so a revert can be undone.
+func (s *Store) RevertMemory(ctx context.Context, memoryID string, revision int) (*Memory, error) {
+	var r Revision
+	var tagsJSON, contextNull sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT content, tags, context FROM memory_revisions WHERE memory_id = ? AND revision = ?
+	`, memoryID, revision).Scan(&r.Content, &tagsJSON, &contextNull)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("revision %d not found for memory %s", revision, memoryID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tagsJSON.Valid {
+		_ = json.Unmarshal([]byte(tagsJSON.String), &r.Tags)
+	}
+	r.Content = s.open(r.Content)
+	if contextNull.Valid {
+		r.Context = s.open(contextNull.String)
+	}
+	if r.Tags == nil {
+		r.Tags = []string{}
+	}
+	return s.Update(ctx, memoryID, MemoryUpdate{Content: &r.Content, Tags: &r.Tags, Context: &r.Context})
+}
+
+// dedupeTags returns the tags sorted with duplicates and empty strings removed.
+func dedupeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// sameTags reports whether two tag lists contain the same set of tags.
+func sameTags(a, b []string) bool {
+	a, b = dedupeTags(a), dedupeTags(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_ContentRecordsRevision(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mem, err := store.Remember(ctx, "JWT tokens expire after 15 minutes", []string{"auth"}, "")
+	require.NoError(t, err)
+
+	content := "JWT tokens expire after 30 minutes"
+	updated, err := store.Update(ctx, mem.ID, MemoryUpdate{Content: &content})
+	require.NoError(t, err)
+	assert.Equal(t, content, updated.Content)
+	assert.Equal(t, []string{"auth"}, updated.Tags)
+
+	got, err := store.GetMemoryByID(ctx, mem.ID)
+	require.NoError(t, err)
+	assert.Equal(t, content, got.Content)
+	assert.NotEqual(t, mem.Embedding, got.Embedding, "content change should re-embed")
+
+	revisions, err := store.GetRevisions(ctx, mem.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, 1, revisions[0].Revision)
+	assert.Equal(t, "JWT tokens expire after 15 minutes", revisions[0].Content)
+
+	// The lexical index follows the edit
+	results, err := store.ftsIdx.Search("30", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, mem.ID, results[0].MemoryID)
+}
+
+func TestUpdate_Tags(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mem, err := store.Remember(ctx, "Use sqlc for queries", []string{"db"}, "")
+	require.NoError(t, err)
+
+	tags := []string{"decision", "db", "decision"}
+	updated, err := store.Update(ctx, mem.ID, MemoryUpdate{Tags: &tags})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db", "decision"}, updated.Tags)
+
+	tagged, err := store.List(ctx, 10, []string{"decision"})
+	require.NoError(t, err)
+	require.Len(t, tagged, 1)
+	assert.Equal(t, mem.ID, tagged[0].ID)
+}
+
+func TestUpdate_NotFound(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	content := "anything"
+	_, err := store.Update(context.Background(), "missing", MemoryUpdate{Content: &content})
+	assert.Error(t, err)
+}
+
+func TestUpdate_NoChangeSkipsRevision(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mem, err := store.Remember(ctx, "Unchanged", nil, "")
+	require.NoError(t, err)
+
+	content := "Unchanged"
+	_, err = store.Update(ctx, mem.ID, MemoryUpdate{Content: &content})
+	require.NoError(t, err)
+
+	revisions, err := store.GetRevisions(ctx, mem.ID)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+}
+
+func TestRevertMemory(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mem, err := store.Remember(ctx, "v1", nil, "")
+	require.NoError(t, err)
+	for _, c := range []string{"v2", "v3"} {
+		content := c
+		_, err := store.Update(ctx, mem.ID, MemoryUpdate{Content: &content})
+		require.NoError(t, err)
+	}
+
+	reverted, err := store.RevertMemory(ctx, mem.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", reverted.Content)
+
+	// The revert itself is recorded, so v3 can be restored
+	revisions, err := store.GetRevisions(ctx, mem.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, "v3", revisions[2].Content)
+
+	_, err = store.RevertMemory(ctx, mem.ID, 99)
+	assert.Error(t, err)
+}
+
+func TestForget_RemovesRevisions(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mem, err := store.Remember(ctx, "before", nil, "")
+	require.NoError(t, err)
+	content := "after"
+	_, err = store.Update(ctx, mem.ID, MemoryUpdate{Content: &content})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Forget(ctx, mem.ID))
+
+	revisions, err := store.GetRevisions(ctx, mem.ID)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+}
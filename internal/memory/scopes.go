@@ -0,0 +1,589 @@
+// Package memory: scope registry and hierarchy.
+// A scope is a slash-separated identifier: a remote host and repository path
+// ("github.com/owner/repo", "gitlab.example.com/group/sub/repo"), optionally followed by
+// a monorepo subdirectory, or git.LocalScopePrefix plus a path hash for repositories
+// without a remote. Every shorter prefix of at least two segments is an ancestor, so
+// recall from a package can include what was remembered for its repo and organization.
+// Scopes in use are registered in the scopes table with a type, metadata and description.
+// Renaming or merging a scope moves its whole subtree, so memories follow a repository
+// that was renamed or moved to another host.
+
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CanopyHQ/phloem/internal/git"
+)
+
+// Scope types recorded in the scopes table
+const (
+	ScopeTypeOrg    = "org"    // Owner, organization or group: "github.com/owner"
+	ScopeTypeRepo   = "repo"   // Repository: "github.com/owner/repo"
+	ScopeTypeSubdir = "subdir" // Directory of a monorepo: "github.com/owner/repo/services/api"
+	ScopeTypeLocal  = "local"  // Repository without a remote: "local/<hash>"
+)
+
+// Scope is a registered scope
+type Scope struct {
+	ID          string            `json:"id"`   // Scope identifier as stored on memories
+	Name        string            `json:"name"` // Human-readable name, e.g. "owner/repo"
+	Type        string            `json:"type"`
+	Description string            `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+
+	Memories   int  `json:"memories"`   // Memories stored directly in this scope
+	Registered bool `json:"registered"` // False for scopes only seen on memories
+}
+
+// ScopeDetail is a scope with its place in the hierarchy
+type ScopeDetail struct {
+	Scope
+	Ancestors     []string  `json:"ancestors,omitempty"` // Nearest first
+	Descendants   []Scope   `json:"descendants,omitempty"`
+	TotalMemories int       `json:"total_memories"` // Including descendants
+	Recent        []*Memory `json:"recent,omitempty"`
+}
+
+// ScopeMoveResult summarizes RenameScope and MergeScope
+type ScopeMoveResult struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Moved  int    `json:"moved"`  // Memories re-scoped
+	Merged int    `json:"merged"` // Memories folded into an identical memory already in the target
+	Scopes int    `json:"scopes"` // Registered scopes renamed
+}
+
+// ScopeAncestors returns the ancestors of scope, nearest first. A bare host is not a
+// scope, so "github.com/owner/repo" has the single ancestor "github.com/owner".
+func ScopeAncestors(scope string) []string {
+	segments := strings.Split(strings.Trim(scope, "/"), "/")
+	var ancestors []string
+	for n := len(segments) - 1; n >= 2; n-- {
+		ancestors = append(ancestors, strings.Join(segments[:n], "/"))
+	}
+	return ancestors
+}
+
+// ScopeChain returns scope followed by its ancestors, for RecallWithScopes. Empty for "".
+func ScopeChain(scope string) []string {
+	if scope == "" {
+		return nil
+	}
+	return append([]string{scope}, ScopeAncestors(scope)...)
+}
+
+// scopeFilter returns a SQL condition matching memories in any of scopes, where ""
+// matches unscoped memories (stored as NULL or an empty string)
+func scopeFilter(scopes []string) (string, []interface{}) {
+	args := make([]interface{}, len(scopes))
+	unscoped := false
+	for i, scope := range scopes {
+		args[i] = scope
+		unscoped = unscoped || scope == ""
+	}
+	condition := `scope IN (` + strings.TrimSuffix(strings.Repeat("?,", len(scopes)), ",") + `)`
+	if unscoped {
+		condition = `(` + condition + ` OR scope IS NULL)`
+	}
+	return condition, args
+}
+
+// RegisterScope records sc in the scopes table, updating name, type and metadata if it
+// is already registered. An empty description keeps the existing one.
+func (s *Store) RegisterScope(ctx context.Context, sc Scope) error {
+	if sc.ID == "" {
+		return fmt.Errorf("scope id is required")
+	}
+	if sc.Name == "" {
+		sc.Name = scopeName(sc.ID)
+	}
+	metadataJSON, _ := json.Marshal(sc.Metadata)
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO scopes (id, name, type, metadata, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			type = excluded.type,
+			metadata = excluded.metadata,
+			description = CASE WHEN excluded.description = '' THEN scopes.description ELSE excluded.description END,
+			updated_at = excluded.updated_at
+	`, sc.ID, sc.Name, sc.Type, string(metadataJSON), sc.Description, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to register scope %s: %w", sc.ID, err)
+	}
+	return nil
+}
+
+// RegisterRepository registers the scopes of a detected repository: its organization,
+// the repository itself and, when detected from a subdirectory, that directory.
+// Returns the most specific scope.
+func (s *Store) RegisterRepository(ctx context.Context, repo *git.Repository) (string, error) {
+	metadata := map[string]string{"path": repo.Path}
+	repoType := ScopeTypeLocal
+	if repo.Host != "" {
+		repoType = ScopeTypeRepo
+		metadata["host"] = repo.Host
+		metadata["remote"] = redactRemote(repo.Remote)
+
+		if err := s.RegisterScope(ctx, Scope{
+			ID:       repo.OrgScope(),
+			Type:     ScopeTypeOrg,
+			Metadata: map[string]string{"host": repo.Host},
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	name := scopeName(repo.Scope())
+	if repo.Host == "" {
+		name = filepath.Base(repo.Path)
+	}
+	if err := s.RegisterScope(ctx, Scope{ID: repo.Scope(), Name: name, Type: repoType, Metadata: metadata}); err != nil {
+		return "", err
+	}
+
+	if repo.Subdir != "" {
+		if err := s.RegisterScope(ctx, Scope{
+			ID:       repo.SubdirScope(),
+			Name:     name + "/" + repo.Subdir,
+			Type:     ScopeTypeSubdir,
+			Metadata: map[string]string{"path": filepath.Join(repo.Path, filepath.FromSlash(repo.Subdir)), "repo": repo.Scope()},
+		}); err != nil {
+			return "", err
+		}
+	}
+	return repo.SubdirScope(), nil
+}
+
+// DescribeScope sets the description of a registered scope
+func (s *Store) DescribeScope(ctx context.Context, id, description string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE scopes SET description = ?, updated_at = ? WHERE id = ?`, description, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to describe scope %s: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("scope not found: %s", id)
+	}
+	return nil
+}
+
+// GetScope returns a registered scope, or nil if it is not registered
+func (s *Store) GetScope(ctx context.Context, id string) (*Scope, error) {
+	var sc Scope
+	var metadataJSON sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, name, type, metadata, COALESCE(description, ''), created_at, updated_at FROM scopes WHERE id = ?
+	`, id).Scan(&sc.ID, &sc.Name, &sc.Type, &metadataJSON, &sc.Description, &sc.CreatedAt, &sc.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scope %s: %w", id, err)
+	}
+	if metadataJSON.Valid {
+		json.Unmarshal([]byte(metadataJSON.String), &sc.Metadata)
+	}
+	return &sc, nil
+}
+
+// ensureScope registers a scope first seen on a memory, inferring its type from the
+// identifier. Registered scopes are left as they are.
+func (s *Store) ensureScope(ctx context.Context, scope string) {
+	if scope == "" {
+		return
+	}
+	if existing, err := s.GetScope(ctx, scope); err != nil || existing != nil {
+		return
+	}
+	scopeType := ScopeTypeRepo
+	switch {
+	case strings.HasPrefix(scope, git.LocalScopePrefix):
+		scopeType = ScopeTypeLocal
+		if strings.Count(scope, "/") > 1 {
+			scopeType = ScopeTypeSubdir
+		}
+	case strings.Count(strings.Trim(scope, "/"), "/") == 1:
+		scopeType = ScopeTypeOrg
+	default:
+		// Below a registered repository it is a monorepo directory
+		for _, ancestor := range ScopeAncestors(scope) {
+			if parent, _ := s.GetScope(ctx, ancestor); parent != nil && (parent.Type == ScopeTypeRepo || parent.Type == ScopeTypeSubdir) {
+				scopeType = ScopeTypeSubdir
+				break
+			}
+		}
+	}
+	s.RegisterScope(ctx, Scope{ID: scope, Type: scopeType})
+}
+
+// scopeName is the scope without its host, e.g. "owner/repo"
+func scopeName(scope string) string {
+	if _, rest, ok := strings.Cut(scope, "/"); ok && rest != "" {
+		return rest
+	}
+	return scope
+}
+
+// redactRemote drops credentials from an HTTPS remote URL before it is stored
+func redactRemote(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil || u.Host == "" {
+		return remote
+	}
+	u.User = nil
+	return u.String()
+}
+
+// inSubtree is a SQL condition (with its arguments) matching column values equal to
+// scope or below it. Values below it sort between scope+"/" and scope+"0" ('0' follows
+// '/') byte by byte, as TEXT compares by default, so non-ASCII scopes, "_" and "%"
+// need no escaping and case is not folded as it would be by LIKE.
+func inSubtree(column, scope string) (string, []interface{}) {
+	return `(` + column + ` = ? OR (` + column + ` >= ? AND ` + column + ` < ?))`, []interface{}{scope, scope + "/", scope + "0"}
+}
+
+// isInSubtree reports whether scope is root or below it
+func isInSubtree(scope, root string) bool {
+	return scope == root || strings.HasPrefix(scope, root+"/")
+}
+
+// ListScopes returns every registered scope and every scope used by a memory, with
+// their memory counts, ordered by identifier
+func (s *Store) ListScopes(ctx context.Context) ([]Scope, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, type, metadata, COALESCE(description, ''), created_at, updated_at FROM scopes
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scopes: %w", err)
+	}
+	byID := make(map[string]*Scope)
+	for rows.Next() {
+		sc := &Scope{Registered: true}
+		var metadataJSON sql.NullString
+		if err := rows.Scan(&sc.ID, &sc.Name, &sc.Type, &metadataJSON, &sc.Description, &sc.CreatedAt, &sc.UpdatedAt); err != nil {
+			continue
+		}
+		if metadataJSON.Valid {
+			json.Unmarshal([]byte(metadataJSON.String), &sc.Metadata)
+		}
+		byID[sc.ID] = sc
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list scopes: %w", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT scope, COUNT(*) FROM memories WHERE scope IS NOT NULL AND scope != '' GROUP BY scope
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count memories by scope: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			continue
+		}
+		sc, ok := byID[id]
+		if !ok {
+			sc = &Scope{ID: id, Name: scopeName(id)}
+			byID[id] = sc
+		}
+		sc.Memories = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count memories by scope: %w", err)
+	}
+
+	scopes := make([]Scope, 0, len(byID))
+	for _, sc := range byID {
+		scopes = append(scopes, *sc)
+	}
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i].ID < scopes[j].ID })
+	return scopes, nil
+}
+
+// ShowScope returns a scope with its ancestors, descendants and up to recent of its
+// newest memories. Fails if the scope is neither registered nor used by a memory.
+func (s *Store) ShowScope(ctx context.Context, id string, recent int) (*ScopeDetail, error) {
+	scopes, err := s.ListScopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	detail := &ScopeDetail{Ancestors: ScopeAncestors(id)}
+	found := false
+	for _, sc := range scopes {
+		switch {
+		case sc.ID == id:
+			detail.Scope = sc
+			found = true
+		case isInSubtree(sc.ID, id):
+			detail.Descendants = append(detail.Descendants, sc)
+		default:
+			continue
+		}
+		detail.TotalMemories += sc.Memories
+	}
+	if !found {
+		if len(detail.Descendants) == 0 {
+			return nil, fmt.Errorf("scope not found: %s", id)
+		}
+		detail.Scope = Scope{ID: id, Name: scopeName(id)} // Only reachable as a parent
+	}
+
+	if recent > 0 && detail.Memories > 0 {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT id, content, tags, context, scope, embedding, created_at, updated_at, COALESCE(utility_score, 1.0)
+			FROM memories WHERE scope = ? ORDER BY created_at DESC LIMIT ?
+		`, id, recent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list memories in scope %s: %w", id, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			mem, err := s.scanMemory(rows)
+			if err != nil {
+				continue
+			}
+			detail.Recent = append(detail.Recent, mem)
+		}
+	}
+	return detail, nil
+}
+
+// scopeInUse reports whether scope, or anything below it, is registered or holds memories
+func (s *Store) scopeInUse(ctx context.Context, scope string) (bool, error) {
+	memCond, memArgs := inSubtree("scope", scope)
+	idCond, idArgs := inSubtree("id", scope)
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM memories WHERE `+memCond+`) + (SELECT COUNT(*) FROM scopes WHERE `+idCond+`)
+	`, append(memArgs, idArgs...)...).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up scope %s: %w", scope, err)
+	}
+	return n > 0, nil
+}
+
+// RenameScope moves a scope and everything below it to a new identifier, e.g. after a
+// repository was renamed or moved to another host. The target must not be in use;
+// use MergeScope to combine two existing scopes.
+func (s *Store) RenameScope(ctx context.Context, from, to string) (*ScopeMoveResult, error) {
+	inUse, err := s.scopeInUse(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	if inUse {
+		return nil, fmt.Errorf("scope %s already exists; merge into it instead", to)
+	}
+	return s.moveScope(ctx, from, to)
+}
+
+// MergeScope moves the memories of a scope and everything below it into another scope.
+// A memory whose content is already stored in the target is folded into that memory,
+// as consolidation does.
+func (s *Store) MergeScope(ctx context.Context, from, into string) (*ScopeMoveResult, error) {
+	return s.moveScope(ctx, from, into)
+}
+
+func (s *Store) moveScope(ctx context.Context, from, to string) (*ScopeMoveResult, error) {
+	from, to = strings.Trim(from, "/"), strings.Trim(to, "/")
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("both scopes are required")
+	}
+	if from == to {
+		return nil, fmt.Errorf("scope %s cannot be moved onto itself", from)
+	}
+	if isInSubtree(to, from) {
+		return nil, fmt.Errorf("cannot move scope %s below itself (%s)", from, to)
+	}
+	inUse, err := s.scopeInUse(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if !inUse {
+		return nil, fmt.Errorf("scope not found: %s", from)
+	}
+	// The registry is read first: moving memories does not change it
+	registered, err := s.ListScopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Everything below moves in one transaction, so a failure leaves the scope whole
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin scope move: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &ScopeMoveResult{From: from, To: to}
+	rename := func(scope string) string { return to + strings.TrimPrefix(scope, from) }
+
+	// Memories, folding content-identical ones into the memory already in the target
+	cond, args := inSubtree("scope", from)
+	rows, err := tx.QueryContext(ctx, `SELECT id, scope, content_hash FROM memories WHERE `+cond, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories in scope %s: %w", from, err)
+	}
+	type moving struct{ id, scope, hash string }
+	var memories []moving
+	for rows.Next() {
+		var m moving
+		var hash sql.NullString
+		if err := rows.Scan(&m.id, &m.scope, &hash); err != nil {
+			continue
+		}
+		m.hash = hash.String
+		memories = append(memories, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list memories in scope %s: %w", from, err)
+	}
+
+	var merged []string
+	for _, m := range memories {
+		target := rename(m.scope)
+		var existingID string
+		if m.hash != "" {
+			err := tx.QueryRowContext(ctx, `SELECT id FROM memories WHERE content_hash = ? AND scope = ? AND id != ?`,
+				m.hash, target, m.id).Scan(&existingID)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, fmt.Errorf("failed to check for duplicates in %s: %w", target, err)
+			}
+		}
+		if existingID != "" {
+			canonical, err := s.getMemoryByID(ctx, tx, existingID)
+			if err != nil {
+				return nil, err
+			}
+			dup, err := s.getMemoryByID(ctx, tx, m.id)
+			if err != nil {
+				return nil, err
+			}
+			if canonical != nil && dup != nil {
+				if err := s.mergeIntoTx(ctx, tx, canonical, dup); err != nil {
+					return nil, fmt.Errorf("failed to merge %s into %s: %w", m.id, existingID, err)
+				}
+				merged = append(merged, dup.ID)
+				result.Merged++
+				continue
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE memories SET scope = ? WHERE id = ?`, target, m.id); err != nil {
+			return nil, fmt.Errorf("failed to re-scope memory %s: %w", m.id, err)
+		}
+		result.Moved++
+	}
+
+	// Registry entries; where the target is already registered it is kept as it is
+	exists := make(map[string]bool)
+	for _, sc := range registered {
+		if sc.Registered {
+			exists[sc.ID] = true
+		}
+	}
+	for _, sc := range registered {
+		if !sc.Registered || !isInSubtree(sc.ID, from) {
+			continue
+		}
+		newID := rename(sc.ID)
+		if exists[newID] {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM scopes WHERE id = ?`, sc.ID); err != nil {
+				return nil, fmt.Errorf("failed to remove scope %s: %w", sc.ID, err)
+			}
+			continue
+		}
+		if sc.Name == scopeName(sc.ID) {
+			sc.Name = scopeName(newID)
+		}
+		if repoScope, ok := sc.Metadata["repo"]; ok && isInSubtree(repoScope, from) {
+			sc.Metadata["repo"] = rename(repoScope)
+		}
+		metadataJSON, _ := json.Marshal(sc.Metadata)
+		if _, err := tx.ExecContext(ctx, `UPDATE scopes SET id = ?, name = ?, metadata = ?, updated_at = ? WHERE id = ?`,
+			newID, sc.Name, string(metadataJSON), time.Now(), sc.ID); err != nil {
+			return nil, fmt.Errorf("failed to rename scope %s: %w", sc.ID, err)
+		}
+		result.Scopes++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit scope move: %w", err)
+	}
+	if s.vecIdx != nil {
+		for _, id := range merged {
+			s.vecIdx.Delete(id)
+		}
+	}
+	s.ensureScope(ctx, to)
+	return result, nil
+}
+
+// DeleteScope removes a scope and everything below it from the registry. Its memories
+// become unscoped, or are forgotten when forgetMemories is set. Returns how many
+// memories were affected.
+func (s *Store) DeleteScope(ctx context.Context, scope string, forgetMemories bool) (int, error) {
+	scope = strings.Trim(scope, "/")
+	if scope == "" {
+		return 0, fmt.Errorf("scope is required")
+	}
+	inUse, err := s.scopeInUse(ctx, scope)
+	if err != nil {
+		return 0, err
+	}
+	if !inUse {
+		return 0, fmt.Errorf("scope not found: %s", scope)
+	}
+
+	cond, args := inSubtree("scope", scope)
+	affected := 0
+	if forgetMemories {
+		rows, err := s.db.QueryContext(ctx, `SELECT id FROM memories WHERE `+cond, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list memories in scope %s: %w", scope, err)
+		}
+		var ids []string
+		for rows.Next() {
+			var id string
+			if rows.Scan(&id) == nil {
+				ids = append(ids, id)
+			}
+		}
+		rows.Close()
+		for _, id := range ids {
+			if err := s.Forget(ctx, id); err != nil {
+				return affected, err
+			}
+			affected++
+		}
+	} else {
+		res, err := s.db.ExecContext(ctx, `UPDATE memories SET scope = NULL WHERE `+cond, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unscope memories in %s: %w", scope, err)
+		}
+		n, _ := res.RowsAffected()
+		affected = int(n)
+	}
+
+	idCond, idArgs := inSubtree("id", scope)
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM scopes WHERE `+idCond, idArgs...); err != nil {
+		return affected, fmt.Errorf("failed to remove scope %s: %w", scope, err)
+	}
+	return affected, nil
+}
@@ -0,0 +1,97 @@
+// Package memory: memories grouped by where they came from.
+// Imported memories carry a source (e.g. "graft:name:author") and the ID they had in
+// that source, so an import can later be listed, removed or upgraded as a unit without
+// touching memories the user created.
+
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// SourceSummary is one import source and how many memories it contributed.
+type SourceSummary struct {
+	Source string `json:"source"`
+	Count  int    `json:"count"`
+}
+
+// Sources returns the distinct memory sources starting with prefix (e.g. "graft:"),
+// ordered by source. An empty prefix returns every non-empty source.
+func (s *Store) Sources(ctx context.Context, prefix string) ([]SourceSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT source, COUNT(*) FROM memories
+		WHERE source IS NOT NULL AND source != '' AND substr(source, 1, ?) = ?
+		GROUP BY source ORDER BY source
+	`, len(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []SourceSummary
+	for rows.Next() {
+		var src SourceSummary
+		if err := rows.Scan(&src.Source, &src.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan source: %w", err)
+		}
+		sources = append(sources, src)
+	}
+	return sources, rows.Err()
+}
+
+// ListBySource returns every memory imported from source, oldest first,
+// with Source and SourceRef populated.
+func (s *Store) ListBySource(ctx context.Context, source string) ([]*Memory, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, content, tags, context, scope, embedding, created_at, updated_at,
+			COALESCE(utility_score, 1.0), COALESCE(source_ref, '')
+		FROM memories WHERE source = ? ORDER BY created_at ASC
+	`, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories for source: %w", err)
+	}
+	defer rows.Close()
+
+	var memories []*Memory
+	for rows.Next() {
+		var mem Memory
+		var tagsJSON string
+		var embeddingBlob []byte
+		var contextNull, scopeNull sql.NullString
+		if err := rows.Scan(&mem.ID, &mem.Content, &tagsJSON, &contextNull, &scopeNull, &embeddingBlob,
+			&mem.CreatedAt, &mem.UpdatedAt, &mem.UtilityScore, &mem.SourceRef); err != nil {
+			continue
+		}
+		mem.Content = s.open(mem.Content)
+		mem.Context = s.open(contextNull.String)
+		mem.Scope = scopeNull.String
+		mem.Source = source
+		json.Unmarshal([]byte(tagsJSON), &mem.Tags)
+		mem.Embedding, _ = decodeEmbedding(embeddingBlob)
+		memories = append(memories, &mem)
+	}
+	return memories, rows.Err()
+}
+
+// ForgetSource deletes every memory imported from source and returns how many were removed.
+// Memories from other sources, including user-created duplicates, are left alone.
+func (s *Store) ForgetSource(ctx context.Context, source string) (int, error) {
+	if source == "" {
+		return 0, fmt.Errorf("source is required")
+	}
+	memories, err := s.ListBySource(ctx, source)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, m := range memories {
+		if err := s.Forget(ctx, m.ID); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSources_ListAndForget(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	const source = "graft:Go Patterns:Jane"
+	_, err := store.Import(ctx, Memory{ID: "g1", Content: "Prefer table-driven tests", Source: source})
+	require.NoError(t, err)
+	// Collides with g1, so it is stored under a new ID but keeps its graft ID as the ref
+	id2, err := store.Import(ctx, Memory{ID: "g1", Content: "Wrap errors with %w", Source: source})
+	require.NoError(t, err)
+	require.NotEqual(t, "g1", id2)
+	_, err = store.Import(ctx, Memory{ID: "o1", Content: "Use structured logging", Source: "graft:Other:Bob"})
+	require.NoError(t, err)
+	user, err := store.Remember(ctx, "My own note about errors", nil, "")
+	require.NoError(t, err)
+
+	sources, err := store.Sources(ctx, "graft:")
+	require.NoError(t, err)
+	assert.Equal(t, []SourceSummary{{Source: "graft:Go Patterns:Jane", Count: 2}, {Source: "graft:Other:Bob", Count: 1}}, sources)
+
+	installed, err := store.ListBySource(ctx, source)
+	require.NoError(t, err)
+	require.Len(t, installed, 2)
+	refs := map[string]string{}
+	for _, m := range installed {
+		assert.Equal(t, source, m.Source)
+		refs[m.Content] = m.SourceRef
+	}
+	assert.Equal(t, "g1", refs["Wrap errors with %w"])
+
+	removed, err := store.ForgetSource(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	sources, err = store.Sources(ctx, "graft:")
+	require.NoError(t, err)
+	assert.Len(t, sources, 1)
+	kept, err := store.GetMemoryByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, kept, "user memories must survive removing a graft")
+}
@@ -19,6 +19,11 @@ type ComposeResult struct {
 // Compose recalls memories for multiple queries and merges them (dedupe by ID, best score wins).
 // Returns combined memories and a short explanation for how the result was derived.
 func (s *Store) Compose(ctx context.Context, queries []string, limit int) (*ComposeResult, error) {
+	return s.ComposeWithScopes(ctx, queries, limit, nil)
+}
+
+// ComposeWithScopes is Compose recalling only from scopes (see RecallWithScopes).
+func (s *Store) ComposeWithScopes(ctx context.Context, queries []string, limit int, scopes []string) (*ComposeResult, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -41,7 +46,7 @@ func (s *Store) Compose(ctx context.Context, queries []string, limit int) (*Comp
 		if q == "" {
 			continue
 		}
-		mems, err := s.Recall(ctx, q, limit*2, nil)
+		mems, err := s.RecallWithScopes(ctx, q, limit*2, nil, scopes)
 		if err != nil {
 			return nil, fmt.Errorf("recall query %q: %w", q, err)
 		}
@@ -74,13 +79,18 @@ func sortBySimilarity(mems []*Memory) {
 // PrefetchSuggest returns memories likely needed next given current context (e.g. open file path or last query).
 // Simple implementation: recall on currentContext and return as suggested preload.
 func (s *Store) PrefetchSuggest(ctx context.Context, currentContext string, limit int) ([]*Memory, error) {
+	return s.PrefetchSuggestWithScopes(ctx, currentContext, limit, nil)
+}
+
+// PrefetchSuggestWithScopes is PrefetchSuggest recalling only from scopes.
+func (s *Store) PrefetchSuggestWithScopes(ctx context.Context, currentContext string, limit int, scopes []string) ([]*Memory, error) {
 	if limit <= 0 {
 		limit = 5
 	}
 	if limit > 20 {
 		limit = 20
 	}
-	return s.Recall(ctx, currentContext, limit, nil)
+	return s.RecallWithScopes(ctx, currentContext, limit, nil, scopes)
 }
 
 // DreamStats holds stats from a dream run.
@@ -8,15 +8,20 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/CanopyHQ/phloem/internal/memory/causal"
+	"github.com/CanopyHQ/phloem/internal/redact"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -27,7 +32,10 @@ type Citation struct {
 	FilePath   string    `json:"file_path"`            // Path to the file
 	StartLine  int       `json:"start_line,omitempty"` // Starting line number
 	EndLine    int       `json:"end_line,omitempty"`   // Ending line number
-	CommitSHA  string    `json:"commit_sha,omitempty"` // Git commit SHA when citation was created
+	RepoRoot   string    `json:"repo_root,omitempty"`  // Work tree FilePath is relative to, when cited inside a git repo
+	Symbol     string    `json:"symbol,omitempty"`     // Cited Go symbol (e.g. "RateLimiter.Allow"), instead of a fixed line range
+	ASTHash    string    `json:"ast_hash,omitempty"`   // Normalized AST hash of Symbol when cited
+	CommitSHA  string    `json:"commit_sha,omitempty"` // Git commit the citation is anchored to (HEAD when added or last verified)
 	Content    string    `json:"content,omitempty"`    // Snapshot of cited content for verification
 	Confidence float64   `json:"confidence"`           // 0.0-1.0, decays over time
 	VerifiedAt time.Time `json:"verified_at"`          // Last verification time
@@ -40,7 +48,7 @@ type Memory struct {
 	Content      string     `json:"content"`
 	Tags         []string   `json:"tags"`
 	Context      string     `json:"context"`
-	Scope        string     `json:"scope,omitempty"` // Repository scope (e.g., "github.com/owner/repo")
+	Scope        string     `json:"scope,omitempty"` // Scope identifier (e.g., "github.com/owner/repo"; see scopes.go)
 	Embedding    []float32  `json:"embedding,omitempty"`
 	Citations    []Citation `json:"citations,omitempty"` // Linked citations
 	Confidence   float64    `json:"confidence"`          // Aggregate confidence from citations
@@ -49,6 +57,12 @@ type Memory struct {
 	Similarity   float64    `json:"similarity,omitempty"`    // Set during recall
 	UtilityScore float64    `json:"utility_score,omitempty"` // 0.0-1.0 from memory critic; default 1.0
 	Source       string     `json:"source,omitempty"`        // Attribution: "graft:name:author" or "user" or "sync"
+	SourceRef    string     `json:"source_ref,omitempty"`    // ID the memory had in its source (set by ListBySource)
+
+	Redactions []redact.Finding `json:"redactions,omitempty"` // Secrets removed from the input when it was stored
+
+	SupersededBy   string `json:"superseded_by,omitempty"`   // Set during recall when a newer memory supersedes this one
+	ContradictedBy string `json:"contradicted_by,omitempty"` // Set during recall when a newer memory contradicts this one
 }
 
 // Edge represents a directed edge between memories (temporal, causal, or semantic)
@@ -56,7 +70,7 @@ type Edge struct {
 	ID        string    `json:"id"`
 	SourceID  string    `json:"source_id"`
 	TargetID  string    `json:"target_id"`
-	EdgeType  string    `json:"edge_type"` // temporal, causal, semantic
+	EdgeType  string    `json:"edge_type"` // temporal, causal, semantic, supersedes, contradicts
 	Payload   string    `json:"payload,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -69,6 +83,27 @@ type Store struct {
 
 	// Vector index for fast KNN recall (nil if sqlite-vec unavailable)
 	vecIdx *vecIndex
+
+	// Full-text index for lexical and hybrid recall
+	ftsIdx *ftsIndex
+
+	// Persistent cache of API embeddings (nil when disabled, see embedcache.go)
+	embedCache *embeddingCache
+
+	// How new vectors are stored (see embedblob.go)
+	embedFormat embeddingFormat
+
+	// Memories waiting for the embedder to recover (see pending.go)
+	pendingEmbeds atomic.Int64
+	draining      atomic.Bool
+
+	// Column encryption (nil when the database is not encrypted, see encryption.go)
+	cipher *fieldCipher
+}
+
+// DataDir returns the directory holding the database and other Phloem state
+func (s *Store) DataDir() string {
+	return s.dataDir
 }
 
 // GetDB returns the underlying SQL database handle
@@ -78,50 +113,124 @@ func (s *Store) GetDB() *sql.DB {
 
 // NewStore creates a new memory store
 func NewStore() (*Store, error) {
+	store, dbPath, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	db, dataDir := store.db, store.dataDir
+	store.embedder = GetEmbedder()
+
+	// Vectors stored as JSON by older versions, or in the other binary format
+	if n, saved, err := store.migrateEmbeddings(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Embedding storage migration incomplete: %v\n", err)
+	} else if n > 0 {
+		if saved > 0 {
+			fmt.Fprintf(os.Stderr, "🧠 Stored %d embeddings as %s, %.1f KB smaller\n", n, store.embedFormat, float64(saved)/1024)
+		} else {
+			fmt.Fprintf(os.Stderr, "🧠 Stored %d embeddings as %s\n", n, store.embedFormat)
+		}
+		store.compact()
+	}
+
+	// Content hashes are keyed once the encryption key is known
+	store.embedCache = newEmbeddingCache(db, embeddingCacheSize(), store.embedFormat)
+	store.embedder = withEmbeddingCache(store.embedder, store.embedCache, store.hashContent)
+
+	// Vectors stored before models were recorded belong to the current model if they fit
+	if n, err := store.claimLegacyEmbeddings(context.Background()); err == nil && n > 0 {
+		fmt.Fprintf(os.Stderr, "🧠 Recorded embedding model %s for %d memories\n", store.embedder.Model(), n)
+	}
+	if n, err := store.pendingReembed(context.Background()); err == nil && n > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  %d memories were embedded with another model; run `phloem reembed` to make them searchable by meaning\n", n)
+	}
+	if n, err := store.PendingEmbeddings(context.Background()); err == nil {
+		store.pendingEmbeds.Store(int64(n))
+	}
+
+	// Initialize the vector index (sqlite-vec, or in-process HNSW) for fast KNN recall
+	store.vecIdx = newVecIndex(db, dataDir, store.embedder.Dimensions(), store.embedder.Model())
+	if store.vecIdx.available {
+		if n, err := store.vecIdx.Backfill(db); err == nil && n > 0 {
+			fmt.Fprintf(os.Stderr, "🔍 Backfilled %d memories into vec index\n", n)
+		}
+	}
+
+	// Initialize full-text index for lexical/hybrid recall.
+	// Encrypted databases have none: it would hold a plaintext copy of every memory.
+	if store.cipher != nil {
+		store.ftsIdx = &ftsIndex{db: db}
+	} else {
+		store.ftsIdx = newFTSIndex(db)
+		if n, err := store.ftsIdx.Backfill(); err == nil && n > 0 {
+			fmt.Fprintf(os.Stderr, "🔍 Backfilled %d memories into full-text index\n", n)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "📁 Memory store: %s\n", dbPath)
+	return store, nil
+}
+
+// NewCitationStore opens the memory store for citation verification only, as git
+// hooks do after every commit. It skips the embedder (which may probe a server), the
+// embedding cache and the vector and full-text indexes, so it opens without network
+// access; recall, remember and anything else that embeds or searches needs NewStore.
+func NewCitationStore() (*Store, error) {
+	store, _, err := openStore()
+	return store, err
+}
+
+// openStore opens the database in the data directory with its schema and encryption
+// key: the part of NewStore that every caller needs
+func openStore() (*Store, string, error) {
 	// Determine data directory
 	dataDir := os.Getenv("PHLOEM_DATA_DIR")
 	if dataDir == "" {
 		home, err := os.UserHomeDir()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get home dir: %w", err)
+			return nil, "", fmt.Errorf("failed to get home dir: %w", err)
 		}
 		dataDir = filepath.Join(home, ".phloem")
 	}
 
 	// Create directory
 	if err := os.MkdirAll(dataDir, 0700); err != nil {
-		return nil, fmt.Errorf("failed to create data dir: %w", err)
+		return nil, "", fmt.Errorf("failed to create data dir: %w", err)
 	}
 
 	// Open database
 	dbPath := filepath.Join(dataDir, "memories.db")
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL")
+	// busy_timeout lets concurrent writers (HTTP clients, multiple processes) wait instead of failing
+	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000")
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, "", fmt.Errorf("failed to open database: %w", err)
 	}
 
 	store := &Store{
-		db:       db,
-		dataDir:  dataDir,
-		embedder: GetEmbedder(),
+		db:          db,
+		dataDir:     dataDir,
+		embedFormat: embeddingStorageFormat(),
 	}
 
 	// Initialize schema
 	if err := store.initSchema(); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to init schema: %w", err)
+		return nil, "", fmt.Errorf("failed to init schema: %w", err)
 	}
 
-	// Initialize sqlite-vec vector index for fast KNN recall
-	store.vecIdx = newVecIndex(db, store.embedder.Dimensions())
-	if store.vecIdx.available {
-		if n, err := store.vecIdx.Backfill(db); err == nil && n > 0 {
-			fmt.Fprintf(os.Stderr, "🔍 Backfilled %d memories into vec index\n", n)
+	// Encrypted databases need their key before anything is read
+	encCfg, err := loadEncryptionConfig(context.Background(), db)
+	if err != nil {
+		db.Close()
+		return nil, "", err
+	}
+	if encCfg != nil {
+		if store.cipher, err = resolveEncryptionKey(dataDir, encCfg); err != nil {
+			db.Close()
+			return nil, "", err
 		}
 	}
 
-	fmt.Fprintf(os.Stderr, "📁 Memory store: %s\n", dbPath)
-	return store, nil
+	return store, dbPath, nil
 }
 
 // initSchema creates the database tables
@@ -194,6 +303,10 @@ func (s *Store) initSchema() error {
 	// Migrate: Add utility_score for memory critic (Stage 3); default 1.0 = full weight in recall
 	_, _ = s.db.Exec(`ALTER TABLE memories ADD COLUMN utility_score REAL DEFAULT 1.0`)
 
+	// Migrate: Embedding model of each memory's vector ('' if unknown; see reembed.go)
+	_, _ = s.db.Exec(`ALTER TABLE memories ADD COLUMN embedding_model TEXT DEFAULT ''`)
+	_, _ = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_memories_embedding_model ON memories(embedding_model)`)
+
 	// Migrate: Add scope support for repo-scoped memories
 	_, _ = s.db.Exec(`ALTER TABLE memories ADD COLUMN scope TEXT`)
 	_, _ = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_memories_scope ON memories(scope)`)
@@ -211,9 +324,84 @@ func (s *Store) initSchema() error {
 	`)
 	_, _ = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_scopes_name ON scopes(name)`)
 	_, _ = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_scopes_type ON scopes(type)`)
+	// Migrate: Add a free-text description to scopes (see scopes.go)
+	_, _ = s.db.Exec(`ALTER TABLE scopes ADD COLUMN description TEXT DEFAULT ''`)
 
 	// Migrate: Add source column for graft attribution tracking
 	_, _ = s.db.Exec(`ALTER TABLE memories ADD COLUMN source TEXT DEFAULT ''`)
+	// Migrate: ID a memory had in its source, so re-imports can match it (see sources.go)
+	_, _ = s.db.Exec(`ALTER TABLE memories ADD COLUMN source_ref TEXT DEFAULT ''`)
+	_, _ = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_memories_source ON memories(source)`)
+
+	// Migrate: Work tree a citation's file_path is relative to (see citations.go)
+	_, _ = s.db.Exec(`ALTER TABLE citations ADD COLUMN repo_root TEXT DEFAULT ''`)
+	// Migrate: Go symbol a citation tracks and its normalized AST hash (see symbol_citations.go)
+	_, _ = s.db.Exec(`ALTER TABLE citations ADD COLUMN symbol TEXT DEFAULT ''`)
+	_, _ = s.db.Exec(`ALTER TABLE citations ADD COLUMN ast_hash TEXT DEFAULT ''`)
+
+	// Create memory_revisions table (prior versions kept by Update)
+	_, _ = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS memory_revisions (
+			id TEXT PRIMARY KEY,
+			memory_id TEXT NOT NULL,
+			revision INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			tags TEXT,
+			context TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (memory_id) REFERENCES memories(id) ON DELETE CASCADE
+		)
+	`)
+	_, _ = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_memory_revisions_memory ON memory_revisions(memory_id, revision)`)
+
+	// Create memory_aliases table (IDs folded into another memory by Consolidate)
+	_, _ = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS memory_aliases (
+			alias_id TEXT PRIMARY KEY,
+			memory_id TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	_, _ = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_memory_aliases_memory ON memory_aliases(memory_id)`)
+
+	// Create memory_redactions table (secrets scrubbed from stored text, see redaction.go)
+	_, _ = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS memory_redactions (
+			id TEXT PRIMARY KEY,
+			memory_id TEXT NOT NULL,
+			field TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	_, _ = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_memory_redactions_memory ON memory_redactions(memory_id)`)
+
+	// Create embedding_cache table (vectors by content hash and model, see embedcache.go)
+	_, _ = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS embedding_cache (
+			content_hash TEXT NOT NULL,
+			model TEXT NOT NULL,
+			embedding BLOB NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_used_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (content_hash, model)
+		)
+	`)
+	_, _ = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_embedding_cache_last_used ON embedding_cache(last_used_at)`)
+
+	// Create pending_embeddings table (memories whose embedding failed, see pending.go)
+	_, _ = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS pending_embeddings (
+			memory_id TEXT PRIMARY KEY,
+			queued_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			attempts INTEGER DEFAULT 0,
+			last_error TEXT
+		)
+	`)
+
+	// Create settings table (store-wide configuration such as encryption)
+	_, _ = s.db.Exec(`CREATE TABLE IF NOT EXISTS settings (key TEXT PRIMARY KEY, value TEXT NOT NULL)`)
 
 	return nil
 }
@@ -301,28 +489,44 @@ func (s *Store) GetIdentityProfile(ctx context.Context) ([]*Memory, error) {
 }
 
 // GetMemoryByID returns a single memory by ID, or nil if not found.
+// IDs merged away by Consolidate resolve to the memory they were merged into.
 func (s *Store) GetMemoryByID(ctx context.Context, id string) (*Memory, error) {
+	return s.getMemoryByID(ctx, s.db, id)
+}
+
+// rowQuerier is a *sql.DB, or a *sql.Tx to read what the transaction wrote
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *Store) getMemoryByID(ctx context.Context, q rowQuerier, id string) (*Memory, error) {
 	if id == "" {
 		return nil, nil
 	}
-	row := s.db.QueryRowContext(ctx, `
+	row := q.QueryRowContext(ctx, `
 		SELECT id, content, tags, context, scope, embedding, created_at, updated_at, COALESCE(utility_score, 1.0)
 		FROM memories WHERE id = ?
 	`, id)
 	// Use a single row scanner; scanMemory expects *sql.Rows, so we need a small adapter or duplicate scan logic.
 	var mem Memory
-	var tagsJSON, embeddingJSON string
+	var tagsJSON string
+	var embeddingBlob []byte
 	var contextNull, scopeNull sql.NullString
 	var utilityNull sql.NullFloat64
-	err := row.Scan(&mem.ID, &mem.Content, &tagsJSON, &contextNull, &scopeNull, &embeddingJSON, &mem.CreatedAt, &mem.UpdatedAt, &utilityNull)
+	err := row.Scan(&mem.ID, &mem.Content, &tagsJSON, &contextNull, &scopeNull, &embeddingBlob, &mem.CreatedAt, &mem.UpdatedAt, &utilityNull)
 	if err == sql.ErrNoRows {
-		return nil, nil
+		target, aliasErr := resolveAlias(ctx, q, id)
+		if aliasErr != nil || target == "" || target == id {
+			return nil, aliasErr
+		}
+		return s.getMemoryByID(ctx, q, target)
 	}
 	if err != nil {
 		return nil, err
 	}
+	mem.Content = s.open(mem.Content)
 	if contextNull.Valid {
-		mem.Context = contextNull.String
+		mem.Context = s.open(contextNull.String)
 	}
 	if scopeNull.Valid {
 		mem.Scope = scopeNull.String
@@ -333,7 +537,7 @@ func (s *Store) GetMemoryByID(ctx context.Context, id string) (*Memory, error) {
 		mem.UtilityScore = 1.0
 	}
 	_ = json.Unmarshal([]byte(tagsJSON), &mem.Tags)
-	_ = json.Unmarshal([]byte(embeddingJSON), &mem.Embedding)
+	mem.Embedding, _ = decodeEmbedding(embeddingBlob)
 	return &mem, nil
 }
 
@@ -519,12 +723,14 @@ func (s *Store) RunMemoryCritic(ctx context.Context) error {
 // NightlyCurationResult summarizes the outcome of RunNightlyCuration.
 type NightlyCurationResult struct {
 	DecayedCitations int
+	Consolidated     int
 	DreamsEdgesAdded int
 	Error            string
 }
 
-// RunNightlyCuration runs the full offline curation pass (Stage 3): decay citations, update utility from confidence, link similar memories.
-// Call periodically (e.g. nightly). Does not delete memories; only adjusts weights and adds semantic edges.
+// RunNightlyCuration runs the full offline curation pass (Stage 3): decay citations, update utility from confidence,
+// merge near-duplicates, link similar memories. Call periodically (e.g. nightly). Merged duplicates are removed
+// but their IDs keep resolving to the canonical memory; nothing else is deleted.
 func (s *Store) RunNightlyCuration(ctx context.Context) (NightlyCurationResult, error) {
 	var result NightlyCurationResult
 	decayed, err := s.DecayCitations(ctx)
@@ -537,6 +743,13 @@ func (s *Store) RunNightlyCuration(ctx context.Context) (NightlyCurationResult,
 		result.Error = err.Error()
 		return result, err
 	}
+	// Consolidate before dreaming so duplicates aren't linked to each other
+	consolidated, err := s.Consolidate(ctx, ConsolidateOptions{})
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Consolidated = consolidated.Merged
 	edgesAdded, err := s.RunMemoryDreams(ctx, 30, 3)
 	if err != nil {
 		result.Error = err.Error()
@@ -554,9 +767,25 @@ func contentHash(content string) string {
 
 // Add inserts a memory directly into the store (used for imports)
 func (s *Store) Add(ctx context.Context, m Memory) error {
-	// Generate ID if missing
+	_, err := s.Import(ctx, m)
+	return err
+}
+
+// Import inserts a memory like Add and returns the ID it is stored under: m.ID, a new ID
+// if m.ID is empty or already taken, or the existing memory's ID if the content is a duplicate.
+// Callers importing edges or citations use it to remap IDs.
+// When m.Source is set, the original m.ID is kept as the memory's source reference.
+func (s *Store) Import(ctx context.Context, m Memory) (string, error) {
+	sourceRef := ""
+	if m.Source != "" {
+		sourceRef = m.ID
+	}
+
+	// Generate ID if missing or already in use
 	if m.ID == "" {
 		m.ID = generateID()
+	} else if existing, _ := s.GetMemoryByID(ctx, m.ID); existing != nil {
+		m.ID = generateID()
 	}
 	if m.CreatedAt.IsZero() {
 		m.CreatedAt = time.Now()
@@ -565,9 +794,13 @@ func (s *Store) Add(ctx context.Context, m Memory) error {
 		m.UpdatedAt = time.Now()
 	}
 
+	// Keep secrets out of the database
+	var contentRedactions, contextRedactions []redact.Finding
+	m.Content, contentRedactions = s.scrub(m.Content)
+	m.Context, contextRedactions = s.scrub(m.Context)
+
 	// Generate hash for deduplication
-	hash := sha256.Sum256([]byte(m.Content))
-	contentHash := hex.EncodeToString(hash[:])
+	contentHash := s.hashContent(m.Content)
 
 	// Check for existing memory with same content hash AND scope
 	var existingID string
@@ -576,43 +809,41 @@ func (s *Store) Add(ctx context.Context, m Memory) error {
 	err := s.db.QueryRowContext(ctx, query, contentHash, m.Scope, m.Scope).Scan(&existingID, &existingTagsJSON)
 	if err == nil {
 		// Already exists, skip or update? For now, skip to avoid duplicates
-		return nil
+		return existingID, nil
 	}
 
-	// Ensure embedding
-	if len(m.Embedding) == 0 {
-		embedding, err := s.embedder.Embed(m.Content)
-		if err == nil {
-			m.Embedding = embedding
-		} else {
-			// Fallback to empty if embedding fails
-			m.Embedding = make([]float32, s.embedder.Dimensions())
-		}
+	// Ensure embedding. An exported vector does not say which model made it, so it is
+	// only kept when it fits the current one (as for legacy rows, see claimLegacyEmbeddings).
+	embeddingModel := s.embedder.Model()
+	if len(m.Embedding) != s.embedder.Dimensions() || isZeroVector(m.Embedding) {
+		m.Embedding, embeddingModel = s.embed(m.Content)
 	}
 
 	tagsJSON, _ := json.Marshal(m.Tags)
-	embeddingJSON, _ := json.Marshal(m.Embedding)
 
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO memories (id, content, content_hash, tags, context, embedding, created_at, updated_at, source)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, m.ID, m.Content, contentHash, string(tagsJSON), m.Context, embeddingJSON, m.CreatedAt, m.UpdatedAt, m.Source)
+		INSERT INTO memories (id, content, content_hash, tags, context, embedding, embedding_model, created_at, updated_at, source, source_ref)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.ID, s.seal(m.Content), contentHash, string(tagsJSON), s.seal(m.Context), s.encodeEmbedding(m.Embedding), embeddingModel, m.CreatedAt, m.UpdatedAt, m.Source, sourceRef)
 
 	if err != nil {
-		return fmt.Errorf("failed to insert memory: %w", err)
+		return "", fmt.Errorf("failed to insert memory: %w", err)
 	}
 
 	// Insert into vec index
 	if s.vecIdx != nil {
-		s.vecIdx.Insert(m.ID, m.Embedding)
+		s.vecIdx.Insert(m.ID, m.Embedding, embeddingModel)
 	}
+	s.queueEmbedding(ctx, m.ID, embeddingModel)
 
 	// Insert tags
 	for _, tag := range m.Tags {
 		s.db.ExecContext(ctx, `INSERT OR IGNORE INTO memory_tags (memory_id, tag) VALUES (?, ?)`, m.ID, tag)
 	}
+	s.recordRedactions(ctx, m.ID, RedactedContent, contentRedactions)
+	s.recordRedactions(ctx, m.ID, RedactedContext, contextRedactions)
 
-	return nil
+	return m.ID, nil
 }
 
 // Remember stores a new memory, checking for duplicates by content hash
@@ -622,8 +853,13 @@ func (s *Store) Remember(ctx context.Context, content string, tags []string, mem
 
 // RememberWithScope stores a new memory with a scope identifier
 func (s *Store) RememberWithScope(ctx context.Context, content string, tags []string, memContext string, scope string) (*Memory, error) {
+	// Keep secrets out of the database
+	content, contentRedactions := s.scrub(content)
+	memContext, contextRedactions := s.scrub(memContext)
+	redactions := append(contentRedactions, contextRedactions...)
+
 	// Calculate content hash for deduplication
-	hash := contentHash(content)
+	hash := s.hashContent(content)
 
 	// Check for existing memory with same content hash AND scope
 	var existingID string
@@ -682,13 +918,13 @@ func (s *Store) RememberWithScope(ctx context.Context, content string, tags []st
 
 		// Return existing memory
 		var existingMemory Memory
-		var embeddingJSON []byte
+		var embeddingBlob []byte
 		var utilityNull sql.NullFloat64
 		err = s.db.QueryRowContext(ctx, `
 			SELECT id, content, tags, context, embedding, created_at, updated_at, COALESCE(utility_score, 1.0)
 			FROM memories WHERE id = ?
 		`, existingID).Scan(&existingMemory.ID, &existingMemory.Content, &existingTagsJSON,
-			&existingMemory.Context, &embeddingJSON, &existingMemory.CreatedAt, &existingMemory.UpdatedAt, &utilityNull)
+			&existingMemory.Context, &embeddingBlob, &existingMemory.CreatedAt, &existingMemory.UpdatedAt, &utilityNull)
 		if err != nil {
 			return nil, fmt.Errorf("failed to retrieve updated memory: %w", err)
 		}
@@ -698,10 +934,11 @@ func (s *Store) RememberWithScope(ctx context.Context, content string, tags []st
 			existingMemory.UtilityScore = 1.0
 		}
 
+		existingMemory.Content = s.open(existingMemory.Content)
+		existingMemory.Context = s.open(existingMemory.Context)
 		json.Unmarshal([]byte(existingTagsJSON), &existingMemory.Tags)
-		if len(embeddingJSON) > 0 {
-			json.Unmarshal(embeddingJSON, &existingMemory.Embedding)
-		}
+		existingMemory.Embedding, _ = decodeEmbedding(embeddingBlob)
+		existingMemory.Redactions = redactions
 
 		return &existingMemory, nil
 	}
@@ -711,20 +948,14 @@ func (s *Store) RememberWithScope(ctx context.Context, content string, tags []st
 	now := time.Now()
 
 	// Generate embedding for semantic search using the configured embedder
-	embedding, err := s.embedder.Embed(content)
-	if err != nil {
-		// Fall back to empty embedding if embedding fails
-		fmt.Fprintf(os.Stderr, "⚠️  Embedding failed: %v\n", err)
-		embedding = make([]float32, s.embedder.Dimensions())
-	}
+	embedding, embeddingModel := s.embed(content)
 
 	tagsJSON, _ := json.Marshal(tags)
-	embeddingJSON, _ := json.Marshal(embedding)
 
 	_, dbErr := s.db.ExecContext(ctx, `
-		INSERT INTO memories (id, content, content_hash, tags, context, scope, embedding, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, id, content, hash, string(tagsJSON), memContext, scope, embeddingJSON, now, now)
+		INSERT INTO memories (id, content, content_hash, tags, context, scope, embedding, embedding_model, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, id, s.seal(content), hash, string(tagsJSON), s.seal(memContext), scope, s.encodeEmbedding(embedding), embeddingModel, now, now)
 
 	if dbErr != nil {
 		return nil, fmt.Errorf("failed to store memory: %w", dbErr)
@@ -734,22 +965,27 @@ func (s *Store) RememberWithScope(ctx context.Context, content string, tags []st
 	for _, tag := range tags {
 		s.db.ExecContext(ctx, `INSERT INTO memory_tags (memory_id, tag) VALUES (?, ?)`, id, tag)
 	}
+	s.recordRedactions(ctx, id, RedactedContent, contentRedactions)
+	s.recordRedactions(ctx, id, RedactedContext, contextRedactions)
+	s.ensureScope(ctx, scope)
 
 	mem := &Memory{
-		ID:        id,
-		Content:   content,
-		Tags:      tags,
-		Context:   memContext,
-		Scope:     scope,
-		Embedding: embedding,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:         id,
+		Content:    content,
+		Tags:       tags,
+		Context:    memContext,
+		Scope:      scope,
+		Embedding:  embedding,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Redactions: redactions,
 	}
 
 	// Insert into vec index for fast KNN recall
 	if s.vecIdx != nil {
-		s.vecIdx.Insert(id, embedding)
+		s.vecIdx.Insert(id, embedding, embeddingModel)
 	}
+	s.queueEmbedding(ctx, id, embeddingModel)
 
 	// Temporal edge: link from previous memory (by created_at) to this one
 	if prevID, err := s.GetPreviousMemoryID(ctx, now); err == nil && prevID != "" {
@@ -768,17 +1004,43 @@ func (s *Store) Recall(ctx context.Context, query string, limit int, filterTags
 	return s.RecallWithScope(ctx, query, limit, filterTags, "")
 }
 
-// RecallWithScope finds memories similar to the query, optionally filtered by scope
+// RecallWithScope finds memories similar to the query, optionally filtered by scope.
+// Superseded memories are left out and contradicted ones down-ranked.
 func (s *Store) RecallWithScope(ctx context.Context, query string, limit int, filterTags []string, scope string) ([]*Memory, error) {
-	// Generate query embedding using the configured embedder
-	queryEmbedding, err := s.embedder.Embed(query)
+	var scopes []string
+	if scope != "" {
+		scopes = []string{scope}
+	}
+	return s.RecallWithScopes(ctx, query, limit, filterTags, scopes)
+}
+
+// RecallWithScopes finds memories similar to the query in any of the given scopes
+// (every memory when scopes is empty). Pass ScopeChain(scope) to include the memories
+// of a scope's ancestors.
+func (s *Store) RecallWithScopes(ctx context.Context, query string, limit int, filterTags []string, scopes []string) ([]*Memory, error) {
+	superseded, contradicted, err := s.staleMemories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load supersession edges: %w", err)
+	}
+	// Overfetch so hidden memories don't shrink the result set
+	memories, err := s.recallWithScope(ctx, query, limit+len(superseded), filterTags, scopes)
+	if err != nil {
+		return nil, err
+	}
+	return suppressStale(memories, superseded, contradicted, false, limit), nil
+}
+
+func (s *Store) recallWithScope(ctx context.Context, query string, limit int, filterTags []string, scopes []string) ([]*Memory, error) {
+	// Generate query embedding using the configured embedder. When it fails, keyword
+	// matches beat comparing vectors from another model.
+	queryEmbedding, err := s.embedQuery(query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to embed query: %w", err)
+		return s.recallLexical(ctx, query, limit, filterTags, scopes, err)
 	}
 
 	// Fast path: use sqlite-vec KNN index when available
 	if s.vecIdx != nil && s.vecIdx.available {
-		results, err := s.recallWithVecIndex(ctx, queryEmbedding, limit, filterTags, scope)
+		results, err := s.recallWithVecIndex(ctx, queryEmbedding, limit, filterTags, scopes)
 		if err == nil && len(results) > 0 {
 			return results, nil
 		}
@@ -790,9 +1052,9 @@ func (s *Store) RecallWithScope(ctx context.Context, query string, limit int, fi
 	// Note: Only use hybrid recall if no tag filtering (RecallWithRecencyBoost doesn't support tags yet)
 	var count int
 	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memories`).Scan(&count)
-	if err == nil && count > 5000 && len(filterTags) == 0 {
+	if err == nil && count > 5000 && len(filterTags) == 0 && len(scopes) == 0 {
 		// For large datasets without tag filtering, use optimized recall with recency boost
-		return s.RecallWithRecencyBoost(ctx, query, limit, RecallOptions{
+		return s.recallWithRecencyBoost(ctx, query, limit, RecallOptions{
 			SemanticWeight:       0.7,
 			RecencyWeight:        0.3,
 			RecencyHalfLifeHours: 168,                                  // 1 week
@@ -801,15 +1063,15 @@ func (s *Store) RecallWithScope(ctx context.Context, query string, limit int, fi
 	}
 
 	// Linear scan fallback
-	return s.recallLinearScan(ctx, queryEmbedding, limit, filterTags, scope)
+	return s.recallLinearScan(ctx, queryEmbedding, limit, filterTags, scopes)
 }
 
 // recallWithVecIndex uses the sqlite-vec KNN index for fast recall.
 // Overfetches candidates then applies tag/scope/utility filtering in Go.
-func (s *Store) recallWithVecIndex(ctx context.Context, queryEmbedding []float32, limit int, filterTags []string, scope string) ([]*Memory, error) {
+func (s *Store) recallWithVecIndex(ctx context.Context, queryEmbedding []float32, limit int, filterTags []string, scopes []string) ([]*Memory, error) {
 	// Overfetch to allow for filtering and utility re-ranking
 	overfetchLimit := limit * 3
-	if len(filterTags) > 0 || scope != "" {
+	if len(filterTags) > 0 || len(scopes) > 0 {
 		overfetchLimit = limit * 5
 	}
 	if overfetchLimit < 20 {
@@ -836,7 +1098,8 @@ func (s *Store) recallWithVecIndex(ctx context.Context, queryEmbedding []float32
 
 	// Batch-fetch full memory data
 	sqlQuery := `SELECT id, content, tags, context, scope, embedding, created_at, updated_at, COALESCE(utility_score, 1.0)
-		FROM memories WHERE id IN (` + strings.Join(placeholders, ",") + `)`
+		FROM memories WHERE id IN (` + strings.Join(placeholders, ",") + `) AND embedding_model = ?`
+	args = append(args, s.embedder.Model())
 
 	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
@@ -852,7 +1115,7 @@ func (s *Store) recallWithVecIndex(ctx context.Context, queryEmbedding []float32
 		}
 
 		// Apply scope filter
-		if scope != "" && mem.Scope != scope {
+		if len(scopes) > 0 && !slices.Contains(scopes, mem.Scope) {
 			continue
 		}
 
@@ -885,15 +1148,17 @@ func (s *Store) recallWithVecIndex(ctx context.Context, queryEmbedding []float32
 }
 
 // recallLinearScan is the original brute-force recall path (fallback when vec index is unavailable).
-func (s *Store) recallLinearScan(ctx context.Context, queryEmbedding []float32, limit int, filterTags []string, scope string) ([]*Memory, error) {
-	// Build query with optional tag and scope filtering
+func (s *Store) recallLinearScan(ctx context.Context, queryEmbedding []float32, limit int, filterTags []string, scopes []string) ([]*Memory, error) {
+	// Build query with optional tag and scope filtering; only vectors from the query's
+	// model are comparable
 	sqlQuery := `SELECT id, content, tags, context, scope, embedding, created_at, updated_at, COALESCE(utility_score, 1.0) FROM memories`
-	args := []interface{}{}
-	whereConditions := []string{}
+	args := []interface{}{s.embedder.Model()}
+	whereConditions := []string{`embedding_model = ?`}
 
-	if scope != "" {
-		whereConditions = append(whereConditions, "scope = ?")
-		args = append(args, scope)
+	if len(scopes) > 0 {
+		condition, scopeArgs := scopeFilter(scopes)
+		whereConditions = append(whereConditions, condition)
+		args = append(args, scopeArgs...)
 	}
 
 	if len(filterTags) > 0 {
@@ -945,6 +1210,62 @@ func (s *Store) recallLinearScan(ctx context.Context, queryEmbedding []float32,
 	return memories, nil
 }
 
+// recallLexical ranks memories by BM25 alone, for when the query cannot be embedded
+// (embedErr). Without a full-text index it returns embedErr.
+func (s *Store) recallLexical(ctx context.Context, query string, limit int, filterTags []string, scopes []string, embedErr error) ([]*Memory, error) {
+	if s.ftsIdx == nil || !s.ftsIdx.available {
+		return nil, fmt.Errorf("failed to embed query: %w", embedErr)
+	}
+	overfetchLimit := limit * 3
+	if len(filterTags) > 0 || len(scopes) > 0 {
+		overfetchLimit = limit * 5
+	}
+	results, err := s.ftsIdx.Search(query, max(overfetchLimit, 20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search memories: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	scores := make(map[string]float64, len(results))
+	ids := make([]string, len(results))
+	for i, r := range results {
+		scores[r.MemoryID] = r.Score
+		ids[i] = r.MemoryID
+	}
+	memories, err := s.fetchMemoriesByIDs(ctx, ids, time.Time{}, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Normalize BM25 to [0, 1] against the best match, then apply utility
+	best := results[0].Score
+	filtered := memories[:0]
+	for _, mem := range memories {
+		if len(filterTags) > 0 && !memHasAnyTag(mem, filterTags) {
+			continue
+		}
+		if mem.UtilityScore <= 0 {
+			mem.UtilityScore = 0.5
+		}
+		relevance := 1.0
+		if best > 0 {
+			relevance = scores[mem.ID] / best
+		}
+		mem.Similarity = relevance * mem.UtilityScore
+		filtered = append(filtered, mem)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Similarity > filtered[j].Similarity
+	})
+	if len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+	return filtered, nil
+}
+
 // memHasAnyTag returns true if the memory has at least one of the given tags.
 func memHasAnyTag(mem *Memory, tags []string) bool {
 	tagSet := make(map[string]bool, len(mem.Tags))
@@ -962,11 +1283,34 @@ func memHasAnyTag(mem *Memory, tags []string) bool {
 // RecallWithRecencyBoost finds memories using blended scoring:
 // FinalScore = (semantic × semanticWeight) + (recency × recencyWeight) + (importance × importanceWeight)
 // This ensures recent memories surface even if semantic match is imperfect.
+// Superseded memories are left out unless options.IncludeSuperseded is set; contradicted ones are down-ranked.
 func (s *Store) RecallWithRecencyBoost(ctx context.Context, query string, limit int, options RecallOptions) ([]*Memory, error) {
-	// Generate query embedding
-	queryEmbedding, err := s.embedder.Embed(query)
+	superseded, contradicted, err := s.staleMemories(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to embed query: %w", err)
+		return nil, fmt.Errorf("failed to load supersession edges: %w", err)
+	}
+	fetchLimit := limit
+	if !options.IncludeSuperseded {
+		fetchLimit += len(superseded)
+	}
+	memories, err := s.recallWithRecencyBoost(ctx, query, fetchLimit, options)
+	if err != nil {
+		return nil, err
+	}
+	return suppressStale(memories, superseded, contradicted, options.IncludeSuperseded, limit), nil
+}
+
+func (s *Store) recallWithRecencyBoost(ctx context.Context, query string, limit int, options RecallOptions) ([]*Memory, error) {
+	// Lexical and hybrid modes rank candidates by rank fusion before blending
+	if options.Mode == RecallModeLexical || options.Mode == RecallModeHybrid {
+		return s.recallFused(ctx, query, limit, options)
+	}
+
+	// Generate query embedding; without one, rank by keywords alone
+	queryEmbedding, err := s.embedQuery(query)
+	if err != nil {
+		options.Mode = RecallModeLexical
+		return s.recallFused(ctx, query, limit, options)
 	}
 
 	// Fast path: use vec index for semantic candidates, then blend with recency
@@ -982,6 +1326,9 @@ func (s *Store) RecallWithRecencyBoost(ctx context.Context, query string, limit
 func (s *Store) recallWithRecencyBoostVec(ctx context.Context, queryEmbedding []float32, limit int, options RecallOptions) ([]*Memory, error) {
 	// Get top semantic candidates from vec index (overfetch for blending)
 	candidateLimit := limit * 5
+	if len(options.Scopes) > 0 {
+		candidateLimit = limit * 10 // Candidates from other scopes are dropped below
+	}
 	if candidateLimit < 50 {
 		candidateLimit = 50
 	}
@@ -1003,13 +1350,19 @@ func (s *Store) recallWithRecencyBoostVec(ctx context.Context, queryEmbedding []
 	}
 
 	sqlQuery := `SELECT id, content, tags, context, scope, embedding, created_at, updated_at, COALESCE(utility_score, 1.0)
-		FROM memories WHERE id IN (` + strings.Join(placeholders, ",") + `)`
+		FROM memories WHERE id IN (` + strings.Join(placeholders, ",") + `) AND embedding_model = ?`
+	args = append(args, s.embedder.Model())
 
 	// Apply time window filter if specified
 	if !options.Since.IsZero() {
 		sqlQuery += ` AND created_at >= ?`
 		args = append(args, options.Since)
 	}
+	if len(options.Scopes) > 0 {
+		condition, scopeArgs := scopeFilter(options.Scopes)
+		sqlQuery += ` AND ` + condition
+		args = append(args, scopeArgs...)
+	}
 
 	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
@@ -1053,13 +1406,22 @@ func (s *Store) recallWithRecencyBoostVec(ctx context.Context, queryEmbedding []
 // recallWithRecencyBoostLinear is the original full-scan blended recall.
 func (s *Store) recallWithRecencyBoostLinear(ctx context.Context, queryEmbedding []float32, limit int, options RecallOptions) ([]*Memory, error) {
 	sqlQuery := `SELECT id, content, tags, context, scope, embedding, created_at, updated_at, COALESCE(utility_score, 1.0) FROM memories`
-	args := []interface{}{}
+	args := []interface{}{s.embedder.Model()}
+	whereConditions := []string{`embedding_model = ?`}
 
 	// Optional time window filter for efficiency at scale
 	if !options.Since.IsZero() {
-		sqlQuery += ` WHERE created_at >= ?`
+		whereConditions = append(whereConditions, `created_at >= ?`)
 		args = append(args, options.Since)
 	}
+	if len(options.Scopes) > 0 {
+		condition, scopeArgs := scopeFilter(options.Scopes)
+		whereConditions = append(whereConditions, condition)
+		args = append(args, scopeArgs...)
+	}
+	if len(whereConditions) > 0 {
+		sqlQuery += ` WHERE ` + strings.Join(whereConditions, " AND ")
+	}
 
 	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
@@ -1100,6 +1462,149 @@ func (s *Store) recallWithRecencyBoostLinear(ctx context.Context, queryEmbedding
 	return memories, nil
 }
 
+// rrfK is the reciprocal rank fusion constant (60 as in the original RRF paper).
+const rrfK = 60
+
+// recallFused ranks candidates by reciprocal rank fusion of BM25 and vector results
+// (lexical mode uses BM25 only), then applies blended scoring with the normalized
+// fused score as the semantic component. Falls back to vector-only candidates when
+// the full-text index is unavailable.
+func (s *Store) recallFused(ctx context.Context, query string, limit int, options RecallOptions) ([]*Memory, error) {
+	candidateLimit := limit * 5
+	if candidateLimit < 50 {
+		candidateLimit = 50
+	}
+
+	var rankings [][]string
+	if s.ftsIdx != nil && s.ftsIdx.available {
+		lexical, err := s.ftsIdx.Search(query, candidateLimit)
+		if err == nil {
+			ids := make([]string, len(lexical))
+			for i, r := range lexical {
+				ids[i] = r.MemoryID
+			}
+			rankings = append(rankings, ids)
+		}
+	}
+
+	if options.Mode == RecallModeHybrid || len(rankings) == 0 {
+		queryEmbedding, err := s.embedQuery(query)
+		if err != nil {
+			if len(rankings) == 0 {
+				return nil, fmt.Errorf("failed to embed query: %w", err)
+			}
+		} else if ids, err := s.vectorCandidates(ctx, queryEmbedding, candidateLimit); err == nil {
+			rankings = append(rankings, ids)
+		}
+	}
+
+	// Reciprocal rank fusion: score = sum of 1/(k + rank) across rankings
+	fused := make(map[string]float64)
+	var ids []string
+	for _, ranking := range rankings {
+		for rank, id := range ranking {
+			if _, ok := fused[id]; !ok {
+				ids = append(ids, id)
+			}
+			fused[id] += 1.0 / float64(rrfK+rank+1)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	maxScore := float64(len(rankings)) / float64(rrfK+1)
+
+	memories, err := s.fetchMemoriesByIDs(ctx, ids, options.Since, options.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, mem := range memories {
+		if mem.UtilityScore <= 0 {
+			mem.UtilityScore = 0.5
+		}
+		relevance := fused[mem.ID] / maxScore
+		blended := s.computeBlendedScore(ctx, mem, relevance, now, options)
+		mem.Similarity = blended * mem.UtilityScore
+	}
+
+	sort.Slice(memories, func(i, j int) bool {
+		return memories[i].Similarity > memories[j].Similarity
+	})
+
+	if len(memories) > limit {
+		memories = memories[:limit]
+	}
+
+	return memories, nil
+}
+
+// vectorCandidates returns memory IDs ordered by vector similarity to the query embedding.
+func (s *Store) vectorCandidates(ctx context.Context, queryEmbedding []float32, limit int) ([]string, error) {
+	if s.vecIdx != nil && s.vecIdx.available {
+		results, err := s.vecIdx.Search(queryEmbedding, limit)
+		if err == nil && len(results) > 0 {
+			ids := make([]string, len(results))
+			for i, r := range results {
+				ids[i] = r.MemoryID
+			}
+			return ids, nil
+		}
+	}
+	memories, err := s.recallLinearScan(ctx, queryEmbedding, limit, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(memories))
+	for i, m := range memories {
+		ids[i] = m.ID
+	}
+	return ids, nil
+}
+
+// fetchMemoriesByIDs batch-loads memories by ID, optionally restricted to those created since a time
+// and to the given scopes.
+func (s *Store) fetchMemoriesByIDs(ctx context.Context, ids []string, since time.Time, scopes []string) ([]*Memory, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	sqlQuery := `SELECT id, content, tags, context, scope, embedding, created_at, updated_at, COALESCE(utility_score, 1.0)
+		FROM memories WHERE id IN (` + strings.Join(placeholders, ",") + `)`
+	if !since.IsZero() {
+		sqlQuery += ` AND created_at >= ?`
+		args = append(args, since)
+	}
+	if len(scopes) > 0 {
+		condition, scopeArgs := scopeFilter(scopes)
+		sqlQuery += ` AND ` + condition
+		args = append(args, scopeArgs...)
+	}
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memories []*Memory
+	for rows.Next() {
+		mem, err := s.scanMemory(rows)
+		if err != nil {
+			continue
+		}
+		memories = append(memories, mem)
+	}
+	return memories, nil
+}
+
 // computeBlendedScore calculates the blended recall score for a memory.
 func (s *Store) computeBlendedScore(ctx context.Context, mem *Memory, semantic float64, now time.Time, options RecallOptions) float64 {
 	// Calculate recency score: exponential decay with configurable half-life
@@ -1168,11 +1673,46 @@ type RecallOptions struct {
 	RecencyHalfLifeHours float64
 	// Only consider memories since this time (for efficiency at scale)
 	Since time.Time
+	// Ranking mode: vector (default), lexical (BM25), or hybrid (rank fusion of both)
+	Mode RecallMode
+	// Return superseded memories (down-ranked, with SupersededBy set) instead of hiding them
+	IncludeSuperseded bool
+	// Only memories in these scopes ("" for unscoped memories); every memory when empty
+	Scopes []string
+}
+
+// RecallMode selects how recall candidates are ranked
+type RecallMode string
+
+const (
+	RecallModeVector  RecallMode = "vector"
+	RecallModeLexical RecallMode = "lexical"
+	RecallModeHybrid  RecallMode = "hybrid"
+)
+
+// ParseRecallMode validates a recall mode string; empty means vector.
+func ParseRecallMode(mode string) (RecallMode, error) {
+	switch RecallMode(strings.ToLower(strings.TrimSpace(mode))) {
+	case "", RecallModeVector:
+		return RecallModeVector, nil
+	case RecallModeLexical:
+		return RecallModeLexical, nil
+	case RecallModeHybrid:
+		return RecallModeHybrid, nil
+	default:
+		return "", fmt.Errorf("mode must be 'vector', 'lexical' or 'hybrid', got %q", mode)
+	}
 }
 
 // GetRecentImportant returns recent memories with important tags, guaranteed to surface
 // regardless of semantic similarity. Used by session_context for guaranteed slots.
 func (s *Store) GetRecentImportant(ctx context.Context, maxAge time.Duration, limit int) ([]*Memory, error) {
+	return s.GetRecentImportantWithScopes(ctx, maxAge, limit, nil)
+}
+
+// GetRecentImportantWithScopes is GetRecentImportant limited to memories in scopes
+// (see scopeFilter); nil means every scope.
+func (s *Store) GetRecentImportantWithScopes(ctx context.Context, maxAge time.Duration, limit int, scopes []string) ([]*Memory, error) {
 	cutoff := time.Now().Add(-maxAge)
 
 	sqlQuery := `
@@ -1180,12 +1720,20 @@ func (s *Store) GetRecentImportant(ctx context.Context, maxAge time.Duration, li
 		FROM memories m
 		JOIN memory_tags mt ON m.id = mt.memory_id
 		WHERE m.created_at >= ? 
-		AND mt.tag IN ('critical', 'milestone', 'founding', 'permanent', 'promise', 'decision')
+		AND mt.tag IN ('critical', 'milestone', 'founding', 'permanent', 'promise', 'decision')`
+	args := []interface{}{cutoff}
+	if len(scopes) > 0 {
+		condition, scopeArgs := scopeFilter(scopes)
+		sqlQuery += ` AND ` + condition
+		args = append(args, scopeArgs...)
+	}
+	sqlQuery += `
 		ORDER BY m.created_at DESC
 		LIMIT ?
 	`
+	args = append(args, limit)
 
-	rows, err := s.db.QueryContext(ctx, sqlQuery, cutoff, limit)
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query important memories: %w", err)
 	}
@@ -1215,8 +1763,15 @@ func (s *Store) Forget(ctx context.Context, id string) error {
 		return fmt.Errorf("memory not found: %s", id)
 	}
 
-	// Also delete tags and vec index entry
+	// Also delete tags, citations, revisions, edges, aliases, queued embedding and vec index entry.
+	// Dropping edges un-hides anything this memory superseded.
 	s.db.ExecContext(ctx, `DELETE FROM memory_tags WHERE memory_id = ?`, id)
+	s.db.ExecContext(ctx, `DELETE FROM citations WHERE memory_id = ?`, id)
+	s.db.ExecContext(ctx, `DELETE FROM memory_revisions WHERE memory_id = ?`, id)
+	s.db.ExecContext(ctx, `DELETE FROM memory_edges WHERE source_id = ? OR target_id = ?`, id, id)
+	s.db.ExecContext(ctx, `DELETE FROM memory_aliases WHERE memory_id = ?`, id)
+	s.db.ExecContext(ctx, `DELETE FROM memory_redactions WHERE memory_id = ?`, id)
+	s.db.ExecContext(ctx, `DELETE FROM pending_embeddings WHERE memory_id = ?`, id)
 	if s.vecIdx != nil {
 		s.vecIdx.Delete(id)
 	}
@@ -1226,8 +1781,15 @@ func (s *Store) Forget(ctx context.Context, id string) error {
 
 // List returns recent memories
 func (s *Store) List(ctx context.Context, limit int, filterTags []string) ([]*Memory, error) {
+	return s.ListWithScopes(ctx, limit, filterTags, nil)
+}
+
+// ListWithScopes is List limited to memories in scopes (see scopeFilter); nil means
+// every scope.
+func (s *Store) ListWithScopes(ctx context.Context, limit int, filterTags []string, scopes []string) ([]*Memory, error) {
 	sqlQuery := `SELECT id, content, tags, context, scope, embedding, created_at, updated_at, COALESCE(utility_score, 1.0) FROM memories`
 	args := []interface{}{}
+	var conditions []string
 
 	if len(filterTags) > 0 {
 		placeholders := make([]string, len(filterTags))
@@ -1235,7 +1797,15 @@ func (s *Store) List(ctx context.Context, limit int, filterTags []string) ([]*Me
 			placeholders[i] = "?"
 			args = append(args, tag)
 		}
-		sqlQuery += ` WHERE id IN (SELECT memory_id FROM memory_tags WHERE tag IN (` + strings.Join(placeholders, ",") + `))`
+		conditions = append(conditions, `id IN (SELECT memory_id FROM memory_tags WHERE tag IN (`+strings.Join(placeholders, ",")+`))`)
+	}
+	if len(scopes) > 0 {
+		condition, scopeArgs := scopeFilter(scopes)
+		conditions = append(conditions, condition)
+		args = append(args, scopeArgs...)
+	}
+	if len(conditions) > 0 {
+		sqlQuery += ` WHERE ` + strings.Join(conditions, " AND ")
 	}
 
 	sqlQuery += ` ORDER BY created_at DESC`
@@ -1315,22 +1885,29 @@ func (s *Store) LastActivity(ctx context.Context) (time.Time, error) {
 
 // Close closes the database
 func (s *Store) Close() error {
+	if s.vecIdx != nil {
+		if err := s.vecIdx.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		}
+	}
 	return s.db.Close()
 }
 
 func (s *Store) scanMemory(rows *sql.Rows) (*Memory, error) {
 	var mem Memory
-	var tagsJSON, embeddingJSON string
+	var tagsJSON string
+	var embeddingBlob []byte
 	var contextNull, scopeNull sql.NullString
 	var utilityNull sql.NullFloat64
 
-	err := rows.Scan(&mem.ID, &mem.Content, &tagsJSON, &contextNull, &scopeNull, &embeddingJSON, &mem.CreatedAt, &mem.UpdatedAt, &utilityNull)
+	err := rows.Scan(&mem.ID, &mem.Content, &tagsJSON, &contextNull, &scopeNull, &embeddingBlob, &mem.CreatedAt, &mem.UpdatedAt, &utilityNull)
 	if err != nil {
 		return nil, err
 	}
 
+	mem.Content = s.open(mem.Content)
 	if contextNull.Valid {
-		mem.Context = contextNull.String
+		mem.Context = s.open(contextNull.String)
 	}
 	if scopeNull.Valid {
 		mem.Scope = scopeNull.String
@@ -1342,7 +1919,7 @@ func (s *Store) scanMemory(rows *sql.Rows) (*Memory, error) {
 	}
 
 	json.Unmarshal([]byte(tagsJSON), &mem.Tags)
-	json.Unmarshal([]byte(embeddingJSON), &mem.Embedding)
+	mem.Embedding, _ = decodeEmbedding(embeddingBlob)
 
 	return &mem, nil
 }
@@ -1394,38 +1971,50 @@ func cosineSimilarity(a, b []float32) float64 {
 // Citation Methods
 // ============================================================================
 
-// AddCitation adds a citation to a memory
+// AddCitation adds a citation to a memory. A file inside a git work tree is stored
+// relative to the repo root and anchored to HEAD unless commitSHA is given.
 func (s *Store) AddCitation(ctx context.Context, memoryID string, filePath string, startLine, endLine int, commitSHA, content string) (*Citation, error) {
-	id := generateID()
+	return s.insertCitation(ctx, &Citation{
+		MemoryID:  memoryID,
+		FilePath:  filePath,
+		StartLine: startLine,
+		EndLine:   endLine,
+		CommitSHA: commitSHA,
+		Content:   content,
+	})
+}
+
+// insertCitation scrubs, anchors and stores a new citation, filling in its ID and timestamps
+func (s *Store) insertCitation(ctx context.Context, c *Citation) (*Citation, error) {
+	c.ID = generateID()
 	now := time.Now()
 
+	var redactions []redact.Finding
+	c.Content, redactions = s.scrub(c.Content)
+	s.recordRedactions(ctx, c.MemoryID, RedactedCitation, redactions)
+
+	c.RepoRoot, c.FilePath, c.CommitSHA = anchorCitation(c.FilePath, c.CommitSHA)
+
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO citations (id, memory_id, file_path, start_line, end_line, commit_sha, content, confidence, verified_at, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, 1.0, ?, ?)
-	`, id, memoryID, filePath, startLine, endLine, commitSHA, content, now, now)
+		INSERT INTO citations (id, memory_id, file_path, repo_root, symbol, ast_hash, start_line, end_line, commit_sha, content, confidence, verified_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1.0, ?, ?)
+	`, c.ID, c.MemoryID, c.FilePath, c.RepoRoot, c.Symbol, c.ASTHash, c.StartLine, c.EndLine, c.CommitSHA, s.seal(c.Content), now, now)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to add citation: %w", err)
 	}
 
-	return &Citation{
-		ID:         id,
-		MemoryID:   memoryID,
-		FilePath:   filePath,
-		StartLine:  startLine,
-		EndLine:    endLine,
-		CommitSHA:  commitSHA,
-		Content:    content,
-		Confidence: 1.0,
-		VerifiedAt: now,
-		CreatedAt:  now,
-	}, nil
+	c.Confidence = 1.0
+	c.VerifiedAt = now
+	c.CreatedAt = now
+	return c, nil
 }
 
 // GetCitations returns all citations for a memory
 func (s *Store) GetCitations(ctx context.Context, memoryID string) ([]Citation, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, memory_id, file_path, start_line, end_line, commit_sha, content, confidence, verified_at, created_at
+		SELECT id, memory_id, file_path, COALESCE(repo_root, ''), COALESCE(symbol, ''), COALESCE(ast_hash, ''),
+			start_line, end_line, commit_sha, content, confidence, verified_at, created_at
 		FROM citations WHERE memory_id = ?
 	`, memoryID)
 	if err != nil {
@@ -1439,7 +2028,7 @@ func (s *Store) GetCitations(ctx context.Context, memoryID string) ([]Citation,
 		var commitSHA, content sql.NullString
 		var verifiedAt sql.NullTime
 
-		err := rows.Scan(&c.ID, &c.MemoryID, &c.FilePath, &c.StartLine, &c.EndLine, &commitSHA, &content, &c.Confidence, &verifiedAt, &c.CreatedAt)
+		err := rows.Scan(&c.ID, &c.MemoryID, &c.FilePath, &c.RepoRoot, &c.Symbol, &c.ASTHash, &c.StartLine, &c.EndLine, &commitSHA, &content, &c.Confidence, &verifiedAt, &c.CreatedAt)
 		if err != nil {
 			continue
 		}
@@ -1448,7 +2037,7 @@ func (s *Store) GetCitations(ctx context.Context, memoryID string) ([]Citation,
 			c.CommitSHA = commitSHA.String
 		}
 		if content.Valid {
-			c.Content = content.String
+			c.Content = s.open(content.String)
 		}
 		if verifiedAt.Valid {
 			c.VerifiedAt = verifiedAt.Time
@@ -1460,7 +2049,11 @@ func (s *Store) GetCitations(ctx context.Context, memoryID string) ([]Citation,
 	return citations, nil
 }
 
-// VerifyCitation checks if a citation is still valid by comparing file content
+// VerifyCitation checks if a citation is still valid by comparing file content.
+// Citations inside a git repo are resolved against their repo root and follow the
+// file across renames; a cited block that moved within the file is found again and
+// its line range updated instead of losing confidence. Go symbol citations compare
+// the symbol's normalized AST, so reformatting it does not count as a change.
 func (s *Store) VerifyCitation(ctx context.Context, citationID string) (*Citation, bool, error) {
 	// Get the citation
 	var c Citation
@@ -1468,9 +2061,10 @@ func (s *Store) VerifyCitation(ctx context.Context, citationID string) (*Citatio
 	var verifiedAt sql.NullTime
 
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, memory_id, file_path, start_line, end_line, commit_sha, content, confidence, verified_at, created_at
+		SELECT id, memory_id, file_path, COALESCE(repo_root, ''), COALESCE(symbol, ''), COALESCE(ast_hash, ''),
+			start_line, end_line, commit_sha, content, confidence, verified_at, created_at
 		FROM citations WHERE id = ?
-	`, citationID).Scan(&c.ID, &c.MemoryID, &c.FilePath, &c.StartLine, &c.EndLine, &commitSHA, &content, &c.Confidence, &verifiedAt, &c.CreatedAt)
+	`, citationID).Scan(&c.ID, &c.MemoryID, &c.FilePath, &c.RepoRoot, &c.Symbol, &c.ASTHash, &c.StartLine, &c.EndLine, &commitSHA, &content, &c.Confidence, &verifiedAt, &c.CreatedAt)
 
 	if err != nil {
 		return nil, false, fmt.Errorf("citation not found: %w", err)
@@ -1480,7 +2074,7 @@ func (s *Store) VerifyCitation(ctx context.Context, citationID string) (*Citatio
 		c.CommitSHA = commitSHA.String
 	}
 	if content.Valid {
-		c.Content = content.String
+		c.Content = s.open(content.String)
 	}
 	if verifiedAt.Valid {
 		c.VerifiedAt = verifiedAt.Time
@@ -1493,78 +2087,76 @@ func (s *Store) VerifyCitation(ctx context.Context, citationID string) (*Citatio
 		return &c, false, fmt.Errorf("invalid file path: contains '..'")
 	}
 
-	// Check file size before reading (limit to 10MB)
-	const maxFileSize = 10 * 1024 * 1024
-	fileInfo, err := os.Stat(c.FilePath)
-	if err != nil {
-		c.Confidence = 0.0
-		s.updateCitationConfidence(ctx, c.ID, 0.0)
-		return &c, false, nil
-	}
-	if fileInfo.Size() > maxFileSize {
-		c.Confidence = 0.0
-		s.updateCitationConfidence(ctx, c.ID, 0.0)
-		return &c, false, fmt.Errorf("file too large (%d bytes, limit %d)", fileInfo.Size(), maxFileSize)
+	// Read the current file content, following a rename if the file is gone
+	fileContent, err := readCitedFile(c.resolvedPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		if moved := followCitationRename(&c); moved {
+			fileContent, err = readCitedFile(c.resolvedPath())
+		}
 	}
-
-	// Read the current file content
-	fileContent, err := os.ReadFile(c.FilePath)
 	if err != nil {
 		// File doesn't exist or can't be read - citation is invalid
 		c.Confidence = 0.0
 		s.updateCitationConfidence(ctx, c.ID, 0.0)
+		if errors.Is(err, errFileTooLarge) {
+			return &c, false, err
+		}
 		return &c, false, nil
 	}
+	lines := strings.Split(string(fileContent), "\n")
 
-	// If we have stored content, compare it
-	if c.Content != "" {
-		lines := strings.Split(string(fileContent), "\n")
-
-		// Extract the cited lines
-		startIdx := c.StartLine - 1
-		endIdx := c.EndLine
-		if startIdx < 0 {
-			startIdx = 0
-		}
-		if endIdx > len(lines) {
-			endIdx = len(lines)
-		}
-		if startIdx >= len(lines) {
-			// Lines no longer exist
-			c.Confidence = 0.0
-			s.updateCitationConfidence(ctx, c.ID, 0.0)
-			return &c, false, nil
+	// A symbol citation compares the symbol's syntax tree wherever it now is
+	if c.Symbol != "" {
+		if valid, resolved := s.verifySymbolCitation(ctx, &c, fileContent); resolved {
+			return &c, valid, nil
 		}
+		// The symbol was renamed or removed: fall back to the snapshot below
+	}
 
-		currentContent := strings.Join(lines[startIdx:endIdx], "\n")
+	// If we have stored content, compare it with the file scrubbed the same way
+	if c.Content != "" {
+		lines = s.scrubLines(lines)
+		currentContent := citedLines(lines, c.StartLine, c.EndLine)
 
 		// Check if content matches
 		if strings.TrimSpace(currentContent) == strings.TrimSpace(c.Content) {
 			// Content matches - citation is valid
-			c.Confidence = 1.0
-			c.VerifiedAt = time.Now()
-			s.updateCitationVerified(ctx, c.ID, c.VerifiedAt, 1.0)
+			s.markCitationVerified(ctx, &c, 1.0)
 			return &c, true, nil
 		}
 
-		// Content changed - reduce confidence based on similarity
+		// The block may have moved: search the file for it
+		if start, end, ok := locateSnippet(lines, c.Content, c.StartLine); ok {
+			c.StartLine, c.EndLine = start, end
+			s.markCitationVerified(ctx, &c, 1.0)
+			return &c, true, nil
+		}
+
+		// Content changed - reduce confidence based on similarity, moving the range
+		// to the closest match only if it fits better, closely enough and nowhere
+		// else. A better match that fails that leaves the range alone and the
+		// citation stale.
 		similarity := stringSimilarity(c.Content, currentContent)
+		if start, end, best, unique := closestSnippet(lines, c.Content); best > similarity {
+			if unique && best >= minRelocateSimilarity {
+				c.StartLine, c.EndLine, similarity = start, end, best
+			} else if similarity <= 0.8 {
+				similarity = min(similarity, staleCitationConfidence)
+			}
+		}
 		c.Confidence = similarity
-		s.updateCitationConfidence(ctx, c.ID, similarity)
+		s.updateCitationLocation(ctx, &c)
 		return &c, similarity > 0.8, nil
 	}
 
 	// No stored content - just check if file exists and lines are in range
-	lines := strings.Split(string(fileContent), "\n")
 	if c.StartLine > 0 && c.StartLine <= len(lines) {
-		c.Confidence = 0.9 // File exists, lines in range, but can't verify content
-		c.VerifiedAt = time.Now()
-		s.updateCitationVerified(ctx, c.ID, c.VerifiedAt, 0.9)
+		s.markCitationVerified(ctx, &c, 0.9) // File exists, lines in range, but can't verify content
 		return &c, true, nil
 	}
 
 	c.Confidence = 0.0
-	s.updateCitationConfidence(ctx, c.ID, 0.0)
+	s.updateCitationLocation(ctx, &c)
 	return &c, false, nil
 }
 
@@ -1636,10 +2228,6 @@ func (s *Store) updateCitationConfidence(ctx context.Context, id string, confide
 	s.db.ExecContext(ctx, `UPDATE citations SET confidence = ? WHERE id = ?`, confidence, id)
 }
 
-func (s *Store) updateCitationVerified(ctx context.Context, id string, verifiedAt time.Time, confidence float64) {
-	s.db.ExecContext(ctx, `UPDATE citations SET verified_at = ?, confidence = ? WHERE id = ?`, verifiedAt, confidence, id)
-}
-
 // stringSimilarity calculates a simple similarity score between two strings
 func stringSimilarity(a, b string) float64 {
 	if a == b {
@@ -1315,6 +1315,39 @@ func TestAdd_DuplicateContentHash(t *testing.T) {
 	}
 }
 
+func TestImport_RemapsIDs(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	id1, err := store.Import(ctx, Memory{ID: "graft-1", Content: "First imported memory"})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if id1 != "graft-1" {
+		t.Errorf("unused ID should be kept, got %q", id1)
+	}
+
+	// Same ID, different content: gets a fresh ID
+	id2, err := store.Import(ctx, Memory{ID: "graft-1", Content: "Second imported memory"})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if id2 == "graft-1" || id2 == "" {
+		t.Errorf("colliding ID should be remapped, got %q", id2)
+	}
+
+	// Duplicate content: maps to the existing memory
+	id3, err := store.Import(ctx, Memory{ID: "graft-9", Content: "First imported memory"})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if id3 != id1 {
+		t.Errorf("duplicate should map to %q, got %q", id1, id3)
+	}
+}
+
 func TestGetEmbedderDimensions(t *testing.T) {
 	store, cleanup := setupTestStore(t)
 	defer cleanup()
@@ -1811,7 +1844,7 @@ func BenchmarkRecallLinearScan(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, err := store.recallLinearScan(ctx, queryEmbedding, 10, nil, "")
+		_, err := store.recallLinearScan(ctx, queryEmbedding, 10, nil, nil)
 		if err != nil {
 			b.Fatalf("recallLinearScan failed: %v", err)
 		}
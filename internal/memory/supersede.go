@@ -0,0 +1,277 @@
+// Package memory: supersedes/contradicts edges and stale-fact suppression.
+// A "supersedes" edge (new -> old) hides the old memory from recall; a "contradicts"
+// edge (new -> old) keeps the old memory but down-ranks it. Both stay in memory_edges,
+// so Lineage can still show how a fact evolved.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Edge types for fact revision
+const (
+	EdgeSupersedes  = "supersedes"
+	EdgeContradicts = "contradicts"
+)
+
+// Recall score multipliers for stale memories (superseded ones only appear with IncludeSuperseded)
+const (
+	supersededPenalty   = 0.25
+	contradictedPenalty = 0.5
+)
+
+// Lineage describes how a memory relates to the memories that replaced or conflict with it.
+type Lineage struct {
+	Chain       []*Memory `json:"chain"`       // Supersession chain including the memory itself, oldest first
+	Contradicts []*Memory `json:"contradicts"` // Memories linked by a contradicts edge in either direction
+}
+
+// Supersede records that newID replaces oldID. The old memory is hidden from recall
+// and session context but kept for lineage queries.
+func (s *Store) Supersede(ctx context.Context, newID, oldID string) error {
+	return s.addRevisionEdge(ctx, newID, oldID, EdgeSupersedes)
+}
+
+// Contradict records that newID conflicts with oldID. The old memory is down-ranked in recall.
+func (s *Store) Contradict(ctx context.Context, newID, oldID string) error {
+	return s.addRevisionEdge(ctx, newID, oldID, EdgeContradicts)
+}
+
+// addRevisionEdge links the memories newID and oldID resolve to, so an ID merged away
+// by consolidate still names the memory that absorbed it
+func (s *Store) addRevisionEdge(ctx context.Context, newID, oldID, edgeType string) error {
+	ids := []*string{&newID, &oldID}
+	for _, id := range ids {
+		mem, err := s.GetMemoryByID(ctx, *id)
+		if err != nil {
+			return fmt.Errorf("failed to load memory: %w", err)
+		}
+		if mem == nil {
+			return fmt.Errorf("memory not found: %s", *id)
+		}
+		*id = mem.ID
+	}
+	if newID == oldID {
+		return fmt.Errorf("a memory cannot %s itself", edgeVerb(edgeType))
+	}
+
+	existing, err := s.GetEdgesFrom(ctx, newID, edgeType)
+	if err != nil {
+		return fmt.Errorf("failed to read edges: %w", err)
+	}
+	for _, e := range existing {
+		if e.TargetID == oldID {
+			return nil // Already recorded
+		}
+	}
+
+	// A supersession cycle would hide every memory in it
+	if edgeType == EdgeSupersedes {
+		older, err := s.supersessionClosure(ctx, oldID, true)
+		if err != nil {
+			return err
+		}
+		for _, id := range older {
+			if id == newID {
+				return fmt.Errorf("%s already supersedes %s", oldID, newID)
+			}
+		}
+	}
+
+	return s.AddEdge(ctx, newID, oldID, edgeType, "")
+}
+
+func edgeVerb(edgeType string) string {
+	if edgeType == EdgeSupersedes {
+		return "supersede"
+	}
+	return "contradict"
+}
+
+// staleMemories returns, for every superseded or contradicted memory, the ID of the
+// newest memory that superseded or contradicted it.
+func (s *Store) staleMemories(ctx context.Context) (superseded, contradicted map[string]string, err error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT source_id, target_id, edge_type FROM memory_edges
+		WHERE edge_type IN (?, ?) AND target_id IS NOT NULL
+		ORDER BY created_at ASC
+	`, EdgeSupersedes, EdgeContradicts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	superseded = make(map[string]string)
+	contradicted = make(map[string]string)
+	for rows.Next() {
+		var sourceID, targetID, edgeType string
+		if err := rows.Scan(&sourceID, &targetID, &edgeType); err != nil {
+			continue
+		}
+		// Later edges overwrite earlier ones so the newest replacement wins
+		if edgeType == EdgeSupersedes {
+			superseded[targetID] = sourceID
+		} else {
+			contradicted[targetID] = sourceID
+		}
+	}
+	return superseded, contradicted, rows.Err()
+}
+
+// SupersededIDs returns the set of memories that have been superseded by another memory.
+func (s *Store) SupersededIDs(ctx context.Context) (map[string]bool, error) {
+	superseded, _, err := s.staleMemories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(superseded))
+	for id := range superseded {
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// suppressStale drops superseded memories (or down-ranks them when includeSuperseded is set),
+// down-ranks contradicted ones, re-sorts by similarity and applies the limit.
+func suppressStale(memories []*Memory, superseded, contradicted map[string]string, includeSuperseded bool, limit int) []*Memory {
+	if len(superseded) == 0 && len(contradicted) == 0 {
+		if len(memories) > limit {
+			memories = memories[:limit]
+		}
+		return memories
+	}
+
+	out := memories[:0]
+	for _, mem := range memories {
+		if by, ok := superseded[mem.ID]; ok {
+			if !includeSuperseded {
+				continue
+			}
+			mem.SupersededBy = by
+			mem.Similarity *= supersededPenalty
+		}
+		if by, ok := contradicted[mem.ID]; ok {
+			mem.ContradictedBy = by
+			mem.Similarity *= contradictedPenalty
+		}
+		out = append(out, mem)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Similarity > out[j].Similarity
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// supersessionClosure returns the IDs transitively superseded by memoryID (older=true)
+// or transitively superseding it (older=false), excluding memoryID itself.
+func (s *Store) supersessionClosure(ctx context.Context, memoryID string, older bool) ([]string, error) {
+	visited := map[string]bool{memoryID: true}
+	var out []string
+	queue := []string{memoryID}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		var next []string
+		if older {
+			edges, err := s.GetEdgesFrom(ctx, cur, EdgeSupersedes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read edges: %w", err)
+			}
+			for _, e := range edges {
+				next = append(next, e.TargetID)
+			}
+		} else {
+			edges, err := s.GetEdgesTo(ctx, cur, EdgeSupersedes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read edges: %w", err)
+			}
+			for _, e := range edges {
+				next = append(next, e.SourceID)
+			}
+		}
+
+		for _, id := range next {
+			if id == "" || visited[id] {
+				continue
+			}
+			visited[id] = true
+			out = append(out, id)
+			queue = append(queue, id)
+		}
+	}
+	return out, nil
+}
+
+// Lineage returns the supersession chain a memory belongs to (oldest first) and the
+// memories it contradicts or is contradicted by.
+func (s *Store) Lineage(ctx context.Context, memoryID string) (*Lineage, error) {
+	mem, err := s.GetMemoryByID(ctx, memoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load memory: %w", err)
+	}
+	if mem == nil {
+		return nil, fmt.Errorf("memory not found: %s", memoryID)
+	}
+
+	older, err := s.supersessionClosure(ctx, mem.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := s.supersessionClosure(ctx, mem.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	superseded, _, err := s.staleMemories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edges: %w", err)
+	}
+
+	lineage := &Lineage{Chain: []*Memory{mem}}
+	for _, id := range append(older, newer...) {
+		m, err := s.GetMemoryByID(ctx, id)
+		if err != nil || m == nil {
+			continue
+		}
+		lineage.Chain = append(lineage.Chain, m)
+	}
+	for _, m := range lineage.Chain {
+		m.SupersededBy = superseded[m.ID]
+	}
+	sort.SliceStable(lineage.Chain, func(i, j int) bool {
+		return lineage.Chain[i].CreatedAt.Before(lineage.Chain[j].CreatedAt)
+	})
+
+	fromEdges, _ := s.GetEdgesFrom(ctx, mem.ID, EdgeContradicts)
+	toEdges, _ := s.GetEdgesTo(ctx, mem.ID, EdgeContradicts)
+	seen := map[string]bool{mem.ID: true}
+	var ids []string
+	for _, e := range fromEdges {
+		if e.TargetID != "" && !seen[e.TargetID] {
+			seen[e.TargetID] = true
+			ids = append(ids, e.TargetID)
+		}
+	}
+	for _, e := range toEdges {
+		if !seen[e.SourceID] {
+			seen[e.SourceID] = true
+			ids = append(ids, e.SourceID)
+		}
+	}
+	for _, id := range ids {
+		m, err := s.GetMemoryByID(ctx, id)
+		if err != nil || m == nil {
+			continue
+		}
+		lineage.Contradicts = append(lineage.Contradicts, m)
+	}
+
+	return lineage, nil
+}
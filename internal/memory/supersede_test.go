@@ -0,0 +1,207 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recallIDs(memories []*Memory) []string {
+	ids := make([]string, len(memories))
+	for i, m := range memories {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestSupersede_HidesOldMemoryFromRecall(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	oldMem, err := store.Remember(ctx, "JWT expiry is 15 minutes", nil, "")
+	require.NoError(t, err)
+	newMem, err := store.Remember(ctx, "JWT expiry is 30 minutes", nil, "")
+	require.NoError(t, err)
+	require.NoError(t, store.Supersede(ctx, newMem.ID, oldMem.ID))
+
+	results, err := store.Recall(ctx, "JWT expiry", 5, nil)
+	require.NoError(t, err)
+	assert.Contains(t, recallIDs(results), newMem.ID)
+	assert.NotContains(t, recallIDs(results), oldMem.ID)
+
+	results, err = store.RecallWithRecencyBoost(ctx, "JWT expiry", 5, RecallOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, recallIDs(results), oldMem.ID)
+
+	results, err = store.RecallWithRecencyBoost(ctx, "JWT expiry", 5, RecallOptions{IncludeSuperseded: true})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, newMem.ID, results[0].ID)
+	assert.Equal(t, oldMem.ID, results[1].ID)
+	assert.Equal(t, newMem.ID, results[1].SupersededBy)
+}
+
+func TestContradict_DownRanksOldMemory(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	oldMem, err := store.Remember(ctx, "The cache is write-through", nil, "")
+	require.NoError(t, err)
+	newMem, err := store.Remember(ctx, "The cache is write-back", nil, "")
+	require.NoError(t, err)
+
+	before, err := store.RecallWithRecencyBoost(ctx, "cache write-through", 5, RecallOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, before)
+	require.Equal(t, oldMem.ID, before[0].ID)
+	oldScore := before[0].Similarity
+
+	require.NoError(t, store.Contradict(ctx, newMem.ID, oldMem.ID))
+
+	after, err := store.RecallWithRecencyBoost(ctx, "cache write-through", 5, RecallOptions{})
+	require.NoError(t, err)
+	require.Contains(t, recallIDs(after), oldMem.ID)
+	for _, m := range after {
+		if m.ID == oldMem.ID {
+			assert.Less(t, m.Similarity, oldScore)
+			assert.Equal(t, newMem.ID, m.ContradictedBy)
+		}
+	}
+}
+
+func TestSupersede_Validation(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	a, err := store.Remember(ctx, "fact a", nil, "")
+	require.NoError(t, err)
+	b, err := store.Remember(ctx, "fact b", nil, "")
+	require.NoError(t, err)
+
+	assert.Error(t, store.Supersede(ctx, a.ID, a.ID))
+	assert.Error(t, store.Supersede(ctx, a.ID, "missing"))
+
+	require.NoError(t, store.Supersede(ctx, b.ID, a.ID))
+	require.NoError(t, store.Supersede(ctx, b.ID, a.ID), "duplicate edge is a no-op")
+	assert.Error(t, store.Supersede(ctx, a.ID, b.ID), "cycles are rejected")
+
+	edges, err := store.GetEdgesFrom(ctx, b.ID, EdgeSupersedes)
+	require.NoError(t, err)
+	assert.Len(t, edges, 1)
+}
+
+func TestSupersede_ResolvesAliases(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	dup, err := store.Remember(ctx, "The API uses JWT tokens that expire after 15 minutes", nil, "")
+	require.NoError(t, err)
+	canonical, err := store.Remember(ctx, "The API uses JWT tokens which expire after 15 minutes.", nil, "")
+	require.NoError(t, err)
+	_, err = store.Consolidate(ctx, ConsolidateOptions{})
+	require.NoError(t, err)
+	newer, err := store.Remember(ctx, "JWT tokens now expire after 5 minutes", nil, "")
+	require.NoError(t, err)
+
+	// The merged-away ID names the memory that absorbed it
+	require.NoError(t, store.Supersede(ctx, newer.ID, dup.ID))
+	edges, err := store.GetEdgesFrom(ctx, newer.ID, EdgeSupersedes)
+	require.NoError(t, err)
+	require.Len(t, edges, 1)
+	assert.Equal(t, canonical.ID, edges[0].TargetID)
+	require.NoError(t, store.Supersede(ctx, newer.ID, canonical.ID), "the same edge through the canonical ID is a no-op")
+	edges, err = store.GetEdgesFrom(ctx, newer.ID, EdgeSupersedes)
+	require.NoError(t, err)
+	assert.Len(t, edges, 1)
+
+	assert.Error(t, store.Contradict(ctx, dup.ID, canonical.ID), "an alias and its memory are the same memory")
+
+	results, err := store.Recall(ctx, "JWT tokens expire", 5, nil)
+	require.NoError(t, err)
+	for _, m := range results {
+		assert.NotEqual(t, canonical.ID, m.ID, "the superseded memory should be hidden")
+	}
+}
+
+func TestLineage(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	v1, err := store.Remember(ctx, "Deploys go out on Mondays", nil, "")
+	require.NoError(t, err)
+	v2, err := store.Remember(ctx, "Deploys go out on Tuesdays", nil, "")
+	require.NoError(t, err)
+	v3, err := store.Remember(ctx, "Deploys go out on Thursdays", nil, "")
+	require.NoError(t, err)
+	other, err := store.Remember(ctx, "We never deploy during the week", nil, "")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Supersede(ctx, v2.ID, v1.ID))
+	require.NoError(t, store.Supersede(ctx, v3.ID, v2.ID))
+	require.NoError(t, store.Contradict(ctx, other.ID, v3.ID))
+
+	lineage, err := store.Lineage(ctx, v2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{v1.ID, v2.ID, v3.ID}, recallIDs(lineage.Chain))
+	assert.Equal(t, v2.ID, lineage.Chain[0].SupersededBy)
+	assert.Empty(t, lineage.Chain[2].SupersededBy)
+
+	lineage, err = store.Lineage(ctx, v3.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{other.ID}, recallIDs(lineage.Contradicts))
+
+	_, err = store.Lineage(ctx, "missing")
+	assert.Error(t, err)
+}
+
+func TestLineage_ResolvesAliases(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	dup, err := store.Remember(ctx, "The API uses JWT tokens that expire after 15 minutes", nil, "")
+	require.NoError(t, err)
+	canonical, err := store.Remember(ctx, "The API uses JWT tokens which expire after 15 minutes.", nil, "")
+	require.NoError(t, err)
+	_, err = store.Consolidate(ctx, ConsolidateOptions{})
+	require.NoError(t, err)
+	newer, err := store.Remember(ctx, "JWT tokens now expire after 5 minutes", nil, "")
+	require.NoError(t, err)
+	other, err := store.Remember(ctx, "Tokens never expire", nil, "")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Supersede(ctx, newer.ID, canonical.ID))
+	require.NoError(t, store.Contradict(ctx, other.ID, canonical.ID))
+
+	// Looking up the merged-away ID gives the canonical memory's lineage
+	lineage, err := store.Lineage(ctx, dup.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{canonical.ID, newer.ID}, recallIDs(lineage.Chain))
+	assert.Equal(t, newer.ID, lineage.Chain[0].SupersededBy)
+	assert.Equal(t, []string{other.ID}, recallIDs(lineage.Contradicts))
+}
+
+func TestForget_UnhidesSupersededMemory(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	oldMem, err := store.Remember(ctx, "Use Redis for sessions", nil, "")
+	require.NoError(t, err)
+	newMem, err := store.Remember(ctx, "Use Postgres for sessions", nil, "")
+	require.NoError(t, err)
+	require.NoError(t, store.Supersede(ctx, newMem.ID, oldMem.ID))
+
+	require.NoError(t, store.Forget(ctx, newMem.ID))
+
+	superseded, err := store.SupersededIDs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, superseded)
+}
@@ -0,0 +1,72 @@
+// Package memory: symbol-level citations for Go source.
+// Instead of a line range, a citation can name a Go symbol (a package, type, func or
+// method). The symbol is resolved with go/parser when the citation is added and again
+// on every verification, so it is found wherever it moved in the file. Its normalized
+// AST hash decides whether it changed: formatting and comment edits keep full
+// confidence, while a different hash lowers confidence by how much the text differs.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CanopyHQ/phloem/internal/memory/gosym"
+)
+
+// symbolChangePenalty scales the text similarity of a symbol whose AST changed, so a
+// semantic change always costs confidence even when few words differ
+const symbolChangePenalty = 0.9
+
+// AddSymbolCitation cites a Go symbol in filePath ("RateLimiter.Allow", "(*RateLimiter).Allow",
+// "NewLimiter", or the package name for the whole file). The symbol's current line range
+// and source are stored along with its AST hash.
+func (s *Store) AddSymbolCitation(ctx context.Context, memoryID, filePath, symbol, commitSHA string) (*Citation, error) {
+	if !gosym.IsGoFile(filePath) {
+		return nil, fmt.Errorf("symbol citations need a .go file: %s", filePath)
+	}
+	src, err := readCitedFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	loc, err := gosym.Resolve(filePath, src, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return s.insertCitation(ctx, &Citation{
+		MemoryID:  memoryID,
+		FilePath:  filePath,
+		Symbol:    loc.Symbol,
+		ASTHash:   loc.Hash,
+		StartLine: loc.StartLine,
+		EndLine:   loc.EndLine,
+		CommitSHA: commitSHA,
+		Content:   loc.Source,
+	})
+}
+
+// verifySymbolCitation checks a symbol citation against the file's current source.
+// resolved is false when the symbol can no longer be found, leaving c untouched.
+func (s *Store) verifySymbolCitation(ctx context.Context, c *Citation, src []byte) (valid, resolved bool) {
+	loc, err := gosym.Resolve(c.FilePath, src, c.Symbol)
+	if err != nil {
+		return false, false
+	}
+	c.StartLine, c.EndLine = loc.StartLine, loc.EndLine
+	current, findings := s.scrub(loc.Source)
+
+	if loc.Hash == c.ASTHash {
+		// Only formatting or comments changed: keep the snapshot in step with the file
+		if current != c.Content {
+			c.Content = current
+			s.recordRedactions(ctx, c.MemoryID, RedactedCitation, findings)
+			s.db.ExecContext(ctx, `UPDATE citations SET content = ? WHERE id = ?`, s.seal(current), c.ID)
+		}
+		s.markCitationVerified(ctx, c, 1.0)
+		return true, true
+	}
+
+	c.Confidence = stringSimilarity(c.Content, current) * symbolChangePenalty
+	s.updateCitationLocation(ctx, c)
+	return c.Confidence > 0.8, true
+}
@@ -2,9 +2,9 @@ package memory
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
@@ -15,12 +15,17 @@ func init() {
 }
 
 // vecIndex manages the sqlite-vec vector index for fast KNN queries.
-// If the extension fails to load, all operations are no-ops and the store
-// falls back to brute-force cosine similarity.
+// If the extension fails to load (or PHLOEM_VECTOR_INDEX=hnsw), the vectors are kept
+// in an in-process HNSW graph instead (see hnsw.go); PHLOEM_VECTOR_INDEX=none leaves
+// recall to brute-force cosine similarity.
+// The index only holds vectors from one embedding model, so a KNN query never
+// compares vectors from different embedding spaces.
 type vecIndex struct {
 	db         *sql.DB
 	dimensions int
+	model      string
 	available  bool
+	hnsw       *hnswIndex // Used instead of sqlite-vec when set
 }
 
 type vecResult struct {
@@ -28,17 +33,44 @@ type vecResult struct {
 	Distance float64
 }
 
-func newVecIndex(db *sql.DB, dimensions int) *vecIndex {
-	vi := &vecIndex{db: db, dimensions: dimensions}
-	if err := vi.ensureSchema(); err != nil {
-		fmt.Fprintf(os.Stderr, "⚠️  sqlite-vec not available, using linear scan: %v\n", err)
-		vi.available = false
-	} else {
-		vi.available = true
+// newVecIndex opens the vector index for the given embedder; an HNSW index is kept
+// in dataDir ("" keeps it in memory)
+func newVecIndex(db *sql.DB, dataDir string, dimensions int, model string) *vecIndex {
+	vi := &vecIndex{db: db, dimensions: dimensions, model: model}
+	switch os.Getenv("PHLOEM_VECTOR_INDEX") {
+	case "none":
+		fmt.Fprintln(os.Stderr, "🔍 Vector index disabled, using linear scan")
+		return vi
+	case "hnsw":
+		vi.dropVec0() // It would miss the changes made meanwhile
+		vi.hnsw = loadHNSWIndex(dataDir, dimensions, model)
+	default:
+		if err := vi.ensureSchema(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  sqlite-vec not available, using in-process HNSW index: %v\n", err)
+			vi.hnsw = loadHNSWIndex(dataDir, dimensions, model)
+		} else if dataDir != "" {
+			os.Remove(filepath.Join(dataDir, hnswFileName)) // Likewise
+		}
 	}
+	vi.available = true
 	return vi
 }
 
+// dropVec0 removes the sqlite-vec index, so it is rebuilt when used again
+func (vi *vecIndex) dropVec0() {
+	vi.db.Exec(`DROP TABLE IF EXISTS memory_embeddings`)
+	vi.db.Exec(`DELETE FROM memory_vec_ids`)
+	vi.db.Exec(`DELETE FROM vec_metadata`)
+}
+
+// Close saves the HNSW index, if the store uses one
+func (vi *vecIndex) Close() error {
+	if vi.hnsw == nil {
+		return nil
+	}
+	return vi.hnsw.Save()
+}
+
 func (vi *vecIndex) ensureSchema() error {
 	// Verify vec0 extension is loaded
 	var vecVersion string
@@ -59,8 +91,8 @@ func (vi *vecIndex) ensureSchema() error {
 		return fmt.Errorf("failed to create vec ID mapping: %w", err)
 	}
 
-	// Handle dimension changes (e.g. switching from local to OpenAI embedder)
-	vi.handleDimensionChange()
+	// Handle embedder changes (e.g. switching from local to OpenAI embedder)
+	vi.handleEmbedderChange()
 
 	// Create vec0 virtual table with cosine distance
 	createSQL := fmt.Sprintf(
@@ -71,34 +103,49 @@ func (vi *vecIndex) ensureSchema() error {
 		return fmt.Errorf("failed to create vec0 table: %w", err)
 	}
 
-	// Record current dimensions
+	// Record current dimensions and model
 	vi.db.Exec(`INSERT OR REPLACE INTO vec_metadata (key, value) VALUES ('dimensions', ?)`,
 		fmt.Sprintf("%d", vi.dimensions))
+	vi.db.Exec(`INSERT OR REPLACE INTO vec_metadata (key, value) VALUES ('model', ?)`, vi.model)
 
 	return nil
 }
 
-// handleDimensionChange detects if the embedder dimensions changed since last run
-// and drops the vec0 table so it can be recreated with the correct dimensions.
-func (vi *vecIndex) handleDimensionChange() {
+// handleEmbedderChange detects if the embedder dimensions or model changed since last
+// run and drops the vec0 table so it can be recreated and backfilled for the new model.
+func (vi *vecIndex) handleEmbedderChange() {
 	var storedDim string
 	err := vi.db.QueryRow(`SELECT value FROM vec_metadata WHERE key = 'dimensions'`).Scan(&storedDim)
 	if err != nil {
 		return // No stored dimensions yet, first run
 	}
-	if storedDim == fmt.Sprintf("%d", vi.dimensions) {
-		return // Dimensions match
+	// Indexes built before models were recorded have none; they hold the legacy
+	// vectors that claimLegacyEmbeddings assigned to the current model
+	var storedModel string
+	vi.db.QueryRow(`SELECT value FROM vec_metadata WHERE key = 'model'`).Scan(&storedModel)
+
+	switch {
+	case storedDim != fmt.Sprintf("%d", vi.dimensions):
+		fmt.Fprintf(os.Stderr, "⚠️  Embedding dimensions changed (%s -> %d), rebuilding vec index\n", storedDim, vi.dimensions)
+	case storedModel != "" && storedModel != vi.model:
+		fmt.Fprintf(os.Stderr, "⚠️  Embedding model changed (%s -> %s), rebuilding vec index\n", storedModel, vi.model)
+	default:
+		return // Same embedder
 	}
-
-	// Dimension mismatch - drop and recreate
-	fmt.Fprintf(os.Stderr, "⚠️  Embedding dimensions changed (%s -> %d), rebuilding vec index\n", storedDim, vi.dimensions)
-	vi.db.Exec(`DROP TABLE IF EXISTS memory_embeddings`)
-	vi.db.Exec(`DELETE FROM memory_vec_ids`)
+	vi.dropVec0()
 }
 
-// Insert adds or replaces a memory's embedding in the vec0 index.
-func (vi *vecIndex) Insert(memoryID string, embedding []float32) error {
-	if !vi.available || len(embedding) == 0 || len(embedding) != vi.dimensions {
+// Insert adds or replaces a memory's embedding in the vec0 index. Embeddings from
+// another model are left out (and any older entry for the memory removed).
+func (vi *vecIndex) Insert(memoryID string, embedding []float32, model string) error {
+	if !vi.available {
+		return nil
+	}
+	if model != vi.model || len(embedding) == 0 || len(embedding) != vi.dimensions {
+		return vi.Delete(memoryID)
+	}
+	if vi.hnsw != nil {
+		vi.hnsw.Insert(memoryID, embedding)
 		return nil
 	}
 
@@ -136,6 +183,9 @@ func (vi *vecIndex) Search(queryEmbedding []float32, limit int) ([]vecResult, er
 	if !vi.available {
 		return nil, fmt.Errorf("vec index not available")
 	}
+	if vi.hnsw != nil {
+		return vi.hnsw.Search(queryEmbedding, limit), nil
+	}
 
 	blob, err := sqlite_vec.SerializeFloat32(queryEmbedding)
 	if err != nil {
@@ -217,6 +267,10 @@ func (vi *vecIndex) Delete(memoryID string) error {
 	if !vi.available {
 		return nil
 	}
+	if vi.hnsw != nil {
+		vi.hnsw.Delete(memoryID)
+		return nil
+	}
 	var vecID int64
 	if err := vi.db.QueryRow(`SELECT vec_id FROM memory_vec_ids WHERE memory_id = ?`, memoryID).Scan(&vecID); err != nil {
 		return nil // Not in vec index
@@ -226,19 +280,22 @@ func (vi *vecIndex) Delete(memoryID string) error {
 	return nil
 }
 
-// Backfill populates the vec0 index from existing memories that have embeddings.
+// Backfill populates the vec0 index from existing memories embedded with the index's model.
 // Returns the number of memories backfilled.
 func (vi *vecIndex) Backfill(db *sql.DB) (int, error) {
 	if !vi.available {
 		return 0, nil
 	}
+	if vi.hnsw != nil {
+		return vi.hnsw.Backfill(db)
+	}
 
 	// Check if backfill is needed
 	var vecCount int
 	vi.db.QueryRow(`SELECT COUNT(*) FROM memory_vec_ids`).Scan(&vecCount)
 
 	var memCount int
-	db.QueryRow(`SELECT COUNT(*) FROM memories WHERE embedding IS NOT NULL AND embedding != '' AND embedding != '[]' AND embedding != 'null'`).Scan(&memCount)
+	db.QueryRow(`SELECT COUNT(*) FROM memories WHERE embedding_model = ?`, vi.model).Scan(&memCount)
 
 	if vecCount >= memCount || memCount == 0 {
 		return 0, nil
@@ -249,9 +306,8 @@ func (vi *vecIndex) Backfill(db *sql.DB) (int, error) {
 		SELECT m.id, m.embedding
 		FROM memories m
 		LEFT JOIN memory_vec_ids v ON v.memory_id = m.id
-		WHERE v.vec_id IS NULL
-		AND m.embedding IS NOT NULL AND m.embedding != '' AND m.embedding != '[]' AND m.embedding != 'null'
-	`)
+		WHERE v.vec_id IS NULL AND m.embedding_model = ?
+	`, vi.model)
 	if err != nil {
 		return 0, err
 	}
@@ -259,13 +315,14 @@ func (vi *vecIndex) Backfill(db *sql.DB) (int, error) {
 
 	count := 0
 	for rows.Next() {
-		var memID, embJSON string
-		if err := rows.Scan(&memID, &embJSON); err != nil {
+		var memID string
+		var embeddingBlob []byte
+		if err := rows.Scan(&memID, &embeddingBlob); err != nil {
 			continue
 		}
 
-		var embedding []float32
-		if err := json.Unmarshal([]byte(embJSON), &embedding); err != nil {
+		embedding, err := decodeEmbedding(embeddingBlob)
+		if err != nil {
 			continue
 		}
 
@@ -273,7 +330,7 @@ func (vi *vecIndex) Backfill(db *sql.DB) (int, error) {
 			continue // Skip mismatched dimensions
 		}
 
-		if err := vi.Insert(memID, embedding); err != nil {
+		if err := vi.Insert(memID, embedding, vi.model); err != nil {
 			continue
 		}
 		count++
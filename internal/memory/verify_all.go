@@ -0,0 +1,301 @@
+// Package memory: bulk citation verification.
+// VerifyAllCitations checks every citation (optionally within one scope and the scopes
+// below it) against the files on disk. Citations are grouped by file and the groups
+// verified in parallel; memories whose aggregate confidence ends up below a threshold
+// are reported, and can be tagged "stale" so agents stop relying on them until their
+// citations hold again.
+
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StaleTag marks memories whose citations no longer match the code
+const StaleTag = "stale"
+
+// DefaultStaleThreshold is the aggregate citation confidence below which a memory is stale
+const DefaultStaleThreshold = 0.5
+
+// VerifyAllOptions controls VerifyAllCitations
+type VerifyAllOptions struct {
+	Scope     string  // Only memories in this scope or below it ("" for every memory)
+	Threshold float64 // Stale below this aggregate confidence (0 uses DefaultStaleThreshold)
+	TagStale  bool    // Tag stale memories with StaleTag and untag ones that recovered
+	Workers   int     // Files verified concurrently (0 uses the number of CPUs)
+
+	// Files limits verification to citations of these paths, relative to RepoRoot
+	// (used by git hooks to re-verify only what a commit touched). Ignored when nil.
+	RepoRoot string
+	Files    []string
+}
+
+// FileVerification is the outcome for the citations of one file
+type FileVerification struct {
+	FilePath  string `json:"file_path"`
+	RepoRoot  string `json:"repo_root,omitempty"`
+	Citations int    `json:"citations"`
+	Valid     int    `json:"valid"`
+	Invalid   int    `json:"invalid"`
+	Errors    int    `json:"errors,omitempty"`
+}
+
+// StaleMemory is a memory whose citations fell below the threshold
+type StaleMemory struct {
+	MemoryID   string  `json:"memory_id"`
+	Preview    string  `json:"preview"`
+	Confidence float64 `json:"confidence"`
+}
+
+// VerifyAllResult summarizes a VerifyAllCitations run
+type VerifyAllResult struct {
+	Citations int                `json:"citations"`
+	Valid     int                `json:"valid"`
+	Invalid   int                `json:"invalid"`
+	Files     []FileVerification `json:"files"` // Ordered by path
+	Stale     []StaleMemory      `json:"stale"` // Lowest confidence first
+	Tagged    int                `json:"tagged"`
+	Untagged  int                `json:"untagged"`
+	Threshold float64            `json:"threshold"`
+	Changed   []string           `json:"changed,omitempty"` // Memories whose aggregate confidence changed
+}
+
+// CitedFile is a file referenced by at least one citation
+type CitedFile struct {
+	RepoRoot string `json:"repo_root,omitempty"`
+	FilePath string `json:"file_path"` // Relative to RepoRoot when set
+}
+
+// Path is where the cited file is expected on disk
+func (f CitedFile) Path() string {
+	return filepath.Join(f.RepoRoot, f.FilePath)
+}
+
+// citationRef is the part of a citation needed to schedule its verification
+type citationRef struct {
+	id, memoryID, filePath, repoRoot string
+}
+
+// VerifyAllCitations verifies every citation in opts.Scope (or only those of opts.Files),
+// updating confidence and line ranges as VerifyCitation does, and reports memories
+// that are now stale.
+func (s *Store) VerifyAllCitations(ctx context.Context, opts VerifyAllOptions) (*VerifyAllResult, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultStaleThreshold
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+
+	query := `
+		SELECT c.id, c.memory_id, c.file_path, COALESCE(c.repo_root, '')
+		FROM citations c JOIN memories m ON m.id = c.memory_id
+		WHERE 1 = 1`
+	var args []interface{}
+	if opts.Scope != "" {
+		cond, scopeArgs := inSubtree("m.scope", opts.Scope)
+		query += ` AND ` + cond
+		args = append(args, scopeArgs...)
+	}
+	if opts.Files != nil {
+		if len(opts.Files) == 0 {
+			return &VerifyAllResult{Threshold: opts.Threshold}, nil
+		}
+		// Anchored citations match on repo-relative path; older ones on the absolute path
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(opts.Files)), ",")
+		query += ` AND ((c.repo_root = ? AND c.file_path IN (` + placeholders + `)) OR c.file_path IN (` + placeholders + `))`
+		args = append(args, opts.RepoRoot)
+		for _, f := range opts.Files {
+			args = append(args, f)
+		}
+		for _, f := range opts.Files {
+			args = append(args, filepath.Join(opts.RepoRoot, f))
+		}
+	}
+	query += ` ORDER BY c.repo_root, c.file_path`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list citations: %w", err)
+	}
+	var groups [][]citationRef
+	byFile := make(map[string]int)
+	memoryIDs := make(map[string]bool)
+	for rows.Next() {
+		var c citationRef
+		if err := rows.Scan(&c.id, &c.memoryID, &c.filePath, &c.repoRoot); err != nil {
+			continue
+		}
+		key := filepath.Join(c.repoRoot, c.filePath)
+		i, ok := byFile[key]
+		if !ok {
+			i = len(groups)
+			byFile[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], c)
+		memoryIDs[c.memoryID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list citations: %w", err)
+	}
+
+	before := make(map[string]float64, len(memoryIDs))
+	for id := range memoryIDs {
+		before[id], _ = s.GetMemoryConfidence(ctx, id)
+	}
+
+	result := &VerifyAllResult{Threshold: opts.Threshold, Files: make([]FileVerification, len(groups))}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				group := groups[i]
+				file := FileVerification{FilePath: group[0].filePath, RepoRoot: group[0].repoRoot, Citations: len(group)}
+				for _, c := range group {
+					_, valid, err := s.VerifyCitation(ctx, c.id)
+					switch {
+					case err != nil:
+						file.Errors++
+						file.Invalid++
+					case valid:
+						file.Valid++
+					default:
+						file.Invalid++
+					}
+				}
+				result.Files[i] = file
+			}
+		}()
+	}
+	for i := range groups {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, f := range result.Files {
+		result.Citations += f.Citations
+		result.Valid += f.Valid
+		result.Invalid += f.Invalid
+	}
+	sort.Slice(result.Files, func(i, j int) bool {
+		return filepath.Join(result.Files[i].RepoRoot, result.Files[i].FilePath) <
+			filepath.Join(result.Files[j].RepoRoot, result.Files[j].FilePath)
+	})
+
+	for id := range memoryIDs {
+		confidence, _ := s.GetMemoryConfidence(ctx, id)
+		if confidence != before[id] {
+			result.Changed = append(result.Changed, id)
+		}
+		stale := confidence < opts.Threshold
+		if stale {
+			m, err := s.GetMemoryByID(ctx, id)
+			if err != nil || m == nil {
+				continue
+			}
+			preview := m.Content
+			if len(preview) > 80 {
+				preview = preview[:80] + "..."
+			}
+			result.Stale = append(result.Stale, StaleMemory{MemoryID: id, Preview: preview, Confidence: confidence})
+		}
+		if opts.TagStale {
+			changed, err := s.setTag(ctx, id, StaleTag, stale)
+			if err != nil {
+				return result, err
+			}
+			switch {
+			case changed && stale:
+				result.Tagged++
+			case changed:
+				result.Untagged++
+			}
+		}
+	}
+	sort.Strings(result.Changed)
+	sort.Slice(result.Stale, func(i, j int) bool {
+		if result.Stale[i].Confidence != result.Stale[j].Confidence {
+			return result.Stale[i].Confidence < result.Stale[j].Confidence
+		}
+		return result.Stale[i].MemoryID < result.Stale[j].MemoryID
+	})
+	return result, nil
+}
+
+// CitedFiles returns every distinct file referenced by a citation
+func (s *Store) CitedFiles(ctx context.Context) ([]CitedFile, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT COALESCE(repo_root, ''), file_path FROM citations ORDER BY 1, 2
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cited files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []CitedFile
+	for rows.Next() {
+		var f CitedFile
+		if err := rows.Scan(&f.RepoRoot, &f.FilePath); err != nil {
+			continue
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// setTag adds or removes a tag without recording a revision or touching updated_at.
+// Reports whether the memory's tags changed.
+func (s *Store) setTag(ctx context.Context, id, tag string, on bool) (bool, error) {
+	var tagsJSON string
+	if err := s.db.QueryRowContext(ctx, `SELECT tags FROM memories WHERE id = ?`, id).Scan(&tagsJSON); err != nil {
+		return false, fmt.Errorf("failed to load tags for %s: %w", id, err)
+	}
+	var tags []string
+	json.Unmarshal([]byte(tagsJSON), &tags)
+
+	has := false
+	kept := tags[:0:0]
+	for _, t := range tags {
+		if t == tag {
+			has = true
+			continue
+		}
+		kept = append(kept, t)
+	}
+	if has == on {
+		return false, nil
+	}
+	if on {
+		kept = append(tags, tag)
+	}
+
+	newJSON, _ := json.Marshal(kept)
+	if _, err := s.db.ExecContext(ctx, `UPDATE memories SET tags = ? WHERE id = ?`, string(newJSON), id); err != nil {
+		return false, fmt.Errorf("failed to update tags for %s: %w", id, err)
+	}
+	if on {
+		_, err := s.db.ExecContext(ctx, `INSERT INTO memory_tags (memory_id, tag) VALUES (?, ?)`, id, tag)
+		return err == nil, err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM memory_tags WHERE memory_id = ? AND tag = ?`, id, tag)
+	return err == nil, err
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyAllCitations_GroupsByFileAndTagsStale(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.go")
+	gone := filepath.Join(dir, "gone.go")
+	require.NoError(t, os.WriteFile(good, []byte("package a\n\nconst Timeout = 30\n"), 0644))
+	require.NoError(t, os.WriteFile(gone, []byte("package a\n\nconst Retries = 3\n"), 0644))
+
+	fresh, err := store.RememberWithScope(ctx, "Timeout is 30 seconds", []string{"config"}, "", "github.com/acme/api")
+	require.NoError(t, err)
+	stale, err := store.RememberWithScope(ctx, "Requests retry 3 times", []string{"config"}, "", "github.com/acme/api")
+	require.NoError(t, err)
+	other, err := store.RememberWithScope(ctx, "Unrelated repo", nil, "", "github.com/acme/web")
+	require.NoError(t, err)
+
+	_, err = store.AddCitation(ctx, fresh.ID, good, 3, 3, "", "const Timeout = 30")
+	require.NoError(t, err)
+	_, err = store.AddCitation(ctx, stale.ID, gone, 3, 3, "", "const Retries = 3")
+	require.NoError(t, err)
+	_, err = store.AddCitation(ctx, stale.ID, good, 1, 1, "", "package a")
+	require.NoError(t, err)
+	_, err = store.AddCitation(ctx, other.ID, filepath.Join(dir, "missing.go"), 1, 1, "", "x")
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(gone))
+
+	result, err := store.VerifyAllCitations(ctx, VerifyAllOptions{Scope: "github.com/acme/api", Threshold: 0.9, TagStale: true, Workers: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Citations)
+	assert.Equal(t, 2, result.Valid)
+	assert.Equal(t, 1, result.Invalid)
+	require.Len(t, result.Files, 2)
+	assert.Equal(t, gone, result.Files[0].FilePath)
+	assert.Equal(t, 1, result.Files[0].Invalid)
+	assert.Equal(t, good, result.Files[1].FilePath)
+	assert.Equal(t, 2, result.Files[1].Valid)
+
+	require.Len(t, result.Stale, 1)
+	assert.Equal(t, stale.ID, result.Stale[0].MemoryID)
+	assert.InDelta(t, 0.5, result.Stale[0].Confidence, 0.001)
+	assert.Equal(t, 1, result.Tagged)
+	assert.Equal(t, []string{stale.ID}, result.Changed, "only the memory whose confidence moved is reported")
+
+	tagged, _ := store.GetMemoryByID(ctx, stale.ID)
+	assert.ElementsMatch(t, []string{"config", StaleTag}, tagged.Tags)
+	untouched, _ := store.GetMemoryByID(ctx, other.ID)
+	assert.NotContains(t, untouched.Tags, StaleTag, "memories outside the scope are not verified")
+
+	// Restoring the file clears the tag on the next run
+	require.NoError(t, os.WriteFile(gone, []byte("package a\n\nconst Retries = 3\n"), 0644))
+	result, err = store.VerifyAllCitations(ctx, VerifyAllOptions{Scope: "github.com/acme/api", Threshold: 0.9, TagStale: true})
+	require.NoError(t, err)
+	assert.Empty(t, result.Stale)
+	assert.Equal(t, 1, result.Untagged)
+	recovered, _ := store.GetMemoryByID(ctx, stale.ID)
+	assert.Equal(t, []string{"config"}, recovered.Tags)
+}
+
+func TestVerifyAllCitations_ScopeSubtree(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "limits.go")
+	require.NoError(t, os.WriteFile(file, []byte("package a\n\nconst MaxBody = 1 << 20\n"), 0644))
+	for _, scope := range []string{"github.com/acme", "github.com/acme/api", "github.com/acme-labs/api", "github.com/other"} {
+		mem, err := store.RememberWithScope(ctx, "Request bodies are limited in "+scope, nil, "", scope)
+		require.NoError(t, err)
+		_, err = store.AddCitation(ctx, mem.ID, file, 3, 3, "", "const MaxBody = 1 << 20")
+		require.NoError(t, err)
+	}
+
+	// The organization covers its repositories, but not a sibling sharing its prefix
+	result, err := store.VerifyAllCitations(ctx, VerifyAllOptions{Scope: "github.com/acme"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Citations)
+
+	result, err = store.VerifyAllCitations(ctx, VerifyAllOptions{Scope: "github.com/acme/api"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Citations)
+
+	result, err = store.VerifyAllCitations(ctx, VerifyAllOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Citations)
+}
+
+func TestCitedFiles(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mem, err := store.Remember(ctx, "cites two files", nil, "")
+	require.NoError(t, err)
+	_, err = store.AddCitation(ctx, mem.ID, "/src/b.go", 1, 2, "", "")
+	require.NoError(t, err)
+	_, err = store.AddCitation(ctx, mem.ID, "/src/a.go", 1, 2, "", "")
+	require.NoError(t, err)
+	_, err = store.AddCitation(ctx, mem.ID, "/src/a.go", 5, 9, "", "")
+	require.NoError(t, err)
+
+	files, err := store.CitedFiles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []CitedFile{{FilePath: "/src/a.go"}, {FilePath: "/src/b.go"}}, files)
+	assert.Equal(t, "/src/a.go", files[0].Path())
+}
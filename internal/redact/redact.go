@@ -0,0 +1,182 @@
+// Package redact finds credentials and other secrets in text and replaces them with
+// placeholders before the text is stored.
+//
+// Detection is pattern based (AWS keys, GitHub/Slack/Stripe tokens, JWTs, private key
+// blocks, secret-looking assignments such as .env lines) plus a Shannon-entropy check
+// for long random-looking strings. Redacted values are never returned or stored; a
+// Finding only carries the kind of secret and a short fingerprint so the same secret
+// can be recognised across memories.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kinds of secrets reported in Finding.Kind
+const (
+	KindAWSAccessKey = "aws_access_key"
+	KindGitHubToken  = "github_token"
+	KindSlackToken   = "slack_token"
+	KindStripeKey    = "stripe_key"
+	KindAPIKey       = "api_key"
+	KindJWT          = "jwt"
+	KindPrivateKey   = "private_key"
+	KindAssignment   = "secret_assignment"
+	KindHighEntropy  = "high_entropy_string"
+)
+
+// Finding describes one redacted secret
+type Finding struct {
+	Kind        string `json:"kind"`
+	Placeholder string `json:"placeholder"`
+	Fingerprint string `json:"fingerprint"` // First 12 hex chars of the secret's SHA-256
+}
+
+// detector matches one secret format. When group > 0 only that submatch is redacted,
+// so "API_KEY=abc..." keeps its variable name.
+type detector struct {
+	kind  string
+	re    *regexp.Regexp
+	group int
+}
+
+// Ordered from most to least specific; earlier matches win over overlapping later ones
+var detectors = []detector{
+	{KindPrivateKey, regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`), 0},
+	{KindAWSAccessKey, regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA)[0-9A-Z]{16}\b`), 0},
+	{KindGitHubToken, regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`), 0},
+	{KindSlackToken, regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), 0},
+	{KindStripeKey, regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}\b`), 0},
+	{KindAPIKey, regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`), 0},
+	{KindJWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), 0},
+	{KindAssignment, regexp.MustCompile(`(?i)\b[A-Z0-9_.-]*(?:secret|token|passw(?:or)?d|pwd|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)[A-Z0-9_.-]*["']?\s*[:=]\s*["']?([^\s"'` + "`" + `,;]{8,})`), 1},
+}
+
+// candidateRe finds tokens to test for entropy
+var candidateRe = regexp.MustCompile(`[A-Za-z0-9+/=_-]{32,}`)
+
+// MinEntropy is the Shannon entropy (bits per character) above which a long token is treated as a secret.
+// Hex strings such as commit SHAs top out at 4 bits and are never flagged.
+const MinEntropy = 4.5
+
+// Enabled reports whether redaction is on. Set PHLOEM_REDACT=off to store text verbatim.
+func Enabled() bool {
+	v := strings.ToLower(os.Getenv("PHLOEM_REDACT"))
+	return v != "off" && v != "false" && v != "0"
+}
+
+// Scrub replaces secrets in text with "[REDACTED:<kind>]" placeholders and
+// returns the scrubbed text and what was redacted, in order of appearance.
+func Scrub(text string) (string, []Finding) {
+	type span struct {
+		start, end int
+		kind       string
+	}
+	var spans []span
+	overlaps := func(start, end int) bool {
+		for _, s := range spans {
+			if start < s.end && end > s.start {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, d := range detectors {
+		for _, m := range d.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if d.group > 0 {
+				start, end = m[2*d.group], m[2*d.group+1]
+				if start < 0 || !plausibleValue(text[start:end]) {
+					continue
+				}
+			}
+			if !overlaps(start, end) {
+				spans = append(spans, span{start, end, d.kind})
+			}
+		}
+	}
+	for _, m := range candidateRe.FindAllStringIndex(text, -1) {
+		if !overlaps(m[0], m[1]) && looksRandom(text[m[0]:m[1]]) {
+			spans = append(spans, span{m[0], m[1], KindHighEntropy})
+		}
+	}
+	if len(spans) == 0 {
+		return text, nil
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var b strings.Builder
+	findings := make([]Finding, 0, len(spans))
+	last := 0
+	for _, s := range spans {
+		placeholder := Placeholder(s.kind)
+		b.WriteString(text[last:s.start])
+		b.WriteString(placeholder)
+		last = s.end
+		findings = append(findings, Finding{Kind: s.kind, Placeholder: placeholder, Fingerprint: fingerprint(text[s.start:s.end])})
+	}
+	b.WriteString(text[last:])
+	return b.String(), findings
+}
+
+// Placeholder is the text a secret of the given kind is replaced with
+func Placeholder(kind string) string {
+	return "[REDACTED:" + kind + "]"
+}
+
+// plausibleValue filters assignment values that are references or code rather than
+// literal secrets: "${DB_PASSWORD}", "os.Getenv", "[REDACTED:...]" and plain short words.
+func plausibleValue(v string) bool {
+	if strings.ContainsAny(v[:1], "$<{[(%") || strings.ContainsAny(v, "()") {
+		return false
+	}
+	return len(v) >= 16 || strings.ContainsAny(v, "0123456789!@#^&*+/=~")
+}
+
+// looksRandom reports whether a token has the character mix and entropy of a generated secret
+func looksRandom(token string) bool {
+	var upper, lower, digit bool
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper && lower && digit && Entropy(token) >= MinEntropy
+}
+
+// Entropy returns the Shannon entropy of s in bits per character
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+func fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])[:12]
+}
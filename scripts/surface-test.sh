@@ -92,11 +92,12 @@ else
     log_fail "graft export did not create file"
 fi
 
-cli_ok "graft inspect" graft inspect "$TMPDIR/test.graft"
+# The export is unsigned, so inspect and import need --allow-unsigned
+cli_ok "graft inspect" graft inspect "$TMPDIR/test.graft" --allow-unsigned
 
 # Reset data dir for clean import
 UNPACK_DIR=$(mktemp -d)
-PHLOEM_DATA_DIR="$UNPACK_DIR" "$BINARY" graft import "$TMPDIR/test.graft" >"$TMPDIR/stdout" 2>"$TMPDIR/stderr" && \
+PHLOEM_DATA_DIR="$UNPACK_DIR" "$BINARY" graft import "$TMPDIR/test.graft" --allow-unsigned >"$TMPDIR/stdout" 2>"$TMPDIR/stderr" && \
     log_pass "graft import" || log_fail "graft import"
 rm -rf "$UNPACK_DIR"
 
@@ -174,10 +175,10 @@ fi
 
 RESP=$(mcp_call "tools/list" "{}")
 TOOL_COUNT=$(echo "$RESP" | jq '.result.tools | length' 2>/dev/null || echo 0)
-if [ "$TOOL_COUNT" = "14" ]; then
-    log_pass "tools/list returns 14 tools"
+if [ "$TOOL_COUNT" = "21" ]; then
+    log_pass "tools/list returns 21 tools"
 else
-    log_fail "tools/list returned $TOOL_COUNT tools (expected 14)"
+    log_fail "tools/list returned $TOOL_COUNT tools (expected 21)"
 fi
 
 RESP=$(mcp_call "resources/list" "{}")
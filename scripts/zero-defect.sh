@@ -126,10 +126,10 @@ fi
 
 echo "Checking tool count..."
 TOOL_COUNT=$(echo "$MCP_TOOLS" | python3 -c "import sys,json; data=json.load(sys.stdin); print(len(data.get('result',{}).get('tools',[])))" 2>/dev/null || echo "0")
-if [ "$TOOL_COUNT" = "14" ]; then
-    log_pass "MCP tools/list returns 14 tools"
+if [ "$TOOL_COUNT" = "21" ]; then
+    log_pass "MCP tools/list returns 21 tools"
 else
-    log_fail "MCP tools/list returned $TOOL_COUNT tools, expected 14"
+    log_fail "MCP tools/list returned $TOOL_COUNT tools, expected 21"
 fi
 
 # ============================================================================